@@ -0,0 +1,25 @@
+package jsonschema
+
+// reduceAlways records in s.always, whether s accepts or rejects every
+// value, though it is not a boolean schema. Such schemas are validated
+// without evaluating their keywords:
+//
+//   - schema without assertions or applicators, such as {} or
+//     {"description": "..."}, accepts every value.
+//   - schema with "not" whose subschema accepts every value, such as
+//     {"not": {}}, rejects every value.
+//
+// subschemas are compiled before s, except in cycles, in which case
+// they are not considered.
+func (s *Schema) reduceAlways() {
+	if s.always != nil {
+		return
+	}
+	if s.Ref == nil && s.unconstrained() {
+		always := true
+		s.always = &always
+	} else if s.Not != nil && s.Not.always != nil && *s.Not.always {
+		always := false
+		s.always = &always
+	}
+}
@@ -0,0 +1,107 @@
+package jsonschema_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+func TestValidate_always(t *testing.T) {
+	tests := []struct {
+		schema string
+		doc    string
+		valid  bool
+	}{
+		{`{}`, `{"a": 1}`, true},
+		{`{"description": "anything"}`, `[1, "a"]`, true},
+		{`{"not": {}}`, `1`, false},
+		{`{"not": {"title": "anything"}, "type": "string"}`, `"abc"`, false},
+		{`{"not": {"not": {}}}`, `1`, true},
+
+		// subschemas accepting everything, evaluate everything
+		{`{"properties": {"a": {}}, "unevaluatedProperties": false}`, `{"a": 1}`, true},
+		{`{"properties": {"a": {}}, "unevaluatedProperties": false}`, `{"b": 1}`, false},
+		{`{"additionalProperties": {"title": "any"}, "unevaluatedProperties": false}`, `{"a": 1, "b": [2]}`, true},
+		{`{"patternProperties": {"^a": true}, "unevaluatedProperties": false}`, `{"ab": 1, "b": 2}`, false},
+		{`{"items": {}, "unevaluatedItems": false}`, `[1, 2, 3]`, true},
+		{`{"prefixItems": [{}], "unevaluatedItems": false}`, `[1]`, true},
+		{`{"prefixItems": [{}], "unevaluatedItems": false}`, `[1, 2]`, false},
+		{`{"contains": {}, "unevaluatedItems": false}`, `[1, 2]`, true},
+
+		// in place, they evaluate nothing
+		{`{"allOf": [{"description": "any"}], "unevaluatedProperties": false}`, `{"a": 1}`, false},
+		{`{"anyOf": [true], "unevaluatedItems": false}`, `[1]`, false},
+		{`{"allOf": [{"properties": {"a": {}}}, {}], "unevaluatedProperties": false}`, `{"a": 1}`, true},
+	}
+	for i, test := range tests {
+		c := jsonschema.NewCompiler()
+		c.Draft = jsonschema.Draft2020
+		url := fmt.Sprintf("schema%d.json", i)
+		if err := c.AddResource(url, strings.NewReader(test.schema)); err != nil {
+			t.Fatal(err)
+		}
+		sch, err := c.Compile(url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = sch.Validate(decodeString(t, test.doc))
+		if valid := err == nil; valid != test.valid {
+			t.Errorf("%s with %s: got valid %v, want %v: %v", test.schema, test.doc, valid, test.valid, err)
+		}
+	}
+}
+
+func TestValidate_alwaysError(t *testing.T) {
+	sch := jsonschema.MustCompileString("schema.json", `{"properties": {"a": {"not": {}}, "b": false}}`)
+	err := sch.Validate(decodeString(t, `{"a": 1, "b": 2}`))
+	ve, ok := err.(*jsonschema.ValidationError)
+	if !ok {
+		t.Fatalf("got %v, want *ValidationError", err)
+	}
+	got := map[string]string{}
+	for _, cause := range ve.Causes {
+		got[cause.KeywordLocation] = cause.Keyword
+	}
+	want := map[string]string{"/properties/a/not": "not", "/properties/b": "false"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestClone_always(t *testing.T) {
+	sch := jsonschema.MustCompileString("schema.json", `{"description": "any string"}`)
+	clone := sch.Clone()
+	clone.MinLength = 3
+	if err := clone.Validate("ab"); err == nil {
+		t.Fatal("want error")
+	}
+	if err := sch.Validate("ab"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func BenchmarkValidate_always(b *testing.B) {
+	sch := jsonschema.MustCompileString("schema.json", `{
+		"type": "object",
+		"additionalProperties": {"description": "any value"},
+		"properties": {
+			"items": {"type": "array", "items": {"description": "any item"}}
+		}
+	}`)
+	doc := make(map[string]interface{})
+	items := make([]interface{}, 1000)
+	for i := range items {
+		items[i] = map[string]interface{}{"id": i, "tags": []interface{}{"a", "b"}}
+		doc[fmt.Sprint("p", i)] = items[i]
+	}
+	doc["items"] = items
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := sch.Validate(doc); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package jsonschema
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// BatchOptions controls the validation by Schema.ValidateBatch.
+type BatchOptions struct {
+	// Parallelism is the number of goroutines validating the documents.
+	// Values less than 1 mean 1.
+	Parallelism int
+
+	// FailFastPerDoc stops validation of a document at its first error.
+	FailFastPerDoc bool
+}
+
+// BatchResult is the result of validating a document by Schema.ValidateBatch.
+type BatchResult struct {
+	Index int   // index of the document in batch
+	Valid bool  // whether the document is valid
+	Err   error // error returned by Validate. nil if Valid
+}
+
+// ValidateBatch validates each document in docs, and returns their results
+// in the same order as docs, irrespective of opts.Parallelism.
+//
+// This is faster than calling Validate for each document, because
+// recovering from panics is set up once per goroutine, and the stack of
+// schemas, used to track dynamic scope, is allocated once per goroutine
+// and reused across documents.
+func (s *Schema) ValidateBatch(docs []interface{}, opts BatchOptions) []BatchResult {
+	results := make([]BatchResult, len(docs))
+	var index int64 = -1
+	next := func() int {
+		return int(atomic.AddInt64(&index, 1))
+	}
+	vopts := ValidateOptions{FailFast: opts.FailFastPerDoc}
+	if opts.Parallelism <= 1 {
+		s.batchWorker(&validation{opts: vopts}, docs, results, next)
+		return results
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < opts.Parallelism; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.batchWorker(&validation{opts: vopts}, docs, results, next)
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+// batchWorker validates documents at indexes returned by next, until they are exhausted.
+func (s *Schema) batchWorker(vd *validation, docs []interface{}, results []BatchResult, next func() int) {
+	for !s.batchRun(vd, docs, results, next) {
+	}
+}
+
+// batchRun is batchWorker, which returns false, if it is stopped
+// by panic in validation of a document. Thus recovering from panic
+// is set up once for many documents, rather than for each document.
+func (s *Schema) batchRun(vd *validation, docs []interface{}, results []BatchResult, next func() int) (done bool) {
+	i := -1
+	defer func() {
+		if r := recover(); r != nil {
+			results[i] = BatchResult{Index: i, Err: recoverError(r)}
+		}
+	}()
+	for i = next(); i < len(docs); i = next() {
+		for vloc := range vd.natives {
+			delete(vd.natives, vloc)
+		}
+		err := s.validateRoot(vd, docs[i], "")
+		results[i] = BatchResult{i, err == nil, err}
+	}
+	return true
+}
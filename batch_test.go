@@ -0,0 +1,107 @@
+package jsonschema_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+var batchSchema = `{
+	"type": "object",
+	"required": ["id", "name"],
+	"properties": {
+		"id": {"type": "integer", "minimum": 0},
+		"name": {"type": "string", "maxLength": 10},
+		"tags": {"type": "array", "items": {"type": "string"}}
+	}
+}`
+
+func batchDocs(n int) []interface{} {
+	docs := make([]interface{}, n)
+	for i := range docs {
+		switch i % 4 {
+		case 0:
+			docs[i] = map[string]interface{}{"id": i, "name": "doc", "tags": []interface{}{"a", "b"}}
+		case 1:
+			docs[i] = map[string]interface{}{"id": -i, "name": "a very long name", "tags": []interface{}{1}}
+		case 2:
+			docs[i] = map[string]interface{}{"id": i, "name": struct{}{}}
+		default:
+			docs[i] = []interface{}{i}
+		}
+	}
+	return docs
+}
+
+func TestSchema_ValidateBatch(t *testing.T) {
+	sch := jsonschema.MustCompileString("batch.json", batchSchema)
+	docs := batchDocs(1000)
+	for _, opts := range []jsonschema.BatchOptions{
+		{},
+		{Parallelism: 4},
+		{Parallelism: 4, FailFastPerDoc: true},
+	} {
+		t.Run(fmt.Sprintf("%+v", opts), func(t *testing.T) {
+			results := sch.ValidateBatch(docs, opts)
+			if len(results) != len(docs) {
+				t.Fatalf("results: got %d, want %d", len(results), len(docs))
+			}
+			for i, result := range results {
+				if result.Index != i {
+					t.Fatalf("results[%d].Index: got %d", i, result.Index)
+				}
+				want := sch.Validate(docs[i])
+				if result.Valid != (want == nil) {
+					t.Fatalf("results[%d].Valid: got %v, want %v", i, result.Valid, want == nil)
+				}
+				switch want := want.(type) {
+				case nil:
+					if result.Err != nil {
+						t.Fatalf("results[%d].Err: got %v", i, result.Err)
+					}
+				case *jsonschema.ValidationError:
+					ve, ok := result.Err.(*jsonschema.ValidationError)
+					if !ok {
+						t.Fatalf("results[%d].Err: got %v, want *ValidationError", i, result.Err)
+					}
+					if n := len(ve.BasicOutput().Errors); opts.FailFastPerDoc && n != 2 {
+						t.Fatalf("results[%d].Err: got %d errors, want 2\n%#v", i, n, ve)
+					} else if !opts.FailFastPerDoc && n != len(want.BasicOutput().Errors) {
+						t.Fatalf("results[%d].Err: got %d errors, want %d", i, n, len(want.BasicOutput().Errors))
+					}
+				default:
+					if result.Err != want {
+						t.Fatalf("results[%d].Err: got %v, want %v", i, result.Err, want)
+					}
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkValidateBatch(b *testing.B) {
+	sch := jsonschema.MustCompileString("batch.json", batchSchema)
+	docs := batchDocs(10000)[:0]
+	for _, doc := range batchDocs(10000) {
+		if doc, ok := doc.(map[string]interface{}); !ok || doc["name"] != struct{}{} {
+			docs = append(docs, doc)
+		}
+	}
+	b.Run("loop", func(b *testing.B) {
+		b.ReportAllocs()
+		for n := 0; n < b.N; n++ {
+			for _, doc := range docs {
+				_ = sch.Validate(doc)
+			}
+		}
+	})
+	for _, parallelism := range []int{1, 4} {
+		b.Run(fmt.Sprintf("parallelism=%d", parallelism), func(b *testing.B) {
+			b.ReportAllocs()
+			for n := 0; n < b.N; n++ {
+				sch.ValidateBatch(docs, jsonschema.BatchOptions{Parallelism: parallelism})
+			}
+		})
+	}
+}
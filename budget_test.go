@@ -0,0 +1,73 @@
+package jsonschema_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+func TestValidateOptions_MaxEvaluations(t *testing.T) {
+	// each item is tried against 4^4 leaf schemas
+	fanout := `{"anyOf": [{"type": "null"}, {"type": "boolean"}, {"type": "object"}, {"type": "array"}]}`
+	for i := 0; i < 3; i++ {
+		fanout = `{"anyOf": [` + strings.Repeat(fanout+",", 3) + fanout + `]}`
+	}
+	sch, err := jsonschema.CompileString("schema.json", `{"items": `+fanout+`}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc := make([]interface{}, 1000)
+	for i := range doc {
+		doc[i] = "not matching"
+	}
+
+	start := time.Now()
+	err = sch.ValidateWith(doc, jsonschema.ValidateOptions{MaxEvaluations: 1000})
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("took %v to fail", elapsed)
+	}
+	if !errors.Is(err, jsonschema.ErrBudgetExceeded) {
+		t.Fatalf("got %v, want ErrBudgetExceeded", err)
+	}
+	le, ok := err.(*jsonschema.LimitError)
+	if !ok {
+		t.Fatalf("got %#v, want *LimitError", err)
+	}
+	if le.Limit != "MaxEvaluations" || le.Max != 1000 {
+		t.Fatalf("got limit %s=%d", le.Limit, le.Max)
+	}
+	if le.InstanceLocation != "/2" {
+		t.Fatalf("InstanceLocation: got %q, want %q", le.InstanceLocation, "/2")
+	}
+	if !strings.HasPrefix(le.AbsoluteKeywordLocation, "file://") || !strings.Contains(le.AbsoluteKeywordLocation, "#/items/anyOf") {
+		t.Fatalf("AbsoluteKeywordLocation: got %q", le.AbsoluteKeywordLocation)
+	}
+
+	// within budget
+	opts := jsonschema.ValidateOptions{MaxEvaluations: 1000}
+	if err := sch.ValidateWith(doc[:2], opts); err == nil || errors.Is(err, jsonschema.ErrBudgetExceeded) {
+		t.Fatalf("got %v, want *ValidationError", err)
+	}
+	if err := sch.ValidateWith([]interface{}{nil, true}, opts); err != nil {
+		t.Fatal(err)
+	}
+
+	// budget is per validation
+	for i := 0; i < 3; i++ {
+		if err := sch.ValidateWith([]interface{}{nil, true}, opts); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestErrBudgetExceeded(t *testing.T) {
+	if errors.Is(&jsonschema.LimitError{Limit: "MaxItems"}, jsonschema.ErrBudgetExceeded) {
+		t.Fatal("MaxItems must not match ErrBudgetExceeded")
+	}
+	if !errors.Is(&jsonschema.LimitError{Limit: "MaxComparisons"}, jsonschema.ErrBudgetExceeded) {
+		t.Fatal("MaxComparisons must match ErrBudgetExceeded")
+	}
+}
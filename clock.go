@@ -0,0 +1,72 @@
+package jsonschema
+
+import (
+	"strings"
+	"time"
+)
+
+// TimeFormats is a registry of functions, which validate a format relative
+// to the current time, as given by ValidateOptions.Now. They are used only
+// by the compilers with EnableTimeFormats, and take precedence over Formats.
+//
+// New formats can be registered by adding to this map.
+var TimeFormats = map[string]func(v interface{}, now time.Time) bool{
+	"date-time-future": isDateTimeFuture,
+	"date-time-past":   isDateTimePast,
+}
+
+// now returns the current time, as per vd.opts.Now.
+func (vd *validation) now() time.Time {
+	if vd.opts.Now != nil {
+		return vd.opts.Now()
+	}
+	return time.Now()
+}
+
+// validFormat tells whether v is valid as per format of s.
+func (vd *validation) validFormat(s *Schema, v interface{}) bool {
+	if s.timeFormat != nil {
+		return s.timeFormat(v, vd.now())
+	}
+	return s.format(v)
+}
+
+// isDateTimeFuture tells whether given string is a valid date-time,
+// which is after now.
+func isDateTimeFuture(v interface{}, now time.Time) bool {
+	s, ok := v.(string)
+	if !ok {
+		return true
+	}
+	t, ok := parseDateTime(s)
+	return ok && t.After(now)
+}
+
+// isDateTimePast tells whether given string is a valid date-time,
+// which is before now.
+func isDateTimePast(v interface{}, now time.Time) bool {
+	s, ok := v.(string)
+	if !ok {
+		return true
+	}
+	t, ok := parseDateTime(s)
+	return ok && t.Before(now)
+}
+
+// parseDateTime parses RFC 3339 date-time s. A leap second is parsed
+// as the first second of next minute, since time.Time cannot represent it.
+func parseDateTime(s string) (time.Time, bool) {
+	if !isDateTime(s) {
+		return time.Time{}, false
+	}
+	s = strings.ToUpper(s)
+	var leap time.Duration
+	if s[17:19] == "60" {
+		s, leap = s[:17]+"59"+s[19:], time.Second
+	}
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t.Add(leap), true
+}
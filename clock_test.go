@@ -0,0 +1,123 @@
+package jsonschema_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+func TestTimeFormats(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	opts := jsonschema.ValidateOptions{Now: func() time.Time { return now }}
+
+	compile := func(format string) *jsonschema.Schema {
+		t.Helper()
+		c := jsonschema.NewCompiler()
+		c.AssertFormat = true
+		c.EnableTimeFormats = true
+		if err := c.AddResource("schema.json", strings.NewReader(`{"format": "`+format+`"}`)); err != nil {
+			t.Fatal(err)
+		}
+		sch, err := c.Compile("schema.json")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return sch
+	}
+	future, past := compile("date-time-future"), compile("date-time-past")
+
+	tests := []struct {
+		value  interface{}
+		future bool
+		past   bool
+	}{
+		{"2026-01-01T00:00:00Z", false, false}, // exactly now
+		{"2026-01-01T00:00:00.000000001Z", true, false},
+		{"2025-12-31T23:59:59.999999999Z", false, true},
+		{"2026-01-01T05:30:00+05:30", false, false}, // now in another zone
+		{"2026-01-01T05:30:01+05:30", true, false},
+		{"2025-12-31T19:00:00-05:00", false, false},
+		{"2025-12-31T18:59:59-05:00", false, true},
+		{"2025-12-31t23:59:60z", false, false}, // leap second is now
+		{"2025-12-31", false, false},           // not date-time
+		{"tomorrow", false, false},
+		{42, true, true}, // not string
+	}
+	for _, test := range tests {
+		if got := future.ValidateWith(test.value, opts) == nil; got != test.future {
+			t.Errorf("date-time-future %v: got %v, want %v", test.value, got, test.future)
+		}
+		if got := past.ValidateWith(test.value, opts) == nil; got != test.past {
+			t.Errorf("date-time-past %v: got %v, want %v", test.value, got, test.past)
+		}
+	}
+
+	t.Run("defaultClock", func(t *testing.T) {
+		if err := future.Validate(time.Now().Add(time.Hour).Format(time.RFC3339)); err != nil {
+			t.Fatal(err)
+		}
+		if err := future.Validate("2000-01-01T00:00:00Z"); err == nil {
+			t.Fatal("want error")
+		}
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		c := jsonschema.NewCompiler()
+		c.AssertFormat = true
+		if err := c.AddResource("schema.json", strings.NewReader(`{"format": "date-time-future"}`)); err != nil {
+			t.Fatal(err)
+		}
+		sch, err := c.Compile("schema.json")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := sch.ValidateWith("2000-01-01T00:00:00Z", opts); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+type notBeforeCompiler struct{}
+
+func (notBeforeCompiler) Compile(ctx jsonschema.CompilerContext, m map[string]interface{}) (jsonschema.ExtSchema, error) {
+	if v, ok := m["x-notBefore"]; ok && v == "now" {
+		return notBeforeSchema{}, nil
+	}
+	return nil, nil
+}
+
+type notBeforeSchema struct{}
+
+func (notBeforeSchema) Validate(ctx jsonschema.ValidationContext, v interface{}) error {
+	s, ok := v.(string)
+	if !ok {
+		return nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil || t.Before(ctx.Now()) {
+		return ctx.Error("x-notBefore", "%s is before now", s)
+	}
+	return nil
+}
+
+func TestValidationContext_Now(t *testing.T) {
+	c := jsonschema.NewCompiler()
+	c.RegisterExtension("x-notBefore", nil, notBeforeCompiler{})
+	if err := c.AddResource("schema.json", strings.NewReader(`{"x-notBefore": "now"}`)); err != nil {
+		t.Fatal(err)
+	}
+	sch, err := c.Compile("schema.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	opts := jsonschema.ValidateOptions{Now: func() time.Time { return now }}
+	if err := sch.ValidateWith("2026-01-01T00:00:00Z", opts); err != nil {
+		t.Fatal(err)
+	}
+	if err := sch.ValidateWith("2025-12-31T23:59:59Z", opts); err == nil {
+		t.Fatal("want error")
+	}
+}
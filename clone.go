@@ -0,0 +1,217 @@
+package jsonschema
+
+import (
+	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
+	"text/template"
+)
+
+// Clone returns a deep copy of s, in which every schema reachable
+// from s is copied. Schemas shared within s, including cycles, are
+// shared the same way within the copy.
+//
+// The copy is independent of s, so it can be mutated while s is being
+// used for validation. Mutate fields which have internal state, only
+// using the setters such as SetEnum and SetPattern.
+//
+// Extensions are shared with s, because ExtSchema is opaque.
+func (s *Schema) Clone() *Schema {
+	c := &cloner{clones: make(map[*Schema]*Schema)}
+	return c.clone(s)
+}
+
+// cloner deep copies schemas, remembering the copies made.
+type cloner struct {
+	clones map[*Schema]*Schema // original to its copy
+}
+
+func (c *cloner) clone(s *Schema) *Schema {
+	if s == nil {
+		return nil
+	}
+	if sch, ok := c.clones[s]; ok {
+		return sch
+	}
+	sch := new(Schema)
+	*sch = *s
+	c.clones[s] = sch
+
+	if s.Messages != nil {
+		sch.Messages = make(map[string]*template.Template, len(s.Messages))
+		for k, t := range s.Messages {
+			sch.Messages[k] = t
+		}
+	}
+	sch.dynamicAnchors = c.cloneSlice(s.dynamicAnchors)
+	if s.Always != nil {
+		always := *s.Always
+		sch.Always = &always
+	}
+	// the copy may be mutated, so it is not reduced
+	sch.always = sch.Always
+	sch.Ref = c.clone(s.Ref)
+	sch.refChain = c.cloneSlice(s.refChain)
+	sch.RecursiveRef = c.clone(s.RecursiveRef)
+	sch.DynamicRef = c.clone(s.DynamicRef)
+	sch.Types = append([]string(nil), s.Types...)
+	sch.Constant = append([]interface{}(nil), s.Constant...)
+	sch.Enum = append([]interface{}(nil), s.Enum...)
+	sch.Not = c.clone(s.Not)
+	sch.AllOf = c.cloneSlice(s.AllOf)
+	sch.AnyOf = c.cloneSlice(s.AnyOf)
+	sch.OneOf = c.cloneSlice(s.OneOf)
+	sch.If = c.clone(s.If)
+	sch.Then = c.clone(s.Then)
+	sch.Else = c.clone(s.Else)
+
+	sch.Required = append([]string(nil), s.Required...)
+	sch.UniqueKeys = append([]string(nil), s.UniqueKeys...)
+	sch.Properties = c.cloneMap(s.Properties)
+	sch.PropertyNames = c.clone(s.PropertyNames)
+	if s.PatternProperties != nil {
+		sch.PatternProperties = make(map[*regexp.Regexp]*Schema, len(s.PatternProperties))
+		for re, ps := range s.PatternProperties {
+			sch.PatternProperties[re] = c.clone(ps)
+		}
+	}
+	sch.AdditionalProperties = c.cloneValue(s.AdditionalProperties)
+	if s.Dependencies != nil {
+		sch.Dependencies = make(map[string]interface{}, len(s.Dependencies))
+		for pname, dep := range s.Dependencies {
+			sch.Dependencies[pname] = c.cloneValue(dep)
+		}
+	}
+	if s.DependentRequired != nil {
+		sch.DependentRequired = make(map[string][]string, len(s.DependentRequired))
+		for pname, required := range s.DependentRequired {
+			sch.DependentRequired[pname] = append([]string(nil), required...)
+		}
+	}
+	sch.DependentSchemas = c.cloneMap(s.DependentSchemas)
+	sch.UnevaluatedProperties = c.clone(s.UnevaluatedProperties)
+
+	sch.Items = c.cloneValue(s.Items)
+	sch.AdditionalItems = c.cloneValue(s.AdditionalItems)
+	sch.PrefixItems = c.cloneSlice(s.PrefixItems)
+	sch.Items2020 = c.clone(s.Items2020)
+	sch.Contains = c.clone(s.Contains)
+	sch.UnevaluatedItems = c.clone(s.UnevaluatedItems)
+
+	sch.Minimum = cloneRat(s.Minimum)
+	sch.ExclusiveMinimum = cloneRat(s.ExclusiveMinimum)
+	sch.Maximum = cloneRat(s.Maximum)
+	sch.ExclusiveMaximum = cloneRat(s.ExclusiveMaximum)
+	sch.MultipleOf = cloneRat(s.MultipleOf)
+	sch.cacheBounds()
+
+	sch.Examples = append([]interface{}(nil), s.Examples...)
+	if s.Extensions != nil {
+		sch.Extensions = make(map[string]ExtSchema, len(s.Extensions))
+		for name, ext := range s.Extensions {
+			sch.Extensions[name] = ext
+		}
+	}
+	return sch
+}
+
+func (c *cloner) cloneSlice(schemas []*Schema) []*Schema {
+	if schemas == nil {
+		return nil
+	}
+	clones := make([]*Schema, len(schemas))
+	for i, sch := range schemas {
+		clones[i] = c.clone(sch)
+	}
+	return clones
+}
+
+func (c *cloner) cloneMap(schemas map[string]*Schema) map[string]*Schema {
+	if schemas == nil {
+		return nil
+	}
+	clones := make(map[string]*Schema, len(schemas))
+	for k, sch := range schemas {
+		clones[k] = c.clone(sch)
+	}
+	return clones
+}
+
+// cloneValue clones the fields of type interface{}, such as Items.
+func (c *cloner) cloneValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case *Schema:
+		return c.clone(v)
+	case []*Schema:
+		return c.cloneSlice(v)
+	case []string:
+		return append([]string(nil), v...)
+	default:
+		return v
+	}
+}
+
+func cloneRat(r *big.Rat) *big.Rat {
+	if r == nil {
+		return nil
+	}
+	return new(big.Rat).Set(r)
+}
+
+// SetEnum sets Enum to given values, which must be valid json values.
+// It also updates the error message used when enum fails.
+func (s *Schema) SetEnum(values []interface{}) {
+	s.Enum = values
+	s.enumError = enumMessage(s.Enum, s.enumLimit)
+	s.narrowTypes()
+}
+
+// enumMessage returns the error message for enum fail, listing at most
+// limit values. zero limit means 10 and negative limit means no limit.
+func enumMessage(enum []interface{}, limit int) string {
+	for _, item := range enum {
+		switch jsonType(item) {
+		case "object", "array":
+			return "enum failed"
+		}
+	}
+	if len(enum) == 1 {
+		return fmt.Sprintf("value must be %#v", enum[0])
+	}
+	if limit == 0 {
+		limit = 10
+	}
+	n := len(enum)
+	if limit > 0 && n > limit {
+		n = limit
+	}
+	strEnum := make([]string, n)
+	for i, item := range enum[:n] {
+		strEnum[i] = fmt.Sprintf("%#v", item)
+	}
+	msg := fmt.Sprintf("value must be one of %s", strings.Join(strEnum, ", "))
+	if n < len(enum) {
+		msg += fmt.Sprintf(", … (%d total)", len(enum))
+	}
+	return msg
+}
+
+// SetPattern compiles given regex, and sets it as Pattern.
+// Empty pattern removes the Pattern. The regex is anchored to match
+// whole string, if s is compiled with Compiler.FullMatchPatterns.
+func (s *Schema) SetPattern(pattern string) error {
+	if pattern == "" {
+		s.Pattern = nil
+		return nil
+	}
+	if s.fullMatch {
+		pattern = "^(?:" + pattern + ")$"
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return err
+	}
+	s.Pattern = re
+	return nil
+}
@@ -0,0 +1,152 @@
+package jsonschema_test
+
+import (
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+const cloneSchema = `{
+	"$defs": {
+		"node": {
+			"type": "object",
+			"properties": {
+				"color": {"enum": ["red", "green"]},
+				"name": {"type": "string", "maxLength": 5, "pattern": "^[a-z]+$"},
+				"children": {"type": "array", "items": {"$ref": "#/$defs/node"}}
+			}
+		}
+	},
+	"$ref": "#/$defs/node"
+}`
+
+func TestSchema_Clone(t *testing.T) {
+	sch := jsonschema.MustCompileString("clone.json", cloneSchema)
+	clone := sch.Clone()
+	if clone == sch {
+		t.Fatal("clone must be different from original")
+	}
+
+	docs := []string{
+		`{"color": "red", "name": "abc"}`,
+		`{"color": "blue"}`,
+		`{"name": "abcdef"}`,
+		`{"children": [{"children": [{"name": "ABC"}]}]}`,
+		`{"children": [{"color": "green"}]}`,
+	}
+	for _, doc := range docs {
+		v := decodeString(t, doc)
+		err1, err2 := sch.Validate(v), clone.Validate(v)
+		if (err1 == nil) != (err2 == nil) {
+			t.Fatalf("%s: original %v, clone %v", doc, err1, err2)
+		}
+	}
+
+	// cycle preserved within clone
+	node := clone.Ref
+	items := node.Properties["children"].Items2020
+	if items.Ref != node {
+		t.Fatal("cycle in clone must point to cloned schema")
+	}
+	if node == sch.Ref {
+		t.Fatal("subschemas must be cloned")
+	}
+}
+
+func TestSchema_Clone_mutate(t *testing.T) {
+	sch := jsonschema.MustCompileString("clone.json", cloneSchema)
+	clone := sch.Clone()
+	color := clone.Ref.Properties["color"]
+	color.SetEnum([]interface{}{"blue"})
+	name := clone.Ref.Properties["name"]
+	name.MaxLength = 10
+	if err := name.SetPattern("^[A-Z]+$"); err != nil {
+		t.Fatal(err)
+	}
+	if err := name.SetPattern("("); err == nil {
+		t.Fatal("error expected for invalid pattern")
+	}
+
+	tests := []struct {
+		doc          string
+		original, cl bool
+	}{
+		{`{"color": "red"}`, true, false},
+		{`{"color": "blue"}`, false, true},
+		{`{"name": "abc"}`, true, false},
+		{`{"name": "ABCDEFG"}`, false, true},
+		{`{"children": [{"color": "blue"}]}`, false, true},
+	}
+	for _, test := range tests {
+		v := decodeString(t, test.doc)
+		if got := sch.Validate(v) == nil; got != test.original {
+			t.Errorf("%s: original valid=%v, want %v", test.doc, got, test.original)
+		}
+		if got := clone.Validate(v) == nil; got != test.cl {
+			t.Errorf("%s: clone valid=%v, want %v", test.doc, got, test.cl)
+		}
+	}
+
+	err := clone.Validate(decodeString(t, `{"color": "red"}`))
+	if ve, ok := err.(*jsonschema.ValidationError); !ok || ve.Causes[0].Message != `value must be "blue"` {
+		t.Fatalf("got %#v", err)
+	}
+}
+
+func TestSchema_SetPattern_fullMatch(t *testing.T) {
+	for _, fullMatch := range []bool{false, true} {
+		c := jsonschema.NewCompiler()
+		c.FullMatchPatterns = fullMatch
+		if err := c.AddResource("schema.json", strings.NewReader(`{"pattern": "a"}`)); err != nil {
+			t.Fatal(err)
+		}
+		sch, err := c.Compile("schema.json")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := sch.SetPattern("b|c"); err != nil {
+			t.Fatal(err)
+		}
+		tests := []struct {
+			doc   string
+			valid bool
+		}{
+			{"b", true},
+			{"c", true},
+			{"abc", !fullMatch},
+			{"a", false},
+		}
+		for _, test := range tests {
+			if err := sch.Validate(test.doc); (err == nil) != test.valid {
+				t.Errorf("FullMatchPatterns=%v %q: got %v, want valid=%v", fullMatch, test.doc, err, test.valid)
+			}
+		}
+	}
+}
+
+func TestSchema_Clone_race(t *testing.T) {
+	sch := jsonschema.MustCompileString("clone.json", cloneSchema)
+	v := decodeString(t, `{"color": "red", "name": "abc", "children": [{"name": "xyz"}]}`)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			if err := sch.Validate(v); err != nil {
+				t.Error(err)
+				return
+			}
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		clone := sch.Clone()
+		clone.Ref.Properties["color"].SetEnum([]interface{}{"blue"})
+		clone.Ref.Properties["name"].MaxLength = 1
+		if err := clone.Ref.Properties["name"].SetPattern("^x"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	wg.Wait()
+}
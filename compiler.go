@@ -1,14 +1,18 @@
 package jsonschema
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"math/big"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"text/template"
+	"time"
 )
 
 // A Compiler represents a json-schema compiler.
@@ -22,6 +26,9 @@ type Compiler struct {
 	// Extensions is used to register extensions.
 	extensions map[string]extension
 
+	macros  map[string]Macro                  // registered with RegisterMacro, by keyword
+	formats map[string]func(interface{}) bool // registered with RegisterFormat, by name
+
 	// ExtractAnnotations tells whether schema annotations has to be extracted
 	// in compiled Schema or not.
 	ExtractAnnotations bool
@@ -31,10 +38,79 @@ type Compiler struct {
 	// If nil, package global LoadURL is used.
 	LoadURL func(s string) (io.ReadCloser, error)
 
+	// RefMappings maps url prefixes to the prefixes from which the
+	// resources are actually loaded. This is useful to serve schemas from
+	// local mirror, without editing them. If more than one prefix matches,
+	// the longest one is used.
+	//
+	// The mapping is applied only when loading. The schemas retain their
+	// original urls, which are used in resolving references and in errors.
+	RefMappings map[string]string
+
+	// URIOptions tightens the validation of uri, iri, uri-reference,
+	// iri-reference and uriref formats, in the schemas compiled.
+	URIOptions URIOptions
+
+	// SensitiveLocations lists the absolute locations of schemas, which must
+	// be treated as if they have "x-sensitive": true. for example
+	// "https://example.com/user.json#/properties/password".
+	SensitiveLocations []string
+
+	// EnableUniqueKeys enables keyword uniqueKeys in the schemas compiled.
+	// Its value is an array of json-pointers into array items, such as
+	// ["/id", "/region"]. No two items of array can have equal values at
+	// all of these pointers. The items not having any of the keys are
+	// ignored, unless RequireUniqueKeys is true.
+	EnableUniqueKeys bool
+
+	// RequireUniqueKeys fails validation of the array items not having
+	// any of the keys of uniqueKeys keyword.
+	RequireUniqueKeys bool
+
+	// EnableTimeFormats enables the formats in TimeFormats, such as
+	// date-time-future, in the schemas compiled.
+	EnableTimeFormats bool
+
+	// OnProgress, if not nil, is called synchronously with the events
+	// reporting progress of compilation. See CompileEvent for the order
+	// of events. If it panics, compilation fails with *SchemaError.
+	OnProgress func(ev CompileEvent)
+
+	// OnWarning, if not nil, is called synchronously with the problems
+	// found in schemas being compiled, which do not fail compilation.
+	// Currently it reports the keywords of allOf branches that contradict
+	// each other, such as "type": "string" and "type": "integer", and
+	// the patterns probably meant to match whole string, such as "[a-z]+".
+	// If it panics, compilation fails with *SchemaError.
+	OnWarning func(w CompileWarning)
+
+	// AllowJSONC decodes schema resources as JSONC, which allows comments
+	// and trailing commas. See DecodeJSONC. The documents validated are
+	// not affected.
+	AllowJSONC bool
+
+	// FullMatchPatterns makes the pattern keyword match whole string,
+	// rather than any substring, as if it is anchored with ^ and $.
+	// This is not as per specification, and does not apply to
+	// patternProperties. Schema.Pattern is the anchored regex.
+	FullMatchPatterns bool
+
+	// MaxEnumMessageValues limits the number of values listed in the error
+	// message of enum keyword. The rest are summarized with their count.
+	// Zero means 10 and negative means no limit.
+	MaxEnumMessageValues int
+
+	ctx      context.Context // context of current compilation
+	compiled map[string]int  // number of schemas compiled per resource, tracked for OnProgress
+
 	// AssertFormat for specifications >= draft2019-09.
+	// It can be overridden by ValidateOptions.AssertFormat.
 	AssertFormat bool
 
 	// AssertContent for specifications >= draft2019-09.
+	// When true, contentEncoding and contentMediaType are asserted for those
+	// drafts too; earlier releases ignored this field and never asserted them.
+	// It can be overridden by ValidateOptions.AssertContent.
 	AssertContent bool
 }
 
@@ -80,16 +156,78 @@ func NewCompiler() *Compiler {
 
 // AddResource adds in-memory resource to the compiler.
 //
-// Note that url must not have fragment
+// Note that url must not have fragment. The resource is decoded as JSONC,
+// if c.AllowJSONC is true. The urls of resources, $id and $ref
+// are compared in normal form, in which scheme and host are lowercase
+// and default port is omitted. Thus HTTPS://Example.com:443/a.json and
+// https://example.com/a.json refer to the same resource.
 func (c *Compiler) AddResource(url string, r io.Reader) error {
-	res, err := newResource(url, r)
+	res, err := newResource(url, r, c.AllowJSONC)
+	if err != nil {
+		return err
+	}
+	c.resources[normalizeURL(res.url)] = res
+	return nil
+}
+
+// AddResourceInterface adds in-memory resource, which is already decoded,
+// such as by yaml decoder, to the compiler. doc must be encodable by
+// encoding/json. ResourceInfo.Hash of such resource, is hash of its
+// canonical json encoding, in which object keys are sorted.
+func (c *Compiler) AddResourceInterface(url string, doc interface{}) error {
+	b, err := json.Marshal(doc)
+	if err != nil {
+		return fmt.Errorf("jsonschema: invalid json %s: %v", url, err)
+	}
+	return c.AddResource(url, bytes.NewReader(b))
+}
+
+// AddDocument adds in-memory document, which is not a schema but embeds
+// schemas at given json-pointers, such as OpenAPI document, to the
+// compiler. A pointer ending with "/*" refers to each member of the object
+// or array at its prefix, for example "/components/schemas/*".
+//
+// The embedded schemas are compiled with fragment, such as
+// url+"#/components/schemas/Address", and their references are resolved
+// against the document. Rest of the document is never treated as schema;
+// compiling or referring to it fails. The draft of embedded schemas is
+// c.Draft, and macros are not expanded in them.
+func (c *Compiler) AddDocument(url string, r io.Reader, schemaPointers []string) error {
+	res, err := newResource(url, r, c.AllowJSONC)
 	if err != nil {
 		return err
 	}
-	c.resources[res.url] = res
+	locs, err := schemaLocations(res.doc, schemaPointers)
+	if err != nil {
+		return fmt.Errorf("jsonschema: invalid schema pointer in %s: %v", url, err)
+	}
+	// non-nil, even if there are no schemas
+	res.schemaLocs = append([]string{}, locs...)
+	c.resources[normalizeURL(res.url)] = res
 	return nil
 }
 
+// ResourceInfo describes a resource added to or loaded by Compiler.
+type ResourceInfo struct {
+	URL    string    // url with which resource is added or loaded, in normal form
+	Hash   string    // hex encoded sha256 of the raw bytes of resource
+	Loaded time.Time // time at which resource is added or loaded
+}
+
+// Resources returns the resources added to or loaded by c, sorted by url.
+// The hashes are stable across processes, so they can be used to detect
+// whether the content of schemas has changed.
+func (c *Compiler) Resources() []ResourceInfo {
+	infos := make([]ResourceInfo, 0, len(c.resources))
+	for url, r := range c.resources {
+		infos = append(infos, ResourceInfo{URL: url, Hash: r.hash, Loaded: r.loaded})
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].URL < infos[j].URL
+	})
+	return infos
+}
+
 // MustCompile is like Compile but panics if the url cannot be compiled to *Schema.
 // It simplifies safe initialization of global variables holding compiled Schemas.
 func (c *Compiler) MustCompile(url string) *Schema {
@@ -103,44 +241,98 @@ func (c *Compiler) MustCompile(url string) *Schema {
 // Compile parses json-schema at given url returns, if successful,
 // a Schema object that can be used to match against json.
 //
+// Chains of pure references are collapsed, so that Schema.Ref refers to
+// the end of chain. Thus validation errors do not have a "$ref" level for
+// each pure reference skipped, unless ValidateOptions.Trace is true.
+//
 // error returned will be of type *SchemaError
 func (c *Compiler) Compile(url string) (*Schema, error) {
+	return c.CompileContext(context.Background(), url)
+}
+
+// CompileContext is like Compile, but stops compilation when ctx is done.
+// The error returned wraps ctx.Err() in that case.
+//
+// The resources and schemas compiled before cancellation are retained
+// by compiler, so a cancelled compiler should not be reused.
+func (c *Compiler) CompileContext(ctx context.Context, url string) (*Schema, error) {
 	// make url absolute
 	u, err := toAbs(url)
 	if err != nil {
-		return nil, &SchemaError{url, err}
+		return nil, newSchemaError(url, err)
 	}
 	url = u
 
+	c.ctx = ctx
+	defer func() {
+		c.ctx = nil
+	}()
 	sch, err := c.compileURL(url, nil, "#")
 	if err != nil {
-		err = &SchemaError{url, err}
+		return nil, newSchemaError(url, err)
 	}
-	return sch, err
+	collapseRefs(sch)
+	return sch, nil
+}
+
+// mapURL returns the url from which the resource at given url is loaded.
+// url must be in normal form, see normalizeURL.
+func (c *Compiler) mapURL(url string) string {
+	var from, to string
+	for prefix, target := range c.RefMappings {
+		prefix = normalizeURL(prefix)
+		if strings.HasPrefix(url, prefix) && len(prefix) > len(from) {
+			from, to = prefix, target
+		}
+	}
+	if from == "" {
+		return url
+	}
+	return to + url[len(from):]
 }
 
 func (c *Compiler) findResource(url string) (*resource, error) {
-	if _, ok := c.resources[url]; !ok {
+	key := normalizeURL(url)
+	if _, ok := c.resources[key]; !ok {
 		// load resource
 		loadURL := LoadURL
 		if c.LoadURL != nil {
 			loadURL = c.LoadURL
 		}
-		rdr, err := loadURL(url)
-		if err != nil {
+		if err := c.ctx.Err(); err != nil {
+			return nil, err
+		}
+		if err := c.emit(CompileEvent{Kind: ResourceLoadStart, URL: url}); err != nil {
 			return nil, err
 		}
+		rdr, err := loadURL(c.mapURL(key))
+		if err != nil {
+			return nil, &ResourceLoadError{url, err}
+		}
 		defer rdr.Close()
-		if err := c.AddResource(url, rdr); err != nil {
+		cr := &countingReader{r: rdr}
+		if err := c.AddResource(url, cr); err != nil {
+			return nil, err
+		}
+		if err := c.emit(CompileEvent{Kind: ResourceLoadEnd, URL: url, Size: cr.n}); err != nil {
 			return nil, err
 		}
 	}
 
-	r := c.resources[url]
+	r := c.resources[key]
 	if r.draft != nil {
 		return r, nil
 	}
 
+	if r.schemaLocs != nil {
+		// document embedding schemas, which is not a schema itself
+		r.draft = c.Draft
+		if err := r.fillEmbedded(c); err != nil {
+			return nil, err
+		}
+		return r, nil
+	}
+
 	// set draft
 	r.draft = c.Draft
 	if m, ok := r.doc.(map[string]interface{}); ok {
@@ -155,6 +347,13 @@ func (c *Compiler) findResource(url string) (*resource, error) {
 		}
 	}
 
+	if err := c.expandMacros(r); err != nil {
+		return nil, err
+	}
+
+	if err := r.draft.checkID("#", r.doc); err != nil {
+		return nil, err
+	}
 	id, err := r.draft.resolveID(r.url, r.doc)
 	if err != nil {
 		return nil, err
@@ -172,7 +371,7 @@ func (c *Compiler) findResource(url string) (*resource, error) {
 
 func (c *Compiler) compileURL(url string, stack []schemaRef, ptr string) (*Schema, error) {
 	// if url points to a draft, return Draft.meta
-	if d := findDraft(url); d != nil && d.meta != nil {
+	if d := findDraft(normalizeURL(url)); d != nil && d.meta != nil {
 		return d.meta, nil
 	}
 
@@ -201,9 +400,6 @@ func (c *Compiler) compileRef(r *resource, stack []schemaRef, refPtr string, res
 	if err != nil {
 		return nil, err
 	}
-	if sr == nil {
-		return nil, fmt.Errorf("jsonschema: %s not found", ref)
-	}
 
 	if sr.schema != nil {
 		if err := checkLoop(stack, schemaRef{refPtr, sr.schema, false}); err != nil {
@@ -212,8 +408,23 @@ func (c *Compiler) compileRef(r *resource, stack []schemaRef, refPtr string, res
 		return sr.schema, nil
 	}
 
+	if err := c.ctx.Err(); err != nil {
+		return nil, err
+	}
 	sr.schema = newSchema(r.url, sr.floc, sr.doc)
-	return c.compile(r, stack, schemaRef{refPtr, sr.schema, false}, sr)
+	sr.schema.resourceHash = r.hash
+	if c.OnProgress == nil {
+		return c.compile(r, stack, schemaRef{refPtr, sr.schema, false}, sr)
+	}
+	if c.compiled == nil {
+		c.compiled = make(map[string]int)
+	}
+	c.compiled[r.url]++
+	sch, err := c.compile(r, stack, schemaRef{refPtr, sr.schema, false}, sr)
+	if err == nil && sr == r {
+		err = c.emit(CompileEvent{Kind: ResourceCompiled, URL: r.url, Schemas: c.compiled[r.url]})
+	}
+	return sch, err
 }
 
 func (c *Compiler) compileDynamicAnchors(r *resource, res *resource) error {
@@ -245,6 +456,7 @@ func (c *Compiler) compile(r *resource, stack []schemaRef, sref schemaRef, res *
 	switch v := res.doc.(type) {
 	case bool:
 		res.schema.Always = &v
+		res.schema.always = &v
 		return res.schema, nil
 	default:
 		return res.schema, c.compileMap(r, stack, sref, res)
@@ -262,11 +474,27 @@ func (c *Compiler) compileMap(r *resource, stack []schemaRef, sref schemaRef, re
 	var s = res.schema
 	var err error
 
+	// sensitive schema is honored, even if siblings of $ref are ignored
+	if sensitive, ok := m["x-sensitive"].(bool); ok && sensitive {
+		s.Sensitive = true
+	}
+	if writeOnly, ok := m["writeOnly"].(bool); ok && writeOnly && r.draft.version >= 7 {
+		s.Sensitive = true
+	}
+	for _, loc := range c.SensitiveLocations {
+		if loc == s.Location {
+			s.Sensitive = true
+		}
+	}
+
 	if ref, ok := m["$ref"]; ok {
 		s.Ref, err = c.compileRef(r, stack, "$ref", res, ref.(string))
 		if err != nil {
 			return err
 		}
+		if err := c.emit(CompileEvent{Kind: RefResolved, URL: s.Ref.Location, From: s.Location}); err != nil {
+			return err
+		}
 		if r.draft.version < 2019 {
 			// All other properties in a "$ref" object MUST be ignored
 			return nil
@@ -279,6 +507,9 @@ func (c *Compiler) compileMap(r *resource, stack []schemaRef, sref schemaRef, re
 			if err != nil {
 				return err
 			}
+			if err := c.emit(CompileEvent{Kind: RefResolved, URL: s.RecursiveRef.Location, From: s.Location}); err != nil {
+				return err
+			}
 		}
 	}
 	if r.draft.version >= 2020 {
@@ -287,6 +518,9 @@ func (c *Compiler) compileMap(r *resource, stack []schemaRef, sref schemaRef, re
 			if err != nil {
 				return err
 			}
+			if err := c.emit(CompileEvent{Kind: RefResolved, URL: s.DynamicRef.Location, From: s.Location}); err != nil {
+				return err
+			}
 		}
 	}
 
@@ -300,27 +534,8 @@ func (c *Compiler) compileMap(r *resource, stack []schemaRef, sref schemaRef, re
 	}
 
 	if e, ok := m["enum"]; ok {
-		s.Enum = e.([]interface{})
-		allPrimitives := true
-		for _, item := range s.Enum {
-			switch jsonType(item) {
-			case "object", "array":
-				allPrimitives = false
-				break
-			}
-		}
-		s.enumError = "enum failed"
-		if allPrimitives {
-			if len(s.Enum) == 1 {
-				s.enumError = fmt.Sprintf("value must be %#v", s.Enum[0])
-			} else {
-				strEnum := make([]string, len(s.Enum))
-				for i, item := range s.Enum {
-					strEnum[i] = fmt.Sprintf("%#v", item)
-				}
-				s.enumError = fmt.Sprintf("value must be one of %s", strings.Join(strEnum, ", "))
-			}
-		}
+		s.enumLimit = c.MaxEnumMessageValues
+		s.SetEnum(e.([]interface{}))
 	}
 
 	compile := func(stack []schemaRef, ptr string) (*Schema, error) {
@@ -391,7 +606,7 @@ func (c *Compiler) compileMap(r *resource, stack []schemaRef, sref schemaRef, re
 	if props, ok := m["properties"]; ok {
 		props := props.(map[string]interface{})
 		s.Properties = make(map[string]*Schema, len(props))
-		for pname := range props {
+		for _, pname := range sortedNames(props) {
 			s.Properties[pname], err = compile(nil, "properties/"+escape(pname))
 			if err != nil {
 				return err
@@ -406,12 +621,22 @@ func (c *Compiler) compileMap(r *resource, stack []schemaRef, sref schemaRef, re
 	if patternProps, ok := m["patternProperties"]; ok {
 		patternProps := patternProps.(map[string]interface{})
 		s.PatternProperties = make(map[*regexp.Regexp]*Schema, len(patternProps))
-		for pattern := range patternProps {
+		for _, pattern := range sortedNames(patternProps) {
 			s.PatternProperties[regexp.MustCompile(pattern)], err = compile(nil, "patternProperties/"+escape(pattern))
 			if err != nil {
 				return err
 			}
 		}
+		for pname, sch := range s.Properties {
+			for re, psch := range s.PatternProperties {
+				if re.MatchString(pname) && sameSchema(sch, psch) {
+					if s.dupPatterns == nil {
+						s.dupPatterns = make(map[string][]*regexp.Regexp)
+					}
+					s.dupPatterns[pname] = append(s.dupPatterns[pname], re)
+				}
+			}
+		}
 	}
 
 	if additionalProps, ok := m["additionalProperties"]; ok {
@@ -429,8 +654,8 @@ func (c *Compiler) compileMap(r *resource, stack []schemaRef, sref schemaRef, re
 	if deps, ok := m["dependencies"]; ok {
 		deps := deps.(map[string]interface{})
 		s.Dependencies = make(map[string]interface{}, len(deps))
-		for pname, pvalue := range deps {
-			switch pvalue := pvalue.(type) {
+		for _, pname := range sortedNames(deps) {
+			switch pvalue := deps[pname].(type) {
 			case []interface{}:
 				s.Dependencies[pname] = toStrings(pvalue)
 			default:
@@ -453,7 +678,7 @@ func (c *Compiler) compileMap(r *resource, stack []schemaRef, sref schemaRef, re
 		if deps, ok := m["dependentSchemas"]; ok {
 			deps := deps.(map[string]interface{})
 			s.DependentSchemas = make(map[string]*Schema, len(deps))
-			for pname := range deps {
+			for _, pname := range sortedNames(deps) {
 				s.DependentSchemas[pname], err = compile(stack, "dependentSchemas/"+escape(pname))
 				if err != nil {
 					return err
@@ -473,6 +698,12 @@ func (c *Compiler) compileMap(r *resource, stack []schemaRef, sref schemaRef, re
 	if unique, ok := m["uniqueItems"]; ok {
 		s.UniqueItems = unique.(bool)
 	}
+	if keys, ok := m["uniqueKeys"]; ok && c.EnableUniqueKeys {
+		if s.UniqueKeys, err = loadUniqueKeys(keys); err != nil {
+			return fmt.Errorf("jsonschema: invalid uniqueKeys in %s: %v", s.Location, err)
+		}
+		s.keysRequired = c.RequireUniqueKeys
+	}
 
 	if r.draft.version >= 2020 {
 		if s.PrefixItems, err = loadSchemas("prefixItems", nil); err != nil {
@@ -511,13 +742,37 @@ func (c *Compiler) compileMap(r *resource, stack []schemaRef, sref schemaRef, re
 
 	s.MinLength, s.MaxLength = loadInt("minLength"), loadInt("maxLength")
 
+	s.fullMatch = c.FullMatchPatterns
 	if pattern, ok := m["pattern"]; ok {
-		s.Pattern = regexp.MustCompile(pattern.(string))
+		if c.FullMatchPatterns {
+			s.Pattern = regexp.MustCompile("^(?:" + pattern.(string) + ")$")
+		} else {
+			s.Pattern = regexp.MustCompile(pattern.(string))
+			if c.OnWarning != nil {
+				if err := c.warnPattern(s, pattern.(string)); err != nil {
+					return err
+				}
+			}
+		}
 	}
 
 	if format, ok := m["format"]; ok {
 		s.Format = format.(string)
-		s.format, _ = Formats[s.Format]
+		if f, ok := c.formats[s.Format]; ok {
+			s.format = f
+		} else {
+			s.format, _ = Formats[s.Format]
+			if c.EnableTimeFormats {
+				s.timeFormat, _ = TimeFormats[s.Format]
+			}
+		}
+		if s.format != nil && uriFormats[s.Format] && !c.URIOptions.isZero() {
+			format, opts := s.format, c.URIOptions
+			opts.AllowedSchemes = append([]string(nil), opts.AllowedSchemes...)
+			s.format = func(v interface{}) bool {
+				return format(v) && opts.check(v)
+			}
+		}
 	}
 
 	loadRat := func(pname string) *big.Rat {
@@ -551,6 +806,7 @@ func (c *Compiler) compileMap(r *resource, stack []schemaRef, sref schemaRef, re
 	}
 
 	s.MultipleOf = loadRat("multipleOf")
+	s.cacheBounds()
 
 	if c.ExtractAnnotations {
 		if title, ok := m["title"]; ok {
@@ -615,12 +871,9 @@ func (c *Compiler) compileMap(r *resource, stack []schemaRef, sref schemaRef, re
 		}
 	}
 
+	s.assertFormat, s.assertContent = true, true
 	if r.draft.version >= 2019 {
-		s.decoder = nil
-		s.mediaType = nil
-		if !c.AssertFormat {
-			s.format = nil
-		}
+		s.assertFormat, s.assertContent = c.AssertFormat, c.AssertContent
 
 		s.MinContains, s.MaxContains = loadInt("minContains"), loadInt("maxContains")
 		if s.MinContains == -1 {
@@ -647,6 +900,17 @@ func (c *Compiler) compileMap(r *resource, stack []schemaRef, sref schemaRef, re
 		}
 	}
 
+	dropped := s.narrowTypes()
+	if c.OnWarning != nil {
+		if err := c.warnNarrowed(s, dropped); err != nil {
+			return err
+		}
+		if err := c.warnConflicts(s); err != nil {
+			return err
+		}
+	}
+
+	s.reduceAlways()
 	return nil
 }
 
@@ -655,20 +919,54 @@ func (c *Compiler) validateSchema(r *resource, v interface{}, vloc string) error
 		if meta == nil {
 			return nil
 		}
-		return meta.validateValue(v, vloc)
+		return meta.validateValue(&validation{}, v, vloc)
 	}
 
-	if err := validate(r.draft.meta); err != nil {
-		return err
+	// violations of extension meta-schemas are added to those of draft,
+	// so that all of them are reported
+	var first *ValidationError
+	metas := []*Schema{r.draft.meta}
+	names := make([]string, 0, len(c.extensions))
+	for name := range c.extensions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		metas = append(metas, c.extensions[name].meta)
 	}
-	for _, ext := range c.extensions {
-		if err := validate(ext.meta); err != nil {
+	for _, meta := range metas {
+		err := validate(meta)
+		if err == nil {
+			continue
+		}
+		ve, ok := err.(*ValidationError)
+		if !ok {
 			return err
 		}
+		if first == nil {
+			first = ve
+		} else {
+			first.add(ve)
+		}
+	}
+	if first != nil {
+		return first
 	}
 	return nil
 }
 
+// sortedNames returns the property names of object m, sorted. It is used to
+// compile subschemas in same order always, so that OnProgress events are
+// emitted in same order.
+func sortedNames(m map[string]interface{}) []string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
 func toStrings(arr []interface{}) []string {
 	s := make([]string, len(arr))
 	for i, v := range arr {
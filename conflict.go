@@ -0,0 +1,238 @@
+package jsonschema
+
+import (
+	"fmt"
+	"math/big"
+	"unicode/utf8"
+)
+
+// CompileWarning is a problem found in a schema, which does not fail
+// compilation. It is reported to Compiler.OnWarning.
+type CompileWarning struct {
+	Location string    // absolute location of the schema having problem
+	Keywords [2]string // absolute locations of the conflicting keywords. second is empty, if only one keyword is involved
+	Message  string    // describes the problem
+}
+
+func (w CompileWarning) String() string {
+	return fmt.Sprintf("%s: %s", w.Location, w.Message)
+}
+
+// warn calls c.OnWarning with w. If OnWarning panics, it is
+// returned as error, like emit does.
+func (c *Compiler) warn(w CompileWarning) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("jsonschema: OnWarning panicked on %s: %v", w.Location, r)
+		}
+	}()
+	c.OnWarning(w)
+	return nil
+}
+
+// warnConflicts reports the allOf branches of s, and s itself, whose
+// keywords contradict each other, so that no value of the type they
+// constrain is valid. Only provable conflicts of simple keywords are
+// reported; patterns and applicators are not analyzed.
+func (c *Compiler) warnConflicts(s *Schema) error {
+	if len(s.AllOf) == 0 {
+		return nil
+	}
+	schemas := []*Schema{s}
+	for _, sch := range s.AllOf {
+		schemas = append(schemas, sch.Resolve())
+	}
+	for i := 0; i < len(schemas); i++ {
+		for j := i + 1; j < len(schemas); j++ {
+			what, kw1, kw2, ok := conflict(schemas[i], schemas[j])
+			if !ok {
+				what, kw2, kw1, ok = conflict(schemas[j], schemas[i])
+			}
+			if !ok {
+				continue
+			}
+			var msg string
+			if i == 0 {
+				msg = fmt.Sprintf("schema and allOf branch %d have incompatible %s constraints", j-1, what)
+			} else {
+				msg = fmt.Sprintf("allOf branches %d and %d have incompatible %s constraints", i-1, j-1, what)
+			}
+			err := c.warn(CompileWarning{
+				Location: s.Location,
+				Keywords: [2]string{joinPtr(schemas[i].Location, kw1), joinPtr(schemas[j].Location, kw2)},
+				Message:  msg,
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// conflict tells whether keywords of s1 and s2 provably conflict.
+// It returns what is conflicting, along with the conflicting keyword
+// of s1 and s2. Asymmetric checks are done only in one direction,
+// so caller must try with s1 and s2 swapped.
+func conflict(s1, s2 *Schema) (what, kw1, kw2 string, ok bool) {
+	if s1 == s2 {
+		return "", "", "", false
+	}
+
+	// type
+	if len(s1.Types) > 0 && len(s2.Types) > 0 && !typesIntersect(s1.Types, s2.Types) {
+		return "type", "type", "type", true
+	}
+
+	// const, enum
+	values1, kw1 := allowedValues(s1)
+	values2, kw2 := allowedValues(s2)
+	if values1 != nil && values2 != nil {
+		matched := false
+		for _, v1 := range values1 {
+			for _, v2 := range values2 {
+				if eq, err := checkEquals(v1, v2, nil); err == nil && eq {
+					matched = true
+				}
+			}
+		}
+		if !matched {
+			return kw1, kw1, kw2, true
+		}
+	}
+	if values1 != nil && len(s2.Types) > 0 {
+		matched := false
+		for _, v := range values1 {
+			if valueOfTypes(v, s2.Types) {
+				matched = true
+			}
+		}
+		if !matched {
+			return kw1 + " and type", kw1, "type", true
+		}
+	}
+	if values1 != nil && (s2.MinLength != -1 || s2.MaxLength != -1) {
+		// only when all values are strings, otherwise non-string values
+		// are not constrained by length
+		matched := false
+		for _, v := range values1 {
+			str, ok := v.(string)
+			if !ok {
+				matched = true
+				break
+			}
+			n := utf8.RuneCountInString(str)
+			if (s2.MinLength == -1 || n >= s2.MinLength) && (s2.MaxLength == -1 || n <= s2.MaxLength) {
+				matched = true
+			}
+		}
+		if !matched {
+			if s2.MinLength != -1 {
+				return kw1 + " and length", kw1, "minLength", true
+			}
+			return kw1 + " and length", kw1, "maxLength", true
+		}
+	}
+
+	// lower bound > upper bound
+	if s1.MinLength != -1 && s2.MaxLength != -1 && s1.MinLength > s2.MaxLength {
+		return "length", "minLength", "maxLength", true
+	}
+	if s1.MinItems != -1 && s2.MaxItems != -1 && s1.MinItems > s2.MaxItems {
+		return "items count", "minItems", "maxItems", true
+	}
+	if s1.MinProperties != -1 && s2.MaxProperties != -1 && s1.MinProperties > s2.MaxProperties {
+		return "properties count", "minProperties", "maxProperties", true
+	}
+	if lo, loKw, loExcl := lowerBound(s1); lo != nil {
+		if hi, hiKw, hiExcl := upperBound(s2); hi != nil {
+			if cmp := lo.Cmp(hi); cmp > 0 || cmp == 0 && (loExcl || hiExcl) {
+				return "range", loKw, hiKw, true
+			}
+		}
+	}
+
+	// required property not allowed
+	for _, pname := range s1.Required {
+		if sch, ok := s2.Properties[pname]; ok && sch.Always != nil && !*sch.Always {
+			return "required and properties", "required", "properties/" + escape(pname), true
+		}
+	}
+	if s2.MaxProperties != -1 {
+		required := make(map[string]struct{})
+		for _, pname := range s1.Required {
+			required[pname] = struct{}{}
+		}
+		if len(required) > s2.MaxProperties {
+			return "required and maxProperties", "required", "maxProperties", true
+		}
+	}
+
+	return "", "", "", false
+}
+
+// typesIntersect tells whether any value matches both types1 and types2.
+func typesIntersect(types1, types2 []string) bool {
+	for _, t1 := range types1 {
+		for _, t2 := range types2 {
+			if t1 == t2 || t1 == "integer" && t2 == "number" || t1 == "number" && t2 == "integer" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// allowedValues returns the values allowed by const or enum of s,
+// along with the keyword. returns nil, if s has neither.
+func allowedValues(s *Schema) ([]interface{}, string) {
+	if len(s.Constant) > 0 {
+		return s.Constant, "const"
+	}
+	if len(s.Enum) > 0 {
+		return s.Enum, "enum"
+	}
+	return nil, ""
+}
+
+// valueOfTypes tells whether v matches any of types.
+func valueOfTypes(v interface{}, types []string) bool {
+	vType, err := checkJSONType(v)
+	if err != nil {
+		// not sure
+		return true
+	}
+	for _, t := range types {
+		if t == vType {
+			return true
+		}
+		if t == "integer" && vType == "number" {
+			if r, err := parseRat(v); err != nil || r.IsInt() {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// lowerBound returns the tightest of minimum and exclusiveMinimum of s.
+func lowerBound(s *Schema) (bound *big.Rat, keyword string, exclusive bool) {
+	if s.Minimum != nil {
+		bound, keyword = s.Minimum, "minimum"
+	}
+	if s.ExclusiveMinimum != nil && (bound == nil || s.ExclusiveMinimum.Cmp(bound) >= 0) {
+		bound, keyword, exclusive = s.ExclusiveMinimum, "exclusiveMinimum", true
+	}
+	return
+}
+
+// upperBound returns the tightest of maximum and exclusiveMaximum of s.
+func upperBound(s *Schema) (bound *big.Rat, keyword string, exclusive bool) {
+	if s.Maximum != nil {
+		bound, keyword = s.Maximum, "maximum"
+	}
+	if s.ExclusiveMaximum != nil && (bound == nil || s.ExclusiveMaximum.Cmp(bound) <= 0) {
+		bound, keyword, exclusive = s.ExclusiveMaximum, "exclusiveMaximum", true
+	}
+	return
+}
@@ -0,0 +1,61 @@
+package jsonschema_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+func TestCompiler_OnWarning(t *testing.T) {
+	data, err := ioutil.ReadFile("testdata/allof_conflicts.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var tests []struct {
+		Description string
+		Schema      json.RawMessage
+		Warnings    []string
+	}
+	if err := json.Unmarshal(data, &tests); err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range tests {
+		t.Run(test.Description, func(t *testing.T) {
+			c := jsonschema.NewCompiler()
+			if err := c.AddResource("schema.json", bytes.NewReader(test.Schema)); err != nil {
+				t.Fatal(err)
+			}
+			loc := func(s string) string {
+				return s[strings.IndexByte(s, '#'):]
+			}
+			warnings := []string{}
+			c.OnWarning = func(w jsonschema.CompileWarning) {
+				warnings = append(warnings, w.Message+": "+loc(w.Keywords[0])+" "+loc(w.Keywords[1]))
+			}
+			if _, err := c.Compile("schema.json"); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(warnings, test.Warnings) {
+				t.Errorf("got %q, want %q", warnings, test.Warnings)
+			}
+		})
+	}
+}
+
+func TestCompiler_OnWarning_panic(t *testing.T) {
+	c := jsonschema.NewCompiler()
+	if err := c.AddResource("schema.json", strings.NewReader(`{"allOf": [{"type": "string"}, {"type": "integer"}]}`)); err != nil {
+		t.Fatal(err)
+	}
+	c.OnWarning = func(w jsonschema.CompileWarning) {
+		panic("boom")
+	}
+	if _, err := c.Compile("schema.json"); err == nil || !strings.Contains(err.Error(), "boom") {
+		t.Fatalf("got %v, want error with panic value", err)
+	}
+}
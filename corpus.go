@@ -0,0 +1,174 @@
+package jsonschema
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// Corpus is a snapshot of validation decisions of a schema over
+// set of documents. It is used to detect changes in behavior, when the
+// schema or this package is upgraded.
+//
+// Corpus can be serialized using encoding/json, and read back
+// using ReadCorpus.
+type Corpus struct {
+	Entries []CorpusEntry `json:"entries"`
+}
+
+// CorpusEntry is the validation decision recorded for a document.
+type CorpusEntry struct {
+	Hash   string        `json:"hash"`             // hex encoded sha256 of canonical json of Doc
+	Doc    interface{}   `json:"doc"`              // document validated
+	Valid  bool          `json:"valid"`            // verdict
+	Errors []CorpusError `json:"errors,omitempty"` // leaf errors, sorted
+}
+
+// CorpusError is a leaf validation error recorded in corpus.
+type CorpusError struct {
+	Code             string `json:"code"`             // ValidationError.Keyword
+	InstanceLocation string `json:"instanceLocation"` // ValidationError.InstanceLocation
+}
+
+func (e CorpusError) less(other CorpusError) bool {
+	if e.InstanceLocation != other.InstanceLocation {
+		return e.InstanceLocation < other.InstanceLocation
+	}
+	return e.Code < other.Code
+}
+
+// Divergence is the difference between recorded and replayed
+// validation decisions for a document.
+type Divergence struct {
+	Index   int           // index of the entry in corpus
+	Hash    string        // hash of the document
+	Valid   bool          // replayed verdict
+	Missing []CorpusError // errors recorded, but not reported in replay
+	Extra   []CorpusError // errors reported in replay, but not recorded
+	Err     error         // error other than *ValidationError, returned in replay
+}
+
+func (d Divergence) String() string {
+	if d.Err != nil {
+		return fmt.Sprintf("entry %d (%s): %v", d.Index, d.Hash, d.Err)
+	}
+	return fmt.Sprintf("entry %d (%s): valid=%v missing=%v extra=%v", d.Index, d.Hash, d.Valid, d.Missing, d.Extra)
+}
+
+// Record validates each document in docs with schema, and returns
+// the decisions as Corpus.
+//
+// returns error if any document fails with error other than *ValidationError.
+func Record(schema *Schema, docs []interface{}) (*Corpus, error) {
+	c := &Corpus{Entries: make([]CorpusEntry, 0, len(docs))}
+	for i, doc := range docs {
+		hash, err := hashDoc(doc)
+		if err != nil {
+			return nil, fmt.Errorf("jsonschema: document %d: %v", i, err)
+		}
+		errors, err := corpusErrors(schema, doc)
+		if err != nil {
+			return nil, fmt.Errorf("jsonschema: document %d: %v", i, err)
+		}
+		c.Entries = append(c.Entries, CorpusEntry{
+			Hash:   hash,
+			Doc:    doc,
+			Valid:  errors == nil,
+			Errors: errors,
+		})
+	}
+	return c, nil
+}
+
+// ReadCorpus reads the json serialized Corpus from r.
+func ReadCorpus(r io.Reader) (*Corpus, error) {
+	decoder := json.NewDecoder(r)
+	decoder.UseNumber()
+	c := &Corpus{}
+	if err := decoder.Decode(c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Replay validates documents in corpus with schema, and returns
+// the entries whose decisions differ from the recorded ones.
+func (c *Corpus) Replay(schema *Schema) []Divergence {
+	var divergences []Divergence
+	for i, entry := range c.Entries {
+		errors, err := corpusErrors(schema, entry.Doc)
+		if err != nil {
+			divergences = append(divergences, Divergence{Index: i, Hash: entry.Hash, Err: err})
+			continue
+		}
+		d := Divergence{Index: i, Hash: entry.Hash, Valid: errors == nil}
+		d.Missing, d.Extra = diffCorpusErrors(entry.Errors, errors)
+		if d.Valid != entry.Valid || len(d.Missing) > 0 || len(d.Extra) > 0 {
+			divergences = append(divergences, d)
+		}
+	}
+	return divergences
+}
+
+// hashDoc returns hex encoded sha256 of canonical json of doc.
+// encoding/json sorts object keys, which makes the encoding canonical.
+func hashDoc(doc interface{}) (string, error) {
+	b, err := json.Marshal(doc)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(b)
+	return fmt.Sprintf("%x", sum), nil
+}
+
+// corpusErrors returns sorted leaf errors of validating doc with schema.
+// returns nil if doc is valid.
+func corpusErrors(schema *Schema, doc interface{}) ([]CorpusError, error) {
+	err := schema.Validate(doc)
+	if err == nil {
+		return nil, nil
+	}
+	ve, ok := err.(*ValidationError)
+	if !ok {
+		return nil, err
+	}
+	errors := []CorpusError{}
+	var leaves func(*ValidationError)
+	leaves = func(ve *ValidationError) {
+		if len(ve.Causes) == 0 {
+			errors = append(errors, CorpusError{ve.Keyword, ve.InstanceLocation})
+		}
+		for _, cause := range ve.Causes {
+			leaves(cause)
+		}
+	}
+	leaves(ve)
+	sort.Slice(errors, func(i, j int) bool {
+		return errors[i].less(errors[j])
+	})
+	return errors, nil
+}
+
+// diffCorpusErrors returns the errors in want but not in got,
+// and the errors in got but not in want. both must be sorted.
+func diffCorpusErrors(want, got []CorpusError) (missing, extra []CorpusError) {
+	i, j := 0, 0
+	for i < len(want) && j < len(got) {
+		switch {
+		case want[i] == got[j]:
+			i++
+			j++
+		case want[i].less(got[j]):
+			missing = append(missing, want[i])
+			i++
+		default:
+			extra = append(extra, got[j])
+			j++
+		}
+	}
+	missing = append(missing, want[i:]...)
+	extra = append(extra, got[j:]...)
+	return missing, extra
+}
@@ -0,0 +1,71 @@
+package jsonschema_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+func TestCorpus(t *testing.T) {
+	schema := func(max int) *jsonschema.Schema {
+		return jsonschema.MustCompileString("schema.json", `{
+			"type": "object",
+			"required": ["id"],
+			"properties": {
+				"id": {"type": "integer"},
+				"tags": {"type": "array", "items": {"type": "string"}, "maxItems": `+strconv.Itoa(max)+`}
+			}
+		}`)
+	}
+	docs := []interface{}{
+		decodeString(t, `{"id": 1}`),
+		decodeString(t, `{"id": 1, "tags": ["a", "b", "c"]}`),
+		decodeString(t, `{"id": "x", "tags": ["a", 1]}`),
+		decodeString(t, `[]`),
+	}
+	corpus, err := jsonschema.Record(schema(3), docs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []jsonschema.CorpusEntry{
+		{Valid: true},
+		{Valid: true},
+		{Valid: false, Errors: []jsonschema.CorpusError{{"type", "/id"}, {"type", "/tags/1"}}},
+		{Valid: false, Errors: []jsonschema.CorpusError{{"type", ""}}},
+	}
+	for i, entry := range corpus.Entries {
+		if entry.Valid != want[i].Valid || !reflect.DeepEqual(entry.Errors, want[i].Errors) {
+			t.Errorf("entry %d: got %v %v, want %v %v", i, entry.Valid, entry.Errors, want[i].Valid, want[i].Errors)
+		}
+	}
+
+	// serialize and read back
+	b, err := json.Marshal(corpus)
+	if err != nil {
+		t.Fatal(err)
+	}
+	corpus, err = jsonschema.ReadCorpus(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if d := corpus.Replay(schema(3)); len(d) != 0 {
+		t.Fatalf("divergences: %v", d)
+	}
+
+	// tweak schema
+	d := corpus.Replay(schema(2))
+	if len(d) != 1 {
+		t.Fatalf("divergences: %v", d)
+	}
+	if d[0].Index != 1 || d[0].Valid || len(d[0].Missing) != 0 || !reflect.DeepEqual(d[0].Extra, []jsonschema.CorpusError{{"maxItems", "/tags"}}) {
+		t.Fatalf("divergence: %v", d[0])
+	}
+	if d[0].Hash != corpus.Entries[1].Hash {
+		t.Fatalf("hash: got %s, want %s", d[0].Hash, corpus.Entries[1].Hash)
+	}
+}
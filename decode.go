@@ -0,0 +1,455 @@
+package jsonschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strconv"
+)
+
+// Limits caps the size of json documents, independent of any schema.
+//
+// Zero value of a field means no limit.
+type Limits struct {
+	MaxProperties   int // maximum number of properties in any object
+	MaxItems        int // maximum number of items in any array
+	MaxStringLength int // maximum length of any string in bytes, including property names
+	MaxValues       int // maximum number of values in document, including nested values
+}
+
+func (l Limits) isZero() bool {
+	return l == Limits{}
+}
+
+// LimitError is the error type returned, when a json document exceeds Limits,
+// or its validation exceeds ValidateOptions.MaxComparisons or MaxEvaluations.
+//
+// Unlike ValidationError, this does not tell whether the document is valid.
+// It tells that the document is rejected by policy.
+type LimitError struct {
+	Limit            string // name of the limit exceeded. for example "MaxItems"
+	Max              int    // value of the limit exceeded
+	InstanceLocation string // location of the json value exceeding the limit
+
+	// AbsoluteKeywordLocation is the location of schema being applied,
+	// when validation exceeded the limit. Empty for Limits.
+	AbsoluteKeywordLocation string
+}
+
+// ErrBudgetExceeded matches *LimitError with errors.Is, if validation
+// exceeded ValidateOptions.MaxComparisons or MaxEvaluations.
+var ErrBudgetExceeded = errors.New("jsonschema: validation budget exceeded")
+
+// Is tells whether target is ErrBudgetExceeded and e is due to
+// a validation budget.
+func (e *LimitError) Is(target error) bool {
+	return target == ErrBudgetExceeded && (e.Limit == "MaxComparisons" || e.Limit == "MaxEvaluations")
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("jsonschema: %s exceeds limit %s=%d", quote(e.InstanceLocation), e.Limit, e.Max)
+}
+
+// limitChecker checks json values against Limits, tracking
+// the location of value being checked.
+type limitChecker struct {
+	Limits
+	values int
+	path   []string // reference tokens of location being checked
+
+	// vd, if not nil, is the validation whose value is checked.
+	// the values decoded by check are reused by the validation.
+	vd *validation
+}
+
+// loc returns the location being checked, followed by given tokens.
+func (lc *limitChecker) loc(token ...string) string {
+	loc := ""
+	for _, tok := range lc.path {
+		loc += "/" + tok
+	}
+	for _, tok := range token {
+		loc += "/" + tok
+	}
+	return loc
+}
+
+func (lc *limitChecker) error(limit string, max int, token ...string) *LimitError {
+	return &LimitError{limit, max, lc.loc(token...), ""}
+}
+
+// native is native(v), using lc.vd if any.
+func (lc *limitChecker) native(v interface{}) (interface{}, error) {
+	if lc.vd == nil || !cacheable(v) {
+		return native(v)
+	}
+	return lc.vd.native(v, lc.loc())
+}
+
+// value accounts for a value, that is not yet descended into.
+func (lc *limitChecker) value() error {
+	lc.values++
+	if lc.MaxValues > 0 && lc.values > lc.MaxValues {
+		return lc.error("MaxValues", lc.MaxValues)
+	}
+	return nil
+}
+
+func (lc *limitChecker) str(s string, token ...string) error {
+	if lc.MaxStringLength > 0 && len(s) > lc.MaxStringLength {
+		return lc.error("MaxStringLength", lc.MaxStringLength, token...)
+	}
+	return nil
+}
+
+func (lc *limitChecker) props(n int) error {
+	if lc.MaxProperties > 0 && n > lc.MaxProperties {
+		return lc.error("MaxProperties", lc.MaxProperties)
+	}
+	return nil
+}
+
+func (lc *limitChecker) items(n int) error {
+	if lc.MaxItems > 0 && n > lc.MaxItems {
+		return lc.error("MaxItems", lc.MaxItems)
+	}
+	return nil
+}
+
+// check checks the decoded json value v.
+func (lc *limitChecker) check(v interface{}) error {
+	if err := lc.value(); err != nil {
+		return err
+	}
+	v, err := lc.native(v)
+	if err != nil {
+		return err
+	}
+	switch v := v.(type) {
+	case string:
+		return lc.str(v)
+	case map[string]interface{}:
+		if err := lc.props(len(v)); err != nil {
+			return err
+		}
+		// sorted, so that location in LimitError does not vary
+		pnames := make([]string, 0, len(v))
+		for pname := range v {
+			pnames = append(pnames, pname)
+		}
+		sort.Strings(pnames)
+		for _, pname := range pnames {
+			if err := lc.str(pname, escape(pname)); err != nil {
+				return err
+			}
+			lc.path = append(lc.path, escape(pname))
+			if err := lc.check(v[pname]); err != nil {
+				return err
+			}
+			lc.path = lc.path[:len(lc.path)-1]
+		}
+	case []interface{}:
+		if err := lc.items(len(v)); err != nil {
+			return err
+		}
+		for i, item := range v {
+			lc.path = append(lc.path, strconv.Itoa(i))
+			if err := lc.check(item); err != nil {
+				return err
+			}
+			lc.path = lc.path[:len(lc.path)-1]
+		}
+	}
+	return nil
+}
+
+// Decoder decodes json documents, with numbers decoded as json.Number.
+//
+// Limits are enforced while reading, so that oversized documents are
+// rejected as soon as a limit is crossed, without reading rest of the document.
+type Decoder struct {
+	Limits Limits
+}
+
+// DecodeJSON decodes single json document from r, with numbers decoded as json.Number.
+func DecodeJSON(r io.Reader) (interface{}, error) {
+	return (&Decoder{}).Decode(r)
+}
+
+// Decode decodes single json document from r.
+//
+// returns *LimitError if the document exceeds d.Limits, and
+// ErrEmptyDocument if r has only whitespace.
+func (d *Decoder) Decode(r io.Reader) (interface{}, error) {
+	if d.Limits.isZero() {
+		doc, err := unmarshal(r)
+		if err == io.EOF {
+			err = ErrEmptyDocument
+		}
+		return doc, err
+	}
+	lc := &limitChecker{Limits: d.Limits}
+	if lc.MaxStringLength > 0 {
+		r = &stringLimitReader{r: r, max: 6 * lc.MaxStringLength}
+	}
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	t, err := dec.Token()
+	if err == io.EOF {
+		return nil, ErrEmptyDocument
+	}
+	if err == nil {
+		var doc interface{}
+		if doc, err = lc.decode(dec, t); err == nil {
+			if err = checkEOF(dec); err == nil {
+				return doc, nil
+			}
+		}
+	}
+	if err == errStringTooLong {
+		err = lc.error("MaxStringLength", lc.MaxStringLength)
+	}
+	return nil, err
+}
+
+// checkEOF checks that there is nothing but whitespace, after the
+// top-level value read by dec.
+func checkEOF(dec *json.Decoder) error {
+	t, err := dec.Token()
+	switch {
+	case err == io.EOF:
+		return nil
+	case err == nil:
+		return fmt.Errorf("invalid character %v after top-level value", t)
+	case err == errStringTooLong:
+		return err
+	}
+	return fmt.Errorf("invalid json after top-level value: %w", err)
+}
+
+var errStringTooLong = errors.New("jsonschema: string too long")
+
+// stringLimitReader fails with errStringTooLong, once it reads a
+// json string with more than max bytes, so that json.Decoder does not
+// buffer huge string tokens.
+//
+// escape sequence in json string takes at most 6 bytes, thus
+// max must be 6 times the limit on decoded string length.
+type stringLimitReader struct {
+	r       io.Reader
+	max     int
+	n       int  // bytes read in current string
+	inStr   bool // inside json string
+	escaped bool // previous byte is backslash inside json string
+	err     error
+}
+
+func (r *stringLimitReader) Read(p []byte) (int, error) {
+	if r.err != nil {
+		return 0, r.err
+	}
+	n, err := r.r.Read(p)
+	for i, b := range p[:n] {
+		switch {
+		case !r.inStr:
+			if b == '"' {
+				r.inStr, r.n = true, 0
+			}
+		case r.escaped:
+			r.escaped = false
+		case b == '\\':
+			r.escaped = true
+		case b == '"':
+			r.inStr = false
+		}
+		if r.inStr {
+			if r.n++; r.n > r.max+1 {
+				// return what is read before the long string
+				r.err = errStringTooLong
+				return i, nil
+			}
+		}
+	}
+	return n, err
+}
+
+// decode decodes the json value starting with token t.
+func (lc *limitChecker) decode(dec *json.Decoder, t json.Token) (interface{}, error) {
+	if err := lc.value(); err != nil {
+		return nil, err
+	}
+	switch t := t.(type) {
+	case string:
+		return t, lc.str(t)
+	case json.Delim:
+		switch t {
+		case '{':
+			obj := make(map[string]interface{})
+			for n := 1; dec.More(); n++ {
+				t, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				pname := t.(string)
+				if err := lc.props(n); err != nil {
+					return nil, err
+				}
+				if err := lc.str(pname, escape(pname)); err != nil {
+					return nil, err
+				}
+				lc.path = append(lc.path, escape(pname))
+				if t, err = dec.Token(); err != nil {
+					return nil, err
+				}
+				if obj[pname], err = lc.decode(dec, t); err != nil {
+					return nil, err
+				}
+				lc.path = lc.path[:len(lc.path)-1]
+			}
+			if _, err := dec.Token(); err != nil {
+				return nil, err
+			}
+			return obj, nil
+		case '[':
+			arr := []interface{}{}
+			for dec.More() {
+				if err := lc.items(len(arr) + 1); err != nil {
+					return nil, err
+				}
+				lc.path = append(lc.path, strconv.Itoa(len(arr)))
+				t, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				item, err := lc.decode(dec, t)
+				if err != nil {
+					return nil, err
+				}
+				lc.path = lc.path[:len(lc.path)-1]
+				arr = append(arr, item)
+			}
+			if _, err := dec.Token(); err != nil {
+				return nil, err
+			}
+			return arr, nil
+		}
+		return nil, fmt.Errorf("invalid character %v", t)
+	default:
+		return t, nil
+	}
+}
+
+// DecodeError is the error type returned, when input is not valid json.
+//
+// It tells that the client sent malformed json, whereas ValidationError
+// tells that the client sent valid json not conforming to schema.
+type DecodeError struct {
+	Offset int64 // byte offset in input, at which error is detected
+	Line   int   // line number of Offset, starting from 1
+	Col    int   // column number of Offset in bytes, starting from 1
+	Err    error // underlying error. io.ErrUnexpectedEOF for truncated input
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("jsonschema: invalid json at line %d column %d: %v", e.Line, e.Col, e.Err)
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// ErrEmptyDocument is returned, when input has no json document,
+// i.e. it is empty or has only whitespace. Note that null is not
+// empty document; it is validated as json null.
+var ErrEmptyDocument = errors.New("jsonschema: empty document")
+
+// decodeBytes decodes single json document from b, with numbers decoded
+// as json.Number.
+//
+// returns ErrEmptyDocument if b has only whitespace, and *DecodeError
+// if b is not valid json.
+func decodeBytes(b []byte) (interface{}, error) {
+	if len(bytes.TrimLeft(b, " \t\r\n")) == 0 {
+		return nil, ErrEmptyDocument
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var doc interface{}
+	if err := dec.Decode(&doc); err != nil {
+		var off int64
+		switch e := err.(type) {
+		case *json.SyntaxError:
+			// Offset is number of bytes read including the offending byte
+			if off = e.Offset - 1; off < 0 {
+				off = 0
+			}
+		default:
+			if err == io.ErrUnexpectedEOF {
+				off = int64(len(b))
+			}
+		}
+		return nil, newDecodeError(b, off, err)
+	}
+	// InputOffset is the offset of the token about to be read
+	off := dec.InputOffset()
+	if _, err := dec.Token(); err != io.EOF {
+		for off < int64(len(b)) && isSpace(b[off]) {
+			off++
+		}
+		return nil, newDecodeError(b, off, fmt.Errorf("invalid character %q after top-level value", b[off]))
+	}
+	return doc, nil
+}
+
+// ValidateBytes decodes json document from b, and validates it.
+//
+// returns ErrEmptyDocument if b is empty or has only whitespace,
+// *DecodeError if b is not valid json, and *ValidationError
+// if the document does not conform to schema s.
+func (s *Schema) ValidateBytes(b []byte) error {
+	doc, err := decodeBytes(b)
+	if err != nil {
+		return err
+	}
+	return s.Validate(doc)
+}
+
+// ValidateReader is like ValidateBytes, but reads the json document from r.
+// The error returned by r is returned as is.
+func (s *Schema) ValidateReader(r io.Reader) error {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	return s.ValidateBytes(b)
+}
+
+// ValidateFile is like ValidateBytes, but reads the json document from file.
+// The error from reading file is returned as is.
+func (s *Schema) ValidateFile(file string) error {
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	return s.ValidateBytes(b)
+}
+
+func newDecodeError(b []byte, off int64, err error) *DecodeError {
+	line, col := 1, 1
+	for _, ch := range b[:off] {
+		if ch == '\n' {
+			line, col = line+1, 1
+		} else {
+			col++
+		}
+	}
+	return &DecodeError{off, line, col, err}
+}
+
+func isSpace(ch byte) bool {
+	return ch == ' ' || ch == '\t' || ch == '\r' || ch == '\n'
+}
@@ -0,0 +1,265 @@
+package jsonschema_test
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// endlessReader returns prefix followed by endless repetition of repeat.
+type endlessReader struct {
+	prefix string
+	repeat string
+	off    int // offset in repeat
+	n      int // number of bytes read
+}
+
+func (r *endlessReader) Read(p []byte) (int, error) {
+	if r.n > 1<<30 {
+		return 0, errors.New("read too much")
+	}
+	n := 0
+	for n < len(p) {
+		if r.prefix != "" {
+			c := copy(p[n:], r.prefix)
+			r.prefix = r.prefix[c:]
+			n += c
+		} else {
+			c := copy(p[n:], r.repeat[r.off:])
+			r.off = (r.off + c) % len(r.repeat)
+			n += c
+		}
+	}
+	r.n += n
+	return n, nil
+}
+
+var _ io.Reader = (*endlessReader)(nil)
+
+func TestDecoder_limits(t *testing.T) {
+	limits := jsonschema.Limits{MaxProperties: 3, MaxItems: 4, MaxStringLength: 5, MaxValues: 20}
+	tests := []struct {
+		name     string
+		r        io.Reader
+		limits   jsonschema.Limits
+		limit    string
+		location string
+	}{
+		{"MaxItems", &endlessReader{prefix: `{"a": [`, repeat: `1,`}, limits, "MaxItems", "/a"},
+		{"MaxProperties", &endlessReader{prefix: `{"a": {"b": 1, `, repeat: `"c": 1, `}, limits, "MaxProperties", "/a"},
+		{"MaxStringLength", &endlessReader{prefix: `["ok", "`, repeat: `x`}, limits, "MaxStringLength", "/1"},
+		{"MaxStringLength_escaped", &endlessReader{prefix: `["`, repeat: `\u0000`}, limits, "MaxStringLength", "/0"},
+		{"MaxStringLength_name", strings.NewReader(`{"a": {"abcdef": 1}}`), limits, "MaxStringLength", "/a/abcdef"},
+		{"MaxValues", &endlessReader{prefix: `[`, repeat: `[1],`}, jsonschema.Limits{MaxValues: 20}, "MaxValues", "/9/0"},
+		{"MaxValues_nested", &endlessReader{prefix: `[`, repeat: `[`}, limits, "MaxValues", "/0/0/0/0/0/0/0/0/0/0/0/0/0/0/0/0/0/0/0/0"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			d := &jsonschema.Decoder{Limits: test.limits}
+			_, err := d.Decode(test.r)
+			le, ok := err.(*jsonschema.LimitError)
+			if !ok {
+				t.Fatalf("got %v, want *LimitError", err)
+			}
+			if le.Limit != test.limit || le.InstanceLocation != test.location {
+				t.Fatalf("got %s at %q, want %s at %q", le.Limit, le.InstanceLocation, test.limit, test.location)
+			}
+			if r, ok := test.r.(*endlessReader); ok && r.n > 1<<20 {
+				t.Fatalf("read %d bytes before failing", r.n)
+			}
+		})
+	}
+}
+
+func TestDecoder_withinLimits(t *testing.T) {
+	d := &jsonschema.Decoder{Limits: jsonschema.Limits{MaxProperties: 2, MaxItems: 2, MaxStringLength: 3, MaxValues: 8}}
+	v, err := d.Decode(strings.NewReader(`{"abc": ["a\"b", 1.5], "b": {"c": [null, true]}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := v.(map[string]interface{})["abc"].([]interface{})[0]; s != `a"b` {
+		t.Fatalf("got %q", s)
+	}
+	for _, doc := range []string{``, `[1,]`, `{"a" 1}`, `[1] 2`, `[1`, `[1] x`, `[1] }`} {
+		if _, err := d.Decode(strings.NewReader(doc)); err == nil {
+			t.Errorf("%q: error expected", doc)
+		}
+	}
+}
+
+func TestDecoder_trailingSyntaxError(t *testing.T) {
+	for _, d := range []*jsonschema.Decoder{{}, {Limits: jsonschema.Limits{MaxItems: 2}}} {
+		for _, doc := range []string{`[1] x`, `{} }`, `"a" ]`} {
+			_, err := d.Decode(strings.NewReader(doc))
+			var se *json.SyntaxError
+			if !errors.As(err, &se) {
+				t.Errorf("%+v %q: got %v, want *json.SyntaxError", d.Limits, doc, err)
+			}
+		}
+	}
+}
+
+func TestSchema_ValidateWith(t *testing.T) {
+	sch := jsonschema.MustCompileString("schema.json", `{"items": {"type": "string"}}`)
+	tests := []struct {
+		doc   string
+		opts  jsonschema.ValidateOptions
+		limit string
+	}{
+		{`["a", "b", "c"]`, jsonschema.ValidateOptions{}, ""},
+		{`["a", "b", "c"]`, jsonschema.ValidateOptions{Limits: jsonschema.Limits{MaxItems: 2}}, "MaxItems"},
+		{`["a", "bcd"]`, jsonschema.ValidateOptions{Limits: jsonschema.Limits{MaxStringLength: 2}}, "MaxStringLength"},
+		{`[{"a": 1, "b": 2}]`, jsonschema.ValidateOptions{Limits: jsonschema.Limits{MaxProperties: 1}}, "MaxProperties"},
+		{`[[1], [2]]`, jsonschema.ValidateOptions{Limits: jsonschema.Limits{MaxValues: 4}}, "MaxValues"},
+	}
+	for _, test := range tests {
+		err := sch.ValidateWith(decodeString(t, test.doc), test.opts)
+		if test.limit == "" {
+			if err != nil {
+				t.Errorf("%s: got %v", test.doc, err)
+			}
+			continue
+		}
+		if le, ok := err.(*jsonschema.LimitError); !ok || le.Limit != test.limit {
+			t.Errorf("%s: got %v, want %s", test.doc, err, test.limit)
+		}
+	}
+
+	// limits are checked before validation
+	err := sch.ValidateWith(decodeString(t, `[1, 2, 3]`), jsonschema.ValidateOptions{Limits: jsonschema.Limits{MaxItems: 2}})
+	if _, ok := err.(*jsonschema.LimitError); !ok {
+		t.Fatalf("got %v, want *LimitError", err)
+	}
+	// validation error when within limits
+	err = sch.ValidateWith(decodeString(t, `[1, 2]`), jsonschema.ValidateOptions{Limits: jsonschema.Limits{MaxItems: 2}})
+	if _, ok := err.(*jsonschema.ValidationError); !ok {
+		t.Fatalf("got %v, want *ValidationError", err)
+	}
+
+	// location does not depend on map iteration order
+	doc := decodeString(t, `{"e": "xyz", "b": "xyz", "d": "xyz", "a": "xyz", "c": "xyz"}`)
+	for i := 0; i < 20; i++ {
+		err := sch.ValidateWith(doc, jsonschema.ValidateOptions{Limits: jsonschema.Limits{MaxStringLength: 2}})
+		if le, ok := err.(*jsonschema.LimitError); !ok || le.InstanceLocation != "/a" {
+			t.Fatalf("got %v, want MaxStringLength at '/a'", err)
+		}
+	}
+}
+
+func TestSchema_ValidateBytes(t *testing.T) {
+	sch := jsonschema.MustCompileString("schema.json", `{"properties": {"b": {"type": "string"}}}`)
+	tests := []struct {
+		doc       string
+		offset    int64
+		line, col int
+	}{
+		{"{\"a\": 1,\n  \"b\": x}", 16, 2, 8},
+		{`{"a": 1,, "b": 2}`, 8, 1, 9},
+		{"[1, 2,\n", 7, 2, 1},         // truncated
+		{`{"a": "abc`, 10, 1, 11},     // truncated in string
+		{"{\"a\": 1}\n  x", 11, 2, 3}, // trailing garbage
+		{"{\"a\": 1}\n  }", 11, 2, 3}, // trailing delimiter
+		{"{} null", 3, 1, 4},          // trailing null
+	}
+	for _, test := range tests {
+		err := sch.ValidateBytes([]byte(test.doc))
+		de, ok := err.(*jsonschema.DecodeError)
+		if !ok {
+			t.Errorf("%q: got %v, want *DecodeError", test.doc, err)
+			continue
+		}
+		if de.Offset != test.offset || de.Line != test.line || de.Col != test.col {
+			t.Errorf("%q: got offset=%d line=%d col=%d, want %d %d %d", test.doc, de.Offset, de.Line, de.Col, test.offset, test.line, test.col)
+		}
+	}
+
+	if err := sch.ValidateBytes([]byte(`{"b": 1}`)); err == nil {
+		t.Error("validation error expected")
+	} else if _, ok := err.(*jsonschema.ValidationError); !ok {
+		t.Errorf("got %v, want *ValidationError", err)
+	}
+	if err := sch.ValidateBytes([]byte(` {"b": "x"} `)); err != nil {
+		t.Error(err)
+	}
+	if err := sch.ValidateBytes([]byte("[1, 2")); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("got %v, want io.ErrUnexpectedEOF", err)
+	}
+}
+
+func TestSchema_ValidateReader(t *testing.T) {
+	sch := jsonschema.MustCompileString("schema.json", `{"type": "array"}`)
+	if _, ok := sch.ValidateReader(strings.NewReader(`[1, }`)).(*jsonschema.DecodeError); !ok {
+		t.Error("*DecodeError expected")
+	}
+	if _, ok := sch.ValidateReader(strings.NewReader(`{}`)).(*jsonschema.ValidationError); !ok {
+		t.Error("*ValidationError expected")
+	}
+	// i/o error returned as is
+	r := &endlessReader{prefix: `[`, repeat: `1,`, n: 1 << 30}
+	if err := sch.ValidateReader(r); err == nil || err.Error() != "read too much" {
+		t.Errorf("got %v, want i/o error", err)
+	}
+}
+
+func TestSchema_ValidateFile(t *testing.T) {
+	sch := jsonschema.MustCompileString("schema.json", `{"type": "object"}`)
+	if err := sch.ValidateFile("testdata/person.json"); err != nil {
+		t.Error(err)
+	}
+	if _, ok := sch.ValidateFile("testdata/syntax_error.json").(*jsonschema.DecodeError); !ok {
+		t.Error("*DecodeError expected")
+	}
+	if _, ok := sch.ValidateFile("testdata/missing.json").(*os.PathError); !ok {
+		t.Error("*os.PathError expected")
+	}
+}
+
+func TestEmptyDocument(t *testing.T) {
+	nullable := jsonschema.MustCompileString("nullable.json", `{"type": ["object", "null"]}`)
+	object := jsonschema.MustCompileString("object.json", `{"type": "object"}`)
+	if !nullable.AcceptsNull() {
+		t.Error("nullable.AcceptsNull() must be true")
+	}
+	if object.AcceptsNull() {
+		t.Error("object.AcceptsNull() must be false")
+	}
+	if !jsonschema.MustCompileString("any.json", `{"$ref": "#/$defs/x", "$defs": {"x": {"not": {"type": "string"}}}}`).AcceptsNull() {
+		t.Error("any.AcceptsNull() must be true")
+	}
+
+	for _, input := range []string{"", " \n\t\r "} {
+		for _, sch := range []*jsonschema.Schema{nullable, object} {
+			if err := sch.ValidateBytes([]byte(input)); err != jsonschema.ErrEmptyDocument {
+				t.Errorf("%s: ValidateBytes(%q): got %v, want ErrEmptyDocument", sch, input, err)
+			}
+			if err := sch.ValidateReader(strings.NewReader(input)); err != jsonschema.ErrEmptyDocument {
+				t.Errorf("%s: ValidateReader(%q): got %v, want ErrEmptyDocument", sch, input, err)
+			}
+		}
+		if _, err := jsonschema.DecodeJSON(strings.NewReader(input)); err != jsonschema.ErrEmptyDocument {
+			t.Errorf("DecodeJSON(%q): got %v, want ErrEmptyDocument", input, err)
+		}
+		d := &jsonschema.Decoder{Limits: jsonschema.Limits{MaxItems: 1}}
+		if _, err := d.Decode(strings.NewReader(input)); err != jsonschema.ErrEmptyDocument {
+			t.Errorf("Decoder.Decode(%q): got %v, want ErrEmptyDocument", input, err)
+		}
+	}
+
+	// null is not empty
+	for _, input := range []string{"null", " null\n"} {
+		if err := nullable.ValidateBytes([]byte(input)); err != nil {
+			t.Errorf("nullable: ValidateBytes(%q): %v", input, err)
+		}
+		if _, ok := object.ValidateBytes([]byte(input)).(*jsonschema.ValidationError); !ok {
+			t.Errorf("object: ValidateBytes(%q): *ValidationError expected", input)
+		}
+	}
+	if err := object.Validate(nil); err == nil || !strings.Contains(err.Error(), "expected object, but got null") {
+		t.Errorf("got %v, want type error", err)
+	}
+}
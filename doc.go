@@ -45,5 +45,7 @@ To load json-schema from HTTPURL, add following import:
 	import _ "github.com/santhosh-tekuri/jsonschema/v5/httploader"
 
 you can validate yaml documents. see https://play.golang.org/p/sJy1qY7dXgA
+
+you can validate xml documents, by converting them to json data model using DecodeXML.
 */
 package jsonschema
@@ -0,0 +1,87 @@
+package jsonschema_test
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+const openapiURL = "https://example.com/openapi.json"
+
+func openapiCompiler(t *testing.T) *jsonschema.Compiler {
+	t.Helper()
+	f, err := os.Open("testdata/document/openapi.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	c := jsonschema.NewCompiler()
+	c.Draft = jsonschema.Draft2020
+	if err := c.AddDocument(openapiURL, f, []string{"/components/schemas/*", "/x-enums/*"}); err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestCompiler_AddDocument(t *testing.T) {
+	c := openapiCompiler(t)
+	sch, err := c.Compile(openapiURL + "#/components/schemas/Order")
+	if err != nil {
+		t.Fatalf("%#v", err)
+	}
+	tests := []struct {
+		doc   string
+		valid bool
+	}{
+		{`{"shipTo": {"city": "paris", "zip": "75001"}, "status": "open"}`, true},
+		{`{"shipTo": {"city": "paris", "zip": "7500"}}`, false},
+		{`{"shipTo": {"zip": "75001"}}`, false},
+		{`{"status": "lost"}`, false},
+	}
+	for _, test := range tests {
+		err := sch.Validate(decodeString(t, test.doc))
+		if valid := err == nil; valid != test.valid {
+			t.Errorf("%s: valid got %v, want %v: %v", test.doc, valid, test.valid, err)
+		}
+	}
+
+	// referred schema is shared
+	address, err := c.Compile(openapiURL + "#/components/schemas/Address")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := sch.Properties["shipTo"].Ref; got != address {
+		t.Errorf("shipTo: got %v, want %v", got, address)
+	}
+}
+
+func TestCompiler_AddDocument_notSchema(t *testing.T) {
+	tests := []string{
+		"#/components/schemas/Broken",   // refers to parameter
+		"#/components/parameters/limit", // parameter itself
+		"#/paths/~1orders/get",
+		"",
+	}
+	for _, f := range tests {
+		_, err := openapiCompiler(t).Compile(openapiURL + f)
+		if err == nil {
+			t.Errorf("%s: want error", f)
+			continue
+		}
+		if !strings.Contains(err.Error(), "is not a schema") {
+			t.Errorf("%s: got %v", f, err)
+		}
+	}
+}
+
+func TestCompiler_AddDocument_invalidPointer(t *testing.T) {
+	for _, ptr := range []string{"/components/missing/*", "components/schemas/*", "", "/info/title/*"} {
+		c := jsonschema.NewCompiler()
+		doc := `{"info": {"title": "x"}, "components": {"schemas": {}}}`
+		if err := c.AddDocument(openapiURL, strings.NewReader(doc), []string{ptr}); err == nil {
+			t.Errorf("%q: want error", ptr)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package jsonschema
 
 import (
+	"fmt"
 	"strconv"
 	"strings"
 )
@@ -45,6 +46,42 @@ func (d *Draft) getID(sch interface{}) string {
 	return id
 }
 
+// checkID checks the id of schema sch at floc, as per the rules of d:
+//   - empty id is not allowed.
+//   - before draft2019, the fragment of id must be plain name, which
+//     is the anchor of sch.
+//   - since draft2019, the fragment of id must be empty. $anchor is
+//     used instead.
+func (d *Draft) checkID(floc string, sch interface{}) error {
+	m, ok := sch.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	if _, ok := m["$ref"]; ok && d.version <= 7 {
+		// sibling id is ignored
+		return nil
+	}
+	id, ok := m[d.id].(string)
+	if !ok {
+		return nil
+	}
+	_, f := split(id)
+	var reason string
+	switch {
+	case id == "":
+		reason = "must not be empty"
+	case f == "#":
+		return nil
+	case d.version >= 2019:
+		reason = "must not have non-empty fragment; use $anchor instead"
+	case !isAnchor(f[1:]):
+		reason = "fragment must be plain name"
+	default:
+		return nil
+	}
+	return fmt.Errorf("jsonschema: invalid %s %s at %s: %s", d.id, quote(id), quote(floc), reason)
+}
+
 func (d *Draft) resolveID(base string, sch interface{}) (string, error) {
 	id, _ := split(d.getID(sch)) // strip fragment
 	if id == "" {
@@ -64,9 +101,10 @@ func (d *Draft) anchors(sch interface{}) []string {
 	var anchors []string
 
 	// before draft2019, anchor is specified in id
-	_, f := split(d.getID(m))
-	if f != "#" {
-		anchors = append(anchors, f[1:])
+	if d.version < 2019 {
+		if _, f := split(d.getID(m)); f != "#" {
+			anchors = append(anchors, f[1:])
+		}
 	}
 
 	if v, ok := m["$anchor"]; ok && d.version >= 2019 {
@@ -81,11 +119,14 @@ func (d *Draft) anchors(sch interface{}) []string {
 // listSubschemas collects subschemas in r into rr.
 func (d *Draft) listSubschemas(r *resource, base string, rr map[string]*resource) error {
 	add := func(loc string, sch interface{}) error {
+		floc := r.floc + "/" + loc
+		if err := d.checkID(floc, sch); err != nil {
+			return err
+		}
 		url, err := d.resolveID(base, sch)
 		if err != nil {
 			return err
 		}
-		floc := r.floc + "/" + loc
 		sr := &resource{url: url, floc: floc, doc: sch}
 		rr[floc] = sr
 
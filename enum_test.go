@@ -0,0 +1,102 @@
+package jsonschema_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+func compileEnum(t *testing.T, c *jsonschema.Compiler, n int) *jsonschema.Schema {
+	t.Helper()
+	enum := make([]string, n)
+	for i := range enum {
+		enum[i] = fmt.Sprintf("country-%03d", i)
+	}
+	b, err := json.Marshal(map[string]interface{}{"enum": enum})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.AddResource("enum.json", strings.NewReader(string(b))); err != nil {
+		t.Fatal(err)
+	}
+	sch, err := c.Compile("enum.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sch
+}
+
+func enumError(t *testing.T, sch *jsonschema.Schema, v interface{}) *jsonschema.ValidationError {
+	t.Helper()
+	err := sch.Validate(v)
+	ve, ok := err.(*jsonschema.ValidationError)
+	if !ok {
+		t.Fatalf("got %v, want *ValidationError", err)
+	}
+	return ve.Causes[0]
+}
+
+func TestEnum_message(t *testing.T) {
+	sch := compileEnum(t, jsonschema.NewCompiler(), 200)
+
+	ve := enumError(t, sch, "contry-042")
+	if len(ve.Message) > 300 {
+		t.Fatalf("message too long: %d bytes", len(ve.Message))
+	}
+	want := `value must be one of "country-000", "country-001", "country-002", "country-003", "country-004", ` +
+		`"country-005", "country-006", "country-007", "country-008", "country-009", … (200 total); `
+	if !strings.HasPrefix(ve.Message, want) {
+		t.Fatalf("message:\n got %s\nwant prefix %s", ve.Message, want)
+	}
+	hint := strings.TrimPrefix(ve.Message, want)
+	if !strings.HasPrefix(hint, `did you mean "country-042", `) || strings.Count(hint, `"`) != 6 {
+		t.Fatalf("suggestions: got %s", hint)
+	}
+
+	allowed, _ := ve.Details["allowed"].([]interface{})
+	if len(allowed) != 200 || allowed[0] != "country-000" || allowed[199] != "country-199" {
+		t.Fatalf("allowed: got %d values", len(allowed))
+	}
+
+	// no suggestions for value far from all members
+	ve = enumError(t, sch, "atlantis")
+	if strings.Contains(ve.Message, "did you mean") {
+		t.Fatalf("unexpected suggestions: %s", ve.Message)
+	}
+	ve = enumError(t, sch, 12)
+	if strings.Contains(ve.Message, "did you mean") {
+		t.Fatalf("unexpected suggestions: %s", ve.Message)
+	}
+
+	// single value is already in message
+	sch = jsonschema.MustCompileString("single.json", `{"enum": ["a"]}`)
+	if ve = enumError(t, sch, "b"); ve.Message != `value must be "a"` {
+		t.Fatalf("got %s", ve.Message)
+	}
+}
+
+func TestCompiler_MaxEnumMessageValues(t *testing.T) {
+	tests := []struct {
+		limit int
+		want  string
+	}{
+		{2, `value must be one of "country-000", "country-001", … (3 total)`},
+		{3, `value must be one of "country-000", "country-001", "country-002"`},
+		{-1, `value must be one of "country-000", "country-001", "country-002"`},
+	}
+	for _, test := range tests {
+		c := jsonschema.NewCompiler()
+		c.MaxEnumMessageValues = test.limit
+		sch := compileEnum(t, c, 3)
+		if got := enumError(t, sch, false).Message; got != test.want {
+			t.Errorf("limit %d:\n got %s\nwant %s", test.limit, got, test.want)
+		}
+		if got := enumError(t, sch, false).Details["allowed"]; !reflect.DeepEqual(got, sch.Enum) {
+			t.Errorf("limit %d: allowed got %v", test.limit, got)
+		}
+	}
+}
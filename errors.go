@@ -2,8 +2,10 @@ package jsonschema
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"strings"
+	"sync"
 	"unicode/utf8"
 )
 
@@ -35,6 +37,79 @@ func infiniteLoopError(stack []schemaRef, sref schemaRef) InfiniteLoopError {
 	return InfiniteLoopError(path + "/" + sref.path)
 }
 
+// Errors matched by errors.Is, against errors returned by Compile.
+var (
+	// ErrResourceLoad tells that a resource could not be loaded.
+	ErrResourceLoad = errors.New("jsonschema: resource load failed")
+
+	// ErrFragmentNotFound tells that a resource is loaded, but
+	// the fragment referred in it could not be resolved.
+	ErrFragmentNotFound = errors.New("jsonschema: fragment not found")
+
+	// ErrInvalidFragment tells that a fragment is malformed.
+	ErrInvalidFragment = errors.New("jsonschema: invalid fragment")
+)
+
+// ResourceLoadError is returned by Compile, when a resource could not be loaded.
+// It matches ErrResourceLoad.
+type ResourceLoadError struct {
+	URL string // url of the resource
+	Err error  // error returned by loader
+}
+
+func (e *ResourceLoadError) Error() string {
+	return fmt.Sprintf("jsonschema: error loading %s: %v", e.URL, e.Err)
+}
+
+func (e *ResourceLoadError) Unwrap() error {
+	return e.Err
+}
+
+func (e *ResourceLoadError) Is(target error) bool {
+	return target == ErrResourceLoad
+}
+
+// FragmentNotFoundError is returned by Compile, when a fragment
+// could not be resolved in a resource that is loaded successfully.
+// It matches ErrFragmentNotFound.
+type FragmentNotFoundError struct {
+	URL      string // url of the resource
+	Fragment string // fragment that could not be resolved. for example "#/$defs/adress"
+	Prefix   string // deepest prefix of json-pointer Fragment, that could be resolved. for example "#/$defs"
+}
+
+func (e *FragmentNotFoundError) Error() string {
+	if e.Prefix != "#" {
+		return fmt.Sprintf("jsonschema: %s%s not found, resolved upto %s", e.URL, e.Fragment, e.Prefix)
+	}
+	return fmt.Sprintf("jsonschema: %s%s not found", e.URL, e.Fragment)
+}
+
+func (e *FragmentNotFoundError) Is(target error) bool {
+	return target == ErrFragmentNotFound
+}
+
+// InvalidFragmentError is returned by Compile, when a fragment
+// is neither valid json-pointer nor valid anchor.
+// It matches ErrInvalidFragment.
+type InvalidFragmentError struct {
+	URL      string // url of the resource
+	Fragment string // the malformed fragment
+	Err      error  // describes the problem
+}
+
+func (e *InvalidFragmentError) Error() string {
+	return fmt.Sprintf("jsonschema: invalid fragment %s%s: %v", e.URL, e.Fragment, e.Err)
+}
+
+func (e *InvalidFragmentError) Unwrap() error {
+	return e.Err
+}
+
+func (e *InvalidFragmentError) Is(target error) bool {
+	return target == ErrInvalidFragment
+}
+
 // SchemaError is the error type returned by Compile.
 type SchemaError struct {
 	// SchemaURL is the url to json-schema that filed to compile.
@@ -45,6 +120,22 @@ type SchemaError struct {
 	// It could be ValidationError, because compilation validates
 	// given schema against the json meta-schema
 	Err error
+
+	// ValidationCauses lists the violations of meta-schema, if Err is
+	// because the schema is not valid against it. Their InstanceLocation
+	// is json-pointer into the schema document, which is not valid.
+	ValidationCauses []*ValidationError
+}
+
+// newSchemaError returns SchemaError for the schema at url failed to compile
+// with err.
+func newSchemaError(url string, err error) *SchemaError {
+	se := &SchemaError{SchemaURL: url, Err: err}
+	var ve *ValidationError
+	if errors.As(err, &ve) {
+		se.ValidationCauses = ve.violations(nil)
+	}
+	return se
 }
 
 func (se *SchemaError) Unwrap() error {
@@ -67,12 +158,110 @@ func (se *SchemaError) GoString() string {
 }
 
 // ValidationError is the error type returned by Validate.
+//
+// Details of errors by the following keywords have:
+//   - minProperties, maxProperties, minItems, maxItems, additionalItems:
+//     "limit" and "count" of type int
+//   - minLength, maxLength: "limit" and "length" of type int
+//   - minContains, maxContains: "limit" and "matchedCount" of type int,
+//     "matchedIndexes" of type []int
+//   - contains: "code" of type string, with keyword of leaf error, and
+//     "indexes" of type []int, with all items failing with that keyword.
+//     minContains error has one such cause for each distinct code.
+//   - required: "missing" of type []string
+//   - additionalProperties: "properties" of type []string, with names of
+//     properties not allowed, sorted
+//   - dependencies, dependentRequired: "presentLocation" and "missingLocation"
+//     of type string, with json-pointers of the property present and the
+//     property missing
+//   - uniqueItems: "indexes" of type []int, with indexes of equal items
+//   - enum: "allowed" of type []interface{}, with all values of Schema.Enum,
+//     which must not be modified
 type ValidationError struct {
-	KeywordLocation         string             // validation path of validating keyword or schema
-	AbsoluteKeywordLocation string             // absolute location of validating keyword or schema
-	InstanceLocation        string             // location of the json value within the instance being validated
-	Message                 string             // describes error
-	Causes                  []*ValidationError // nested validation errors
+	Keyword                 string                 // keyword that failed validation, "false" for false schema
+	KeywordLocation         string                 // validation path of validating keyword or schema
+	AbsoluteKeywordLocation string                 // absolute location of validating keyword or schema
+	InstanceLocation        string                 // location of the json value within the instance being validated
+	Message                 string                 // describes error
+	Causes                  []*ValidationError     // nested validation errors
+	Details                 map[string]interface{} // values computed by keyword, see below
+	Trace                   Trace                  // evaluation path, populated only if ValidateOptions.Trace is true
+
+	// pending message, formatted by message
+	schema *Schema
+	path   string // keywordPath of message
+	format string
+	args   []interface{}
+	pooled bool // whether ve is from errorPool
+}
+
+// errorPool has the ValidationErrors released by validation.
+var errorPool = sync.Pool{
+	New: func() interface{} {
+		return new(ValidationError)
+	},
+}
+
+// release returns the errors in err, which are discarded by validation,
+// to errorPool. The errors not from errorPool are left alone.
+func release(err error) {
+	ve, ok := err.(*ValidationError)
+	if !ok || !ve.pooled {
+		return
+	}
+	for _, cause := range ve.Causes {
+		release(cause)
+	}
+	*ve = ValidationError{}
+	errorPool.Put(ve)
+}
+
+// message returns ve.Message, after formatting it if pending.
+//
+// Messages are formatted lazily, so that the errors discarded by
+// validation, such as from failed branches of anyOf, are never formatted.
+func (ve *ValidationError) message() string {
+	if ve.schema != nil {
+		ve.Message = ve.schema.formatError(ve.path, ve.format, ve.args...)
+		ve.schema, ve.args = nil, nil
+	}
+	return ve.Message
+}
+
+// unpool marks ve and its causes as not from errorPool, so that
+// release leaves them alone. This is used for the errors, which may be
+// retained beyond validation, such as by extensions.
+func (ve *ValidationError) unpool() {
+	ve.pooled = false
+	for _, cause := range ve.Causes {
+		cause.unpool()
+	}
+}
+
+// formatMessages formats the pending messages of ve and its causes.
+func (ve *ValidationError) formatMessages() {
+	ve.message()
+	for _, cause := range ve.Causes {
+		cause.formatMessages()
+	}
+}
+
+// isWrapper tells whether ve has empty message, and is used just for
+// wrapping its causes.
+func (ve *ValidationError) isWrapper() bool {
+	if ve.schema != nil && (ve.format == "" || len(ve.schema.Messages) > 0) {
+		// custom messages may apply
+		ve.message()
+	}
+	return ve.schema == nil && ve.Message == ""
+}
+
+func (ve *ValidationError) withDetails(kv ...interface{}) *ValidationError {
+	ve.Details = make(map[string]interface{}, len(kv)/2)
+	for i := 0; i < len(kv); i += 2 {
+		ve.Details[kv[i].(string)] = kv[i+1]
+	}
+	return ve
 }
 
 func (ve *ValidationError) add(causes ...error) error {
@@ -83,8 +272,11 @@ func (ve *ValidationError) add(causes ...error) error {
 }
 
 func (ve *ValidationError) causes(err error) error {
-	if err := err.(*ValidationError); err.Message == "" {
+	if err := err.(*ValidationError); err.isWrapper() {
 		ve.Causes = err.Causes
+		// only the wrapper is discarded
+		err.Causes = nil
+		release(err)
 	} else {
 		ve.add(err)
 	}
@@ -104,16 +296,32 @@ func (ve *ValidationError) leaf() *ValidationError {
 	return ve
 }
 
+// violations appends the distinct violations in ve to list. The causes
+// of anyOf and oneOf are alternatives of the same violation, so such
+// error with multiple causes is a violation itself.
+func (ve *ValidationError) violations(list []*ValidationError) []*ValidationError {
+	if len(ve.Causes) == 0 {
+		return append(list, ve)
+	}
+	if len(ve.Causes) > 1 && (strings.HasSuffix(ve.KeywordLocation, "/anyOf") || strings.HasSuffix(ve.KeywordLocation, "/oneOf")) {
+		return append(list, ve)
+	}
+	for _, cause := range ve.Causes {
+		list = cause.violations(list)
+	}
+	return list
+}
+
 func (ve *ValidationError) Error() string {
 	err := ve.leaf()
 	u, _ := split(ve.AbsoluteKeywordLocation)
-	return fmt.Sprintf("jsonschema: %s does not validate with %s: %s", quote(err.InstanceLocation), u+"#"+err.KeywordLocation, err.Message)
+	return fmt.Sprintf("jsonschema: %s does not validate with %s: %s", quote(err.InstanceLocation), u+"#"+err.KeywordLocation, err.message())
 }
 
 func (ve *ValidationError) GoString() string {
 	sloc := ve.AbsoluteKeywordLocation
 	sloc = sloc[strings.IndexByte(sloc, '#')+1:]
-	msg := fmt.Sprintf("[I#%s] [S#%s] %s", ve.InstanceLocation, sloc, ve.Message)
+	msg := fmt.Sprintf("[I#%s] [S#%s] %s", ve.InstanceLocation, sloc, ve.message())
 	for _, c := range ve.Causes {
 		for _, line := range strings.Split(c.GoString(), "\n") {
 			msg += "\n  " + line
@@ -122,6 +330,48 @@ func (ve *ValidationError) GoString() string {
 	return msg
 }
 
+// TraceStep is a keyword evaluated in the path to a validation error.
+type TraceStep struct {
+	URL string // url of the resource containing the keyword
+	Ptr string // json-pointer to the keyword within the resource
+}
+
+func (ts TraceStep) String() string {
+	return ts.URL + "#" + ts.Ptr
+}
+
+// Trace is the evaluation path to a validation error, starting from the
+// schema being validated. Unlike KeywordLocation, it has a step for each
+// $ref, $recursiveRef and $dynamicRef followed.
+type Trace []TraceStep
+
+// String returns the trace with each step on a separate line,
+// indented by its depth.
+func (t Trace) String() string {
+	var buf strings.Builder
+	for i, step := range t {
+		if i > 0 {
+			buf.WriteByte('\n')
+		}
+		buf.WriteString(strings.Repeat("  ", i))
+		buf.WriteString(step.String())
+	}
+	return buf.String()
+}
+
+func newTrace(scope []schemaRef, keywordPath string) Trace {
+	t := make(Trace, 0, len(scope))
+	step := func(loc string) {
+		u, f := split(loc)
+		t = append(t, TraceStep{u, f[1:]})
+	}
+	for i := 1; i < len(scope); i++ {
+		step(joinPtr(scope[i-1].schema.Location, scope[i].path))
+	}
+	step(joinPtr(scope[len(scope)-1].schema.Location, keywordPath))
+	return t
+}
+
 func joinPtr(ptr1, ptr2 string) string {
 	if len(ptr1) == 0 {
 		return ptr2
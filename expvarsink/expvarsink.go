@@ -0,0 +1,69 @@
+// Package expvarsink implements jsonschema.MetricsSink over expvar.
+//
+// To publish validation metrics of a schema:
+//
+//	sink := expvarsink.New("jsonschema")
+//	err := schema.ValidateWith(doc, jsonschema.ValidateOptions{Metrics: sink})
+//
+// The metrics are published as map, keyed by schema url, with
+// following counters for each schema:
+//   - valid, invalid: number of validations
+//   - durationNs: total time spent in validations
+//   - leafErrors: total number of leaf errors
+//   - keywords: map of number of failed validations, by top keyword
+package expvarsink
+
+import (
+	"expvar"
+	"sync"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// Sink publishes the validation metrics as expvar.Map.
+type Sink struct {
+	mu      sync.Mutex
+	schemas *expvar.Map
+}
+
+var _ jsonschema.MetricsSink = (*Sink)(nil)
+
+// New returns Sink publishing metrics with given name.
+// Like expvar.Publish, it panics if name is already used.
+func New(name string) *Sink {
+	return &Sink{schemas: expvar.NewMap(name)}
+}
+
+// Observe implements jsonschema.MetricsSink.
+func (s *Sink) Observe(schemaURL string, valid bool, durationNs int64, leafErrors int, topKeyword string) {
+	m := s.schema(schemaURL)
+	if valid {
+		m.Add("valid", 1)
+	} else {
+		m.Add("invalid", 1)
+	}
+	m.Add("durationNs", durationNs)
+	m.Add("leafErrors", int64(leafErrors))
+	if topKeyword != "" {
+		m.Get("keywords").(*expvar.Map).Add(topKeyword, 1)
+	}
+}
+
+// schema returns the metrics of schema at given url, creating it if needed.
+func (s *Sink) schema(url string) *expvar.Map {
+	if m, ok := s.schemas.Get(url).(*expvar.Map); ok {
+		return m
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if m, ok := s.schemas.Get(url).(*expvar.Map); ok {
+		return m
+	}
+	m := new(expvar.Map).Init()
+	for _, name := range []string{"valid", "invalid", "durationNs", "leafErrors"} {
+		m.Set(name, new(expvar.Int))
+	}
+	m.Set("keywords", new(expvar.Map).Init())
+	s.schemas.Set(url, m)
+	return m
+}
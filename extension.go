@@ -1,5 +1,11 @@
 package jsonschema
 
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
 // ExtCompiler compiles custom keyword(s) into ExtSchema.
 type ExtCompiler interface {
 	// Compile compiles the custom keywords in schema m and returns its compiled representation.
@@ -20,13 +26,44 @@ type extension struct {
 	compiler ExtCompiler
 }
 
+// RegisterOptions controls the registration of extensions and formats,
+// with RegisterExtensionWith and RegisterFormatWith.
+type RegisterOptions struct {
+	// Override replaces the extension or format already registered with
+	// same name, including the built-in formats. Without it, registering
+	// a name already taken fails.
+	Override bool
+}
+
 // RegisterExtension registers custom keyword(s) into this compiler.
 //
 // name is extension name, used only to avoid name collisions.
 // meta captures the metaschema for the new keywords.
 // This is used to validate the schema before calling ext.Compile.
-func (c *Compiler) RegisterExtension(name string, meta *Schema, ext ExtCompiler) {
+//
+// It fails if an extension is already registered with name.
+// Use RegisterExtensionWith to replace it.
+func (c *Compiler) RegisterExtension(name string, meta *Schema, ext ExtCompiler) error {
+	return c.RegisterExtensionWith(name, meta, ext, RegisterOptions{})
+}
+
+// RegisterExtensionWith is RegisterExtension with options.
+func (c *Compiler) RegisterExtensionWith(name string, meta *Schema, ext ExtCompiler, opts RegisterOptions) error {
+	if _, ok := c.extensions[name]; ok && !opts.Override {
+		return fmt.Errorf("jsonschema: extension %s is already registered", quote(name))
+	}
 	c.extensions[name] = extension{meta, ext}
+	return nil
+}
+
+// RegisteredExtensions returns the names of extensions registered in c, sorted.
+func (c *Compiler) RegisteredExtensions() []string {
+	names := make([]string, 0, len(c.extensions))
+	for name := range c.extensions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
 }
 
 // CompilerContext ---
@@ -73,9 +110,11 @@ func (ctx CompilerContext) CompileRef(ref string, refPath string, applicableOnSa
 // ValidationContext provides additional context required in validating for extension.
 type ValidationContext struct {
 	result          validationResult
+	count           int
 	validate        func(sch *Schema, schPath string, v interface{}, vpath string) error
 	validateInplace func(sch *Schema, schPath string) error
 	validationError func(keywordPath string, format string, a ...interface{}) *ValidationError
+	now             func() time.Time
 }
 
 // EvaluatedProp marks given property of object as evaluated.
@@ -88,6 +127,41 @@ func (ctx ValidationContext) EvaluatedItem(index int) {
 	delete(ctx.result.unevalItems, index)
 }
 
+// Count returns the number of properties or items, if the value being
+// validated is object or array. Otherwise it returns -1.
+func (ctx ValidationContext) Count() int {
+	return ctx.count
+}
+
+// UnevaluatedProps returns sorted names of properties, which are not
+// evaluated so far by other keywords in schema.
+func (ctx ValidationContext) UnevaluatedProps() []string {
+	pnames := make([]string, 0, len(ctx.result.unevalProps))
+	for pname := range ctx.result.unevalProps {
+		pnames = append(pnames, pname)
+	}
+	sort.Strings(pnames)
+	return pnames
+}
+
+// UnevaluatedItems returns sorted indexes of items, which are not
+// evaluated so far by other keywords in schema.
+func (ctx ValidationContext) UnevaluatedItems() []int {
+	indexes := make([]int, 0, len(ctx.result.unevalItems))
+	for i := range ctx.result.unevalItems {
+		indexes = append(indexes, i)
+	}
+	sort.Ints(indexes)
+	return indexes
+}
+
+// Now returns the current time, as per ValidateOptions.Now.
+// Extensions checking values relative to current time must use this
+// instead of time.Now, so that they can be tested deterministically.
+func (ctx ValidationContext) Now() time.Time {
+	return ctx.now()
+}
+
 // Validate validates schema s with value v. Extension must use this method instead of
 // *Schema.ValidateInterface method. This will be useful in implementing keywords like
 // allOf/oneOf
@@ -95,17 +169,28 @@ func (ctx ValidationContext) EvaluatedItem(index int) {
 // spath is relative-json-pointer to s
 // vpath is relative-json-pointer to v.
 func (ctx ValidationContext) Validate(s *Schema, spath string, v interface{}, vpath string) error {
+	var err error
 	if vpath == "" {
-		return ctx.validateInplace(s, spath)
+		err = ctx.validateInplace(s, spath)
+	} else {
+		err = ctx.validate(s, spath, v, vpath)
+	}
+	if ve, ok := err.(*ValidationError); ok {
+		// extension may use the messages, and retain the errors
+		ve.formatMessages()
+		ve.unpool()
 	}
-	return ctx.validate(s, spath, v, vpath)
+	return err
 }
 
 // Error used to construct validation error by extensions.
 //
 // keywordPath is relative-json-pointer to keyword.
 func (ctx ValidationContext) Error(keywordPath string, format string, a ...interface{}) *ValidationError {
-	return ctx.validationError(keywordPath, format, a...)
+	ve := ctx.validationError(keywordPath, format, a...)
+	ve.message()
+	ve.pooled = false // extension may retain it
+	return ve
 }
 
 // Group is used by extensions to group multiple errors as causes to parent error.
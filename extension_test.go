@@ -47,3 +47,50 @@ func TestPowerOfExt(t *testing.T) {
 		})
 	})
 }
+
+type countingCompiler struct {
+	count            *int
+	unevaluatedProps *[]string
+}
+
+func (c countingCompiler) Compile(ctx jsonschema.CompilerContext, m map[string]interface{}) (jsonschema.ExtSchema, error) {
+	if _, ok := m["x-count"]; ok {
+		return c, nil
+	}
+	return nil, nil
+}
+
+func (c countingCompiler) Validate(ctx jsonschema.ValidationContext, v interface{}) error {
+	*c.count = ctx.Count()
+	*c.unevaluatedProps = ctx.UnevaluatedProps()
+	return nil
+}
+
+func TestValidationContext_counts(t *testing.T) {
+	var count int
+	var unevaluatedProps []string
+	c := jsonschema.NewCompiler()
+	c.RegisterExtension("x-count", jsonschema.MustCompileString("x-count.json", `{}`), countingCompiler{&count, &unevaluatedProps})
+	if err := c.AddResource("test.json", strings.NewReader(`{"x-count": true, "properties": {"a": true}}`)); err != nil {
+		t.Fatal(err)
+	}
+	sch, err := c.Compile("test.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sch.Validate(map[string]interface{}{"a": 1, "c": 2, "b": 3}); err != nil {
+		t.Fatal(err)
+	}
+	if count != 3 {
+		t.Errorf("count: got %d, want 3", count)
+	}
+	if strings.Join(unevaluatedProps, ",") != "b,c" {
+		t.Errorf("unevaluatedProps: got %v, want [b c]", unevaluatedProps)
+	}
+	if err := sch.Validate("abc"); err != nil {
+		t.Fatal(err)
+	}
+	if count != -1 {
+		t.Errorf("count: got %d, want -1", count)
+	}
+}
@@ -2,10 +2,12 @@ package jsonschema
 
 import (
 	"errors"
+	"fmt"
 	"net"
 	"net/mail"
 	"net/url"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -15,7 +17,9 @@ import (
 // a specific format.
 //
 // New Formats can be registered by adding to this map. Key is format name,
-// value is function that knows how to validate that format.
+// value is function that knows how to validate that format. To register
+// a format only for a compiler, and detect name collisions, use
+// Compiler.RegisterFormat instead.
 var Formats = map[string]func(interface{}) bool{
 	"date-time":             isDateTime,
 	"date":                  isDate,
@@ -38,6 +42,60 @@ var Formats = map[string]func(interface{}) bool{
 	"uuid":                  isUUID,
 }
 
+// RegisterFormat registers format name into this compiler, which takes
+// precedence over Formats and TimeFormats.
+//
+// It fails if name is already taken, by a format registered in c or by
+// Formats and TimeFormats. Use RegisterFormatWith to replace it.
+func (c *Compiler) RegisterFormat(name string, f func(interface{}) bool) error {
+	return c.RegisterFormatWith(name, f, RegisterOptions{})
+}
+
+// RegisterFormatWith is RegisterFormat with options.
+func (c *Compiler) RegisterFormatWith(name string, f func(interface{}) bool, opts RegisterOptions) error {
+	if !opts.Override {
+		if _, ok := c.formats[name]; ok {
+			return fmt.Errorf("jsonschema: format %s is already registered", quote(name))
+		}
+		_, builtin := Formats[name]
+		if _, ok := TimeFormats[name]; ok {
+			builtin = true
+		}
+		if builtin {
+			return fmt.Errorf("jsonschema: format %s is built-in", quote(name))
+		}
+	}
+	if c.formats == nil {
+		c.formats = make(map[string]func(interface{}) bool)
+	}
+	c.formats[name] = f
+	return nil
+}
+
+// RegisteredFormats returns the names of formats known to c, sorted.
+// These are the formats registered in c, and those in Formats, and in
+// TimeFormats if c.EnableTimeFormats is true.
+func (c *Compiler) RegisteredFormats() []string {
+	set := make(map[string]struct{})
+	for name := range Formats {
+		set[name] = struct{}{}
+	}
+	if c.EnableTimeFormats {
+		for name := range TimeFormats {
+			set[name] = struct{}{}
+		}
+	}
+	for name := range c.formats {
+		set[name] = struct{}{}
+	}
+	names := make([]string, 0, len(set))
+	for name := range set {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
 // isDateTime tells whether given string is a valid date representation
 // as defined by RFC 3339, section 5.6.
 //
@@ -53,7 +111,7 @@ func isDateTime(v interface{}) bool {
 	if s[10] != 'T' && s[10] != 't' {
 		return false
 	}
-	return isDate(s[:10]) && isTime(s[11:])
+	return isDate(s[:10]) && parseTime(s[11:], TimeOffsetRequired)
 }
 
 // isDate tells whether given string is a valid full-date production
@@ -78,16 +136,55 @@ func isTime(v interface{}) bool {
 	if !ok {
 		return true
 	}
+	return parseTime(str, TimeOffsetRequired)
+}
+
+// TimeOffset tells whether time-offset is allowed in "time" format.
+type TimeOffset int
+
+const (
+	// TimeOffsetRequired accepts only full-time of RFC 3339, which has time-offset.
+	// This is the default for "time" format.
+	TimeOffsetRequired TimeOffset = iota
+
+	// TimeOffsetOptional accepts both full-time and partial-time of RFC 3339.
+	TimeOffsetOptional
 
+	// TimeOffsetForbidden accepts only partial-time of RFC 3339, which has no time-offset.
+	TimeOffsetForbidden
+)
+
+// TimeFormat returns the function validating "time" format, with given
+// policy for time-offset. For example, to accept time without offset:
+//
+//	jsonschema.Formats["time"] = jsonschema.TimeFormat(jsonschema.TimeOffsetOptional)
+func TimeFormat(offset TimeOffset) func(interface{}) bool {
+	return func(v interface{}) bool {
+		str, ok := v.(string)
+		if !ok {
+			return true
+		}
+		return parseTime(str, offset)
+	}
+}
+
+// parseTime tells whether given string is a valid time, as per given offset policy.
+func parseTime(str string, offset TimeOffset) bool {
 	// golang time package does not support leap seconds.
 	// so we are parsing it manually here.
 
 	// hh:mm:ss
 	// 01234567
-	if len(str) < 9 || str[2] != ':' || str[5] != ':' {
+	if len(str) < 8 || str[2] != ':' || str[5] != ':' {
 		return false
 	}
 	isInRange := func(str string, min, max int) (int, bool) {
+		// strconv.Atoi allows sign, which is not allowed here
+		for i := 0; i < len(str); i++ {
+			if str[i] < '0' || str[i] > '9' {
+				return 0, false
+			}
+		}
 		n, err := strconv.Atoi(str)
 		if err != nil {
 			return 0, false
@@ -98,6 +195,7 @@ func isTime(v interface{}) bool {
 		return n, true
 	}
 	var h, m, s int
+	var ok bool
 	if h, ok = isInRange(str[0:2], 0, 23); !ok {
 		return false
 	}
@@ -110,7 +208,7 @@ func isTime(v interface{}) bool {
 	str = str[8:]
 
 	// parse secfrac if present
-	if str[0] == '.' {
+	if str != "" && str[0] == '.' {
 		// dot following more than one digit
 		str = str[1:]
 		var numDigits int
@@ -127,10 +225,13 @@ func isTime(v interface{}) bool {
 	}
 
 	if len(str) == 0 {
+		if offset == TimeOffsetRequired {
+			return false
+		}
+		// without offset, leap second is checked in local time
+	} else if offset == TimeOffsetForbidden {
 		return false
-	}
-
-	if str[0] == 'z' || str[0] == 'Z' {
+	} else if str[0] == 'z' || str[0] == 'Z' {
 		if len(str) != 1 {
 			return false
 		}
@@ -396,6 +497,64 @@ func isURIReference(v interface{}) bool {
 	return err == nil && !strings.Contains(s, `\`)
 }
 
+// URIOptions tightens the validation of uri, iri, uri-reference,
+// iri-reference and uriref formats. Zero value imposes no restriction
+// beyond the specification.
+type URIOptions struct {
+	RequireAbsolute bool     // references must be absolute uri
+	AllowedSchemes  []string // if not empty, only these schemes are allowed. compared case-insensitively
+	ForbidUserinfo  bool     // userinfo such as "user:password@" is not allowed
+	ForbidFragment  bool     // fragment, even if empty, is not allowed
+}
+
+func (opts URIOptions) isZero() bool {
+	return !opts.RequireAbsolute && len(opts.AllowedSchemes) == 0 && !opts.ForbidUserinfo && !opts.ForbidFragment
+}
+
+// uriFormats are the formats to which URIOptions apply.
+var uriFormats = map[string]bool{
+	"uri":           true,
+	"iri":           true,
+	"uri-reference": true,
+	"uriref":        true,
+	"iri-reference": true,
+}
+
+// check tells whether v satisfies opts. Relative references
+// have no scheme, so they are not checked against AllowedSchemes.
+func (opts URIOptions) check(v interface{}) bool {
+	s, ok := v.(string)
+	if !ok {
+		return true
+	}
+	u, err := urlParse(s)
+	if err != nil {
+		return false
+	}
+	if opts.RequireAbsolute && !u.IsAbs() {
+		return false
+	}
+	if len(opts.AllowedSchemes) > 0 && u.Scheme != "" {
+		allowed := false
+		for _, scheme := range opts.AllowedSchemes {
+			if strings.EqualFold(scheme, u.Scheme) {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return false
+		}
+	}
+	if opts.ForbidUserinfo && u.User != nil {
+		return false
+	}
+	if opts.ForbidFragment && strings.IndexByte(s, '#') != -1 {
+		return false
+	}
+	return true
+}
+
 // isURITemplate tells whether given string is a valid URI Template
 // according to RFC6570.
 //
@@ -0,0 +1,433 @@
+// Package httpvalidate validates json bodies of http requests, using
+// jsonschema.
+//
+// To validate the requests of a handler:
+//
+//	sch := jsonschema.MustCompile("person.json")
+//	handler = httpvalidate.Middleware(sch, httpvalidate.Options{})(handler)
+//
+// The handler reads the decoded body using Document:
+//
+//	doc, _ := httpvalidate.Document(r.Context())
+//
+// The requests failing validation are answered with the errors in basic
+// output format, or as RFC 9457 problem details, and are not passed to
+// the handler.
+package httpvalidate
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// Direction tells whether a document is request or response body.
+// readOnly values must not be sent in requests, and writeOnly values
+// must not be sent in responses.
+type Direction int
+
+const (
+	// Request rejects the values whose schema is readOnly.
+	Request Direction = iota
+
+	// Response rejects the values whose schema is writeOnly.
+	Response
+)
+
+func (d Direction) String() string {
+	if d == Response {
+		return "response"
+	}
+	return "request"
+}
+
+// DefaultMaxBodySize is the body size limit used, if Options.MaxBodySize is zero.
+const DefaultMaxBodySize = 1 << 20
+
+// Options controls the validation of requests.
+type Options struct {
+	// Schema returns the schema to validate r, such as by its method and
+	// path. If it returns nil, r is passed to next handler without reading
+	// its body. If Schema is nil, the schema given to Middleware is used.
+	Schema func(r *http.Request) *jsonschema.Schema
+
+	// MaxBodySize limits the size of body in bytes. Zero means
+	// DefaultMaxBodySize and negative means no limit.
+	MaxBodySize int64
+
+	// Limits are enforced while decoding the body. See jsonschema.Decoder.
+	Limits jsonschema.Limits
+
+	// Validate is used to validate the document.
+	Validate jsonschema.ValidateOptions
+
+	// Direction tells which of readOnly and writeOnly values are rejected.
+	// These annotations are available only if Compiler.ExtractAnnotations
+	// was true.
+	Direction Direction
+
+	// Problem writes errors as problem details of RFC 9457, with media type
+	// "application/problem+json". By default, errors are written in basic
+	// output format with media type "application/json".
+	Problem bool
+
+	// ProblemOptions is used to write validation errors, if Problem is true.
+	ProblemOptions jsonschema.ProblemOptions
+}
+
+type contextKey struct{}
+
+// Document returns the decoded body of the request validated by Middleware.
+func Document(ctx context.Context) (interface{}, bool) {
+	doc, ok := ctx.Value(contextKey{}).(*document)
+	if !ok {
+		return nil, false
+	}
+	return doc.v, true
+}
+
+// document wraps decoded body, so that nil body is distinguished from
+// missing one in context.
+type document struct {
+	v interface{}
+}
+
+// Middleware returns middleware validating the json body of requests
+// against s, or the schema returned by opts.Schema. The body must have
+// media type "application/json" or with suffix "+json".
+//
+// The request is answered with status:
+//   - 415, if the body has some other media type.
+//   - 413, if the body is larger than opts.MaxBodySize, or exceeds
+//     opts.Validate.Limits.
+//   - 400, if the body is not valid json or exceeds opts.Limits.
+//   - 422, or opts.ProblemOptions.Status, if the body is not valid
+//     against the schema.
+//
+// Otherwise, the request is passed to next handler, with decoded body
+// in its context. See Document.
+func Middleware(s *jsonschema.Schema, opts Options) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			sch := s
+			if opts.Schema != nil {
+				sch = opts.Schema(r)
+			}
+			if sch == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+			doc, status, err := opts.decode(r)
+			if err == nil {
+				if err = Validate(sch, doc, opts); err != nil {
+					status = http.StatusInternalServerError
+					if _, ok := err.(*jsonschema.ValidationError); ok {
+						status = http.StatusUnprocessableEntity
+						if opts.Problem && opts.ProblemOptions.Status != 0 {
+							status = opts.ProblemOptions.Status
+						}
+					} else if errors.Is(err, jsonschema.ErrBudgetExceeded) {
+						status = http.StatusBadRequest
+					} else if _, ok := err.(*jsonschema.LimitError); ok {
+						status = http.StatusRequestEntityTooLarge
+					}
+				}
+			}
+			if err != nil {
+				opts.writeError(w, status, err)
+				return
+			}
+			ctx := context.WithValue(r.Context(), contextKey{}, &document{doc})
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// errTooLarge is returned by bodyReader, once the body exceeds the limit.
+var errTooLarge = errors.New("request body too large")
+
+// decode returns the decoded body of r. On failure, it returns the
+// status to be answered with.
+func (opts *Options) decode(r *http.Request) (interface{}, int, error) {
+	ct := r.Header.Get("Content-Type")
+	if !isJSON(ct) {
+		return nil, http.StatusUnsupportedMediaType, fmt.Errorf("unsupported media type %s", strconv.Quote(ct))
+	}
+	max := opts.MaxBodySize
+	if max == 0 {
+		max = DefaultMaxBodySize
+	}
+	if max > 0 && r.ContentLength > max {
+		return nil, http.StatusRequestEntityTooLarge, errTooLarge
+	}
+	var body io.Reader = r.Body
+	if body == nil {
+		body = http.NoBody
+	}
+	var br *bodyReader
+	if max > 0 {
+		br = &bodyReader{r: body, n: max}
+		body = br
+	}
+	doc, err := (&jsonschema.Decoder{Limits: opts.Limits}).Decode(body)
+	if br != nil && br.exceeded {
+		return nil, http.StatusRequestEntityTooLarge, errTooLarge
+	}
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	return doc, 0, nil
+}
+
+// isJSON tells whether media type ct is json.
+func isJSON(ct string) bool {
+	mt, _, err := mime.ParseMediaType(ct)
+	if err != nil {
+		return false
+	}
+	return mt == "application/json" || strings.HasSuffix(mt, "+json")
+}
+
+// bodyReader reads at most n bytes from r, and reports whether r has more.
+type bodyReader struct {
+	r        io.Reader
+	n        int64
+	exceeded bool
+}
+
+func (br *bodyReader) Read(p []byte) (int, error) {
+	if int64(len(p)) > br.n+1 {
+		p = p[:br.n+1]
+	}
+	n, err := br.r.Read(p)
+	if int64(n) > br.n {
+		br.exceeded = true
+		n, br.n = int(br.n), 0
+		return n, errTooLarge
+	}
+	br.n -= int64(n)
+	return n, err
+}
+
+// writeError answers the request with err, in the format chosen by opts.
+func (opts *Options) writeError(w http.ResponseWriter, status int, err error) {
+	detail := err.Error()
+	if status >= 500 {
+		// do not expose internal errors
+		detail = http.StatusText(status)
+	}
+
+	var body []byte
+	ve, _ := err.(*jsonschema.ValidationError)
+	switch {
+	case opts.Problem && ve != nil:
+		body, err = jsonschema.ProblemDetails(ve, opts.ProblemOptions)
+	case opts.Problem:
+		body, err = json.Marshal(jsonschema.Problem{
+			Type:   "about:blank",
+			Title:  http.StatusText(status),
+			Status: status,
+			Detail: detail,
+			Errors: []jsonschema.ProblemError{},
+		})
+	case ve != nil:
+		body, err = json.Marshal(ve.BasicOutput())
+	default:
+		body, err = json.Marshal(jsonschema.Basic{
+			Errors: []jsonschema.BasicError{{Error: detail}},
+		})
+	}
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	if opts.Problem {
+		w.Header().Set("Content-Type", "application/problem+json")
+	} else {
+		w.Header().Set("Content-Type", "application/json")
+	}
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	_, _ = w.Write(body)
+}
+
+// Validate validates doc against s, with opts.Validate. In addition,
+// the values whose schema is readOnly or writeOnly are reported as
+// per opts.Direction. This is useful to validate documents outside
+// Middleware, such as response bodies:
+//
+//	err := httpvalidate.Validate(sch, doc, httpvalidate.Options{Direction: httpvalidate.Response})
+func Validate(s *jsonschema.Schema, doc interface{}, opts Options) error {
+	err := s.ValidateWith(doc, opts.Validate)
+	ve, ok := err.(*jsonschema.ValidationError)
+	if err != nil && !ok {
+		return err
+	}
+
+	keyword := "readOnly"
+	if opts.Direction == Response {
+		keyword = "writeOnly"
+	}
+	vopts := opts.Validate
+	vopts.Metrics = nil // branches tried are not validations of document
+	a := &accessChecker{keyword: keyword, dir: opts.Direction, opts: vopts}
+	a.check(s, "", doc, "", make(map[*jsonschema.Schema]bool))
+	if len(a.errors) == 0 {
+		return err
+	}
+	if ve == nil {
+		root := s.Resolve()
+		ve = &jsonschema.ValidationError{
+			AbsoluteKeywordLocation: root.Location,
+			Message:                 fmt.Sprintf("doesn't validate with %s", root.Location),
+		}
+	}
+	ve.Causes = append(ve.Causes, a.errors...)
+	return ve
+}
+
+// accessChecker collects the values, whose schema has keyword
+// readOnly or writeOnly. Only the subschemas of anyOf, oneOf and if,
+// which v is valid against, apply to it.
+type accessChecker struct {
+	keyword string
+	dir     Direction
+	opts    jsonschema.ValidateOptions // to find the subschemas v is valid against
+	errors  []*jsonschema.ValidationError
+}
+
+// valid tells whether v is valid against s.
+func (a *accessChecker) valid(s *jsonschema.Schema, v interface{}) bool {
+	return s.ValidateWith(v, a.opts) == nil
+}
+
+// check checks v at vloc, against s at kloc. seen has the schemas
+// already checked against v.
+func (a *accessChecker) check(s *jsonschema.Schema, kloc string, v interface{}, vloc string, seen map[*jsonschema.Schema]bool) {
+	if s == nil || seen[s] {
+		return
+	}
+	seen[s] = true
+	if (a.dir == Request && s.ReadOnly) || (a.dir == Response && s.WriteOnly) {
+		a.errors = append(a.errors, &jsonschema.ValidationError{
+			Keyword:                 a.keyword,
+			KeywordLocation:         kloc + "/" + a.keyword,
+			AbsoluteKeywordLocation: s.Location + "/" + a.keyword,
+			InstanceLocation:        vloc,
+			Message:                 fmt.Sprintf("%s value not allowed in %s", a.keyword, a.dir),
+		})
+		return
+	}
+
+	a.check(s.Ref, kloc+"/$ref", v, vloc, seen)
+	for i, sch := range s.AllOf {
+		a.check(sch, kloc+"/allOf/"+strconv.Itoa(i), v, vloc, seen)
+	}
+	for i, sch := range s.AnyOf {
+		if a.valid(sch, v) {
+			a.check(sch, kloc+"/anyOf/"+strconv.Itoa(i), v, vloc, seen)
+		}
+	}
+	for i, sch := range s.OneOf {
+		if a.valid(sch, v) {
+			a.check(sch, kloc+"/oneOf/"+strconv.Itoa(i), v, vloc, seen)
+		}
+	}
+	if s.If != nil {
+		if a.valid(s.If, v) {
+			a.check(s.If, kloc+"/if", v, vloc, seen)
+			a.check(s.Then, kloc+"/then", v, vloc, seen)
+		} else {
+			a.check(s.Else, kloc+"/else", v, vloc, seen)
+		}
+	}
+	if obj, ok := v.(map[string]interface{}); ok {
+		dnames := make([]string, 0, len(s.DependentSchemas)+len(s.Dependencies))
+		for dname := range s.DependentSchemas {
+			dnames = append(dnames, dname)
+		}
+		for dname := range s.Dependencies {
+			dnames = append(dnames, dname)
+		}
+		sort.Strings(dnames)
+		for _, dname := range dnames {
+			if _, ok := obj[dname]; !ok {
+				continue
+			}
+			if sch, ok := s.DependentSchemas[dname]; ok {
+				a.check(sch, kloc+"/dependentSchemas/"+escape(dname), v, vloc, seen)
+			}
+			if sch, ok := s.Dependencies[dname].(*jsonschema.Schema); ok {
+				a.check(sch, kloc+"/dependencies/"+escape(dname), v, vloc, seen)
+			}
+		}
+	}
+
+	// the values nested in v, are checked with fresh seen
+	switch v := v.(type) {
+	case map[string]interface{}:
+		pnames := make([]string, 0, len(v))
+		for pname := range v {
+			pnames = append(pnames, pname)
+		}
+		sort.Strings(pnames)
+		for _, pname := range pnames {
+			pvalue, ploc := v[pname], vloc+"/"+escape(pname)
+			matched := false
+			if sch, ok := s.Properties[pname]; ok {
+				matched = true
+				a.check(sch, kloc+"/properties/"+escape(pname), pvalue, ploc, make(map[*jsonschema.Schema]bool))
+			}
+			for pattern, sch := range s.PatternProperties {
+				if pattern.MatchString(pname) {
+					matched = true
+					a.check(sch, kloc+"/patternProperties/"+escape(pattern.String()), pvalue, ploc, make(map[*jsonschema.Schema]bool))
+				}
+			}
+			if sch, ok := s.AdditionalProperties.(*jsonschema.Schema); ok && !matched {
+				a.check(sch, kloc+"/additionalProperties", pvalue, ploc, make(map[*jsonschema.Schema]bool))
+			}
+		}
+	case []interface{}:
+		for i, item := range v {
+			iloc := vloc + "/" + strconv.Itoa(i)
+			check := func(sch *jsonschema.Schema, kloc string) {
+				a.check(sch, kloc, item, iloc, make(map[*jsonschema.Schema]bool))
+			}
+			switch items := s.Items.(type) {
+			case *jsonschema.Schema:
+				check(items, kloc+"/items")
+			case []*jsonschema.Schema:
+				if i < len(items) {
+					check(items[i], kloc+"/items/"+strconv.Itoa(i))
+				} else if sch, ok := s.AdditionalItems.(*jsonschema.Schema); ok {
+					check(sch, kloc+"/additionalItems")
+				}
+			}
+			if i < len(s.PrefixItems) {
+				check(s.PrefixItems[i], kloc+"/prefixItems/"+strconv.Itoa(i))
+			} else if s.Items2020 != nil {
+				check(s.Items2020, kloc+"/items")
+			}
+		}
+	}
+}
+
+// escape escapes json-pointer token for use in uri fragment, as in
+// ValidationError.InstanceLocation.
+func escape(token string) string {
+	token = strings.Replace(token, "~", "~0", -1)
+	token = strings.Replace(token, "/", "~1", -1)
+	return url.PathEscape(token)
+}
@@ -0,0 +1,280 @@
+package httpvalidate_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+	"github.com/santhosh-tekuri/jsonschema/v5/httpvalidate"
+)
+
+const personSchema = `{
+	"type": "object",
+	"properties": {
+		"id": {"type": "integer", "readOnly": true},
+		"name": {"type": "string"},
+		"password": {"type": "string", "writeOnly": true},
+		"friends": {"type": "array", "items": {"$ref": "#"}}
+	},
+	"required": ["name"]
+}`
+
+func compile(t *testing.T) *jsonschema.Schema {
+	t.Helper()
+	c := jsonschema.NewCompiler()
+	c.ExtractAnnotations = true
+	if err := c.AddResource("person.json", strings.NewReader(personSchema)); err != nil {
+		t.Fatal(err)
+	}
+	sch, err := c.Compile("person.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sch
+}
+
+// echo writes the document validated, or 204 if request was not validated.
+var echo = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	doc, ok := httpvalidate.Document(r.Context())
+	if !ok {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	_ = json.NewEncoder(w).Encode(doc)
+})
+
+func serve(h http.Handler, method, ct, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, "/people", strings.NewReader(body))
+	if ct != "" {
+		r.Header.Set("Content-Type", ct)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func TestMiddleware(t *testing.T) {
+	h := httpvalidate.Middleware(compile(t), httpvalidate.Options{MaxBodySize: 64})(echo)
+	tests := []struct {
+		name   string
+		ct     string
+		body   string
+		status int
+	}{
+		{"valid", "application/json", `{"name": "bob", "age": 1.50}`, http.StatusOK},
+		{"jsonSuffix", "application/person+json; charset=utf-8", `{"name": "bob"}`, http.StatusOK},
+		{"noContentType", "", `{"name": "bob"}`, http.StatusUnsupportedMediaType},
+		{"text", "text/plain", `{"name": "bob"}`, http.StatusUnsupportedMediaType},
+		{"syntax", "application/json", `{"name": "bob",}`, http.StatusBadRequest},
+		{"trailing", "application/json", `{"name": "bob"} {}`, http.StatusBadRequest},
+		{"empty", "application/json", ``, http.StatusBadRequest},
+		{"invalid", "application/json", `{"name": 1}`, http.StatusUnprocessableEntity},
+		{"readOnly", "application/json", `{"id": 1, "name": "bob"}`, http.StatusUnprocessableEntity},
+		{"writeOnly", "application/json", `{"name": "bob", "password": "secret"}`, http.StatusOK},
+		{"oversized", "application/json", `{"name": "` + strings.Repeat("a", 64) + `"}`, http.StatusRequestEntityTooLarge},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			w := serve(h, http.MethodPost, test.ct, test.body)
+			if w.Code != test.status {
+				t.Fatalf("got status %d, want %d: %s", w.Code, test.status, w.Body)
+			}
+			if got := w.Header().Get("Content-Type"); got != "application/json" && w.Code != http.StatusOK {
+				t.Fatalf("got Content-Type %q", got)
+			}
+		})
+	}
+
+	// numbers are passed to handler as decoded
+	w := serve(h, http.MethodPost, "application/json", `{"name": "bob", "age": 1.50}`)
+	if got := strings.TrimSpace(w.Body.String()); got != `{"age":1.50,"name":"bob"}` {
+		t.Fatalf("got %s", got)
+	}
+}
+
+func TestMiddleware_basicOutput(t *testing.T) {
+	h := httpvalidate.Middleware(compile(t), httpvalidate.Options{})(echo)
+	w := serve(h, http.MethodPost, "application/json", `{"id": 1, "friends": [{"name": 2}]}`)
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("got status %d", w.Code)
+	}
+	var out jsonschema.Basic
+	if err := json.Unmarshal(w.Body.Bytes(), &out); err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]string{}
+	for _, e := range out.Errors {
+		got[e.InstanceLocation+" "+e.KeywordLocation] = e.Error
+	}
+	for _, want := range []string{
+		" /required",
+		"/friends/0/name /properties/friends/items/$ref/properties/name/type",
+		"/id /properties/id/readOnly",
+	} {
+		if _, ok := got[want]; !ok {
+			t.Errorf("missing error at %q in %v", want, got)
+		}
+	}
+	if msg := got["/id /properties/id/readOnly"]; msg != "readOnly value not allowed in request" {
+		t.Errorf("got message %q", msg)
+	}
+}
+
+func TestMiddleware_problem(t *testing.T) {
+	opts := httpvalidate.Options{
+		Problem:        true,
+		ProblemOptions: jsonschema.ProblemOptions{Type: "https://example.com/probs/invalid", Status: http.StatusBadRequest},
+	}
+	h := httpvalidate.Middleware(compile(t), opts)(echo)
+
+	w := serve(h, http.MethodPost, "application/json", `{"name": 1}`)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("got status %d", w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/problem+json" {
+		t.Fatalf("got Content-Type %q", ct)
+	}
+	var p jsonschema.Problem
+	if err := json.Unmarshal(w.Body.Bytes(), &p); err != nil {
+		t.Fatal(err)
+	}
+	if p.Type != opts.ProblemOptions.Type || len(p.Errors) != 1 || p.Errors[0].Pointer != "#/name" {
+		t.Fatalf("got %+v", p)
+	}
+
+	// syntax error is problem too
+	w = serve(h, http.MethodPost, "application/json", `{`)
+	p = jsonschema.Problem{}
+	if err := json.Unmarshal(w.Body.Bytes(), &p); err != nil {
+		t.Fatal(err)
+	}
+	if w.Code != http.StatusBadRequest || p.Type != "about:blank" || p.Status != http.StatusBadRequest || p.Detail == "" {
+		t.Fatalf("got %d %+v", w.Code, p)
+	}
+}
+
+func TestMiddleware_routing(t *testing.T) {
+	sch := compile(t)
+	opts := httpvalidate.Options{
+		Schema: func(r *http.Request) *jsonschema.Schema {
+			if r.Method == http.MethodPost && r.URL.Path == "/people" {
+				return sch
+			}
+			return nil
+		},
+	}
+	h := httpvalidate.Middleware(nil, opts)(echo)
+	if w := serve(h, http.MethodGet, "", ""); w.Code != http.StatusNoContent {
+		t.Fatalf("GET: got status %d", w.Code)
+	}
+	if w := serve(h, http.MethodPost, "application/json", `{}`); w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("POST: got status %d", w.Code)
+	}
+}
+
+func TestMiddleware_validateLimits(t *testing.T) {
+	opts := httpvalidate.Options{Validate: jsonschema.ValidateOptions{Limits: jsonschema.Limits{MaxItems: 2}}}
+	h := httpvalidate.Middleware(compile(t), opts)(echo)
+	w := serve(h, http.MethodPost, "application/json", `{"name": "bob", "friends": [{"name": "a"}, {"name": "b"}, {"name": "c"}]}`)
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("got status %d: %s", w.Code, w.Body)
+	}
+	if !strings.Contains(w.Body.String(), "MaxItems") {
+		t.Fatalf("limit not reported: %s", w.Body)
+	}
+	if w := serve(h, http.MethodPost, "application/json", `{"name": "bob", "friends": [{"name": "a"}]}`); w.Code != http.StatusOK {
+		t.Fatalf("got status %d: %s", w.Code, w.Body)
+	}
+}
+
+func TestMiddleware_chunked(t *testing.T) {
+	h := httpvalidate.Middleware(compile(t), httpvalidate.Options{MaxBodySize: 32})(echo)
+	body := `{"name": "` + strings.Repeat("a", 1000) + `"}`
+	r := httptest.NewRequest(http.MethodPost, "/people", ioutil.NopCloser(strings.NewReader(body)))
+	r.ContentLength = -1
+	r.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("got status %d", w.Code)
+	}
+}
+
+func TestValidate_response(t *testing.T) {
+	sch := compile(t)
+	doc := map[string]interface{}{"id": 1, "name": "bob", "password": "secret"}
+	err := httpvalidate.Validate(sch, doc, httpvalidate.Options{Direction: httpvalidate.Response})
+	ve, ok := err.(*jsonschema.ValidationError)
+	if !ok {
+		t.Fatalf("got %v, want *ValidationError", err)
+	}
+	if len(ve.Causes) != 1 || ve.Causes[0].InstanceLocation != "/password" || ve.Causes[0].Keyword != "writeOnly" {
+		t.Fatalf("got %s", fmt.Sprintf("%#v", ve))
+	}
+	delete(doc, "password")
+	if err := httpvalidate.Validate(sch, doc, httpvalidate.Options{Direction: httpvalidate.Response}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestValidate_applicators(t *testing.T) {
+	c := jsonschema.NewCompiler()
+	c.ExtractAnnotations = true
+	schema := `{
+		"properties": {
+			"anyOf": {"anyOf": [{"type": "string", "readOnly": true}, {"type": "integer"}]},
+			"oneOf": {"oneOf": [{"type": "string", "readOnly": true}, {"type": "integer"}]},
+			"then": {"if": {"type": "string"}, "then": {"readOnly": true}},
+			"else": {"if": {"type": "string"}, "else": {"readOnly": true}},
+			"dependentSchemas": {"dependentSchemas": {"a": {"properties": {"b": {"readOnly": true}}}}}
+		}
+	}`
+	if err := c.AddResource("schema.json", strings.NewReader(schema)); err != nil {
+		t.Fatal(err)
+	}
+	sch, err := c.Compile("schema.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		doc string
+		loc string // instance location of readOnly error, if any
+	}{
+		{`{"anyOf": "x"}`, "/anyOf"},
+		{`{"anyOf": 1}`, ""},
+		{`{"oneOf": "x"}`, "/oneOf"},
+		{`{"oneOf": 1}`, ""},
+		{`{"then": "x"}`, "/then"},
+		{`{"then": 1}`, ""},
+		{`{"else": 1}`, "/else"},
+		{`{"else": "x"}`, ""},
+		{`{"dependentSchemas": {"a": 1, "b": 2}}`, "/dependentSchemas/b"},
+		{`{"dependentSchemas": {"b": 2}}`, ""},
+	}
+	for _, test := range tests {
+		var doc interface{}
+		if err := json.Unmarshal([]byte(test.doc), &doc); err != nil {
+			t.Fatal(err)
+		}
+		err := httpvalidate.Validate(sch, doc, httpvalidate.Options{})
+		if test.loc == "" {
+			if err != nil {
+				t.Errorf("%s: %v", test.doc, err)
+			}
+			continue
+		}
+		ve, ok := err.(*jsonschema.ValidationError)
+		if !ok {
+			t.Errorf("%s: got %v, want *ValidationError", test.doc, err)
+			continue
+		}
+		if len(ve.Causes) != 1 || ve.Causes[0].Keyword != "readOnly" || ve.Causes[0].InstanceLocation != test.loc {
+			t.Errorf("%s: got %#v", test.doc, ve)
+		}
+	}
+}
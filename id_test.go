@@ -0,0 +1,50 @@
+package jsonschema_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+func TestCompiler_invalidID(t *testing.T) {
+	tests := []struct {
+		draft  *jsonschema.Draft
+		schema string
+		errMsg string // empty if valid
+	}{
+		{jsonschema.Draft7, `{"$id": ""}`, `invalid $id '' at '#': must not be empty`},
+		{jsonschema.Draft7, `{"definitions": {"a": {"$id": ""}}}`, `invalid $id '' at '#/definitions/a': must not be empty`},
+		{jsonschema.Draft4, `{"definitions": {"a": {"id": ""}}}`, `invalid id '' at '#/definitions/a': must not be empty`},
+		{jsonschema.Draft2020, `{"$defs": {"a": {"$id": ""}}}`, `invalid $id '' at '#/$defs/a': must not be empty`},
+		{jsonschema.Draft7, `{"definitions": {"a": {"$id": "#"}}}`, ""},
+		{jsonschema.Draft7, `{"definitions": {"a": {"$id": "#foo"}}}`, ""},
+		{jsonschema.Draft7, `{"definitions": {"a": {"$id": "a.json#foo"}}}`, ""},
+		{jsonschema.Draft7, `{"definitions": {"a": {"$id": "#/a/b"}}}`, `invalid $id '#/a/b' at '#/definitions/a': fragment must be plain name`},
+		{jsonschema.Draft7, `{"definitions": {"a": {"$id": "", "$ref": "#"}}}`, ""}, // $id ignored
+		{jsonschema.Draft2020, `{"$defs": {"a": {"$id": "a.json#"}}}`, ""},
+		{jsonschema.Draft2020, `{"$id": "http://example.com/root.json#foo"}`, `invalid $id 'http://example.com/root.json#foo' at '#': must not have non-empty fragment`},
+		{jsonschema.Draft2019, `{"$defs": {"a": {"$id": "#foo"}}}`, `'/$defs/a/$id' does not validate`}, // by metaschema
+	}
+	for _, test := range tests {
+		c := jsonschema.NewCompiler()
+		c.Draft = test.draft
+		if err := c.AddResource("http://example.com/schema.json", strings.NewReader(test.schema)); err != nil {
+			t.Fatal(err)
+		}
+		_, err := c.Compile("http://example.com/schema.json")
+		if test.errMsg == "" {
+			if err != nil {
+				t.Errorf("%s: %v", test.schema, err)
+			}
+			continue
+		}
+		if _, ok := err.(*jsonschema.SchemaError); !ok {
+			t.Errorf("%s: got %v, want *SchemaError", test.schema, err)
+			continue
+		}
+		if !strings.Contains(err.Error(), test.errMsg) {
+			t.Errorf("%s: got %q, want %q", test.schema, err, test.errMsg)
+		}
+	}
+}
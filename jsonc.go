@@ -0,0 +1,115 @@
+package jsonschema
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+)
+
+// DecodeJSONC decodes single JSONC document from r, with numbers decoded
+// as json.Number. JSONC is json with // and /* */ comments, and trailing
+// commas in objects and arrays, as used by editors for configuration.
+//
+// The comments and trailing commas are replaced with spaces, before
+// decoding as json. Thus *DecodeError reports the line and column of
+// the error in r.
+func DecodeJSONC(r io.Reader) (interface{}, error) {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return decodeBytes(stripJSONC(b))
+}
+
+// stripJSONC returns copy of b, with comments and trailing commas outside
+// strings replaced with spaces. Line breaks in comments are retained, so
+// that the positions are not changed. Unterminated block comment is
+// retained, to be reported by the json decoder.
+func stripJSONC(b []byte) []byte {
+	out := make([]byte, len(b))
+	copy(out, b)
+	blank := func(from, to int) {
+		for i := from; i < to; i++ {
+			if out[i] != '\n' && out[i] != '\r' {
+				out[i] = ' '
+			}
+		}
+	}
+
+	// comments
+	for i := 0; i < len(out); i++ {
+		switch out[i] {
+		case '"':
+			i = skipString(out, i)
+		case '/':
+			if i+1 == len(out) {
+				break
+			}
+			switch out[i+1] {
+			case '/':
+				end := i + 2
+				for end < len(out) && out[end] != '\n' && out[end] != '\r' {
+					end++
+				}
+				blank(i, end)
+				i = end - 1
+			case '*':
+				end := -1
+				for j := i + 2; j+1 < len(out); j++ {
+					if out[j] == '*' && out[j+1] == '/' {
+						end = j + 2
+						break
+					}
+				}
+				if end == -1 {
+					return out
+				}
+				blank(i, end)
+				i = end - 1
+			}
+		}
+	}
+
+	// trailing commas
+	comma := -1
+	for i := 0; i < len(out); i++ {
+		switch out[i] {
+		case ' ', '\t', '\n', '\r':
+			continue
+		case '"':
+			i = skipString(out, i)
+		case '}', ']':
+			if comma != -1 {
+				out[comma] = ' '
+			}
+		}
+		comma = -1
+		if out[i] == ',' {
+			comma = i
+		}
+	}
+	return out
+}
+
+// skipString returns the index of the closing quote of the string
+// starting at b[i], or len(b) if it is unterminated.
+func skipString(b []byte, i int) int {
+	for i++; i < len(b); i++ {
+		switch b[i] {
+		case '\\':
+			i++
+		case '"':
+			return i
+		}
+	}
+	return i
+}
+
+// decodeJSONCResource is unmarshal for JSONC schema resources.
+func decodeJSONCResource(r io.Reader) (interface{}, error) {
+	doc, err := DecodeJSONC(r)
+	if e, ok := err.(*DecodeError); ok {
+		return nil, fmt.Errorf("line %d column %d: %v", e.Line, e.Col, e.Err)
+	}
+	return doc, err
+}
@@ -0,0 +1,100 @@
+package jsonschema_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+const jsoncSchema = `{
+	// the "id" is required
+	"$id": "http://example.com/schema.json", /* see https://example.com/docs */
+	"type": "object",
+	"properties": {
+		"url": {"type": "string", "default": "http://example.com/a//b"},
+		"csv": {"const": "a, b, ]"}, // commas inside strings are kept
+		"quote": {"const": "say \"/* hi */\""},
+	},
+	"required": [
+		"url",
+		/* multi
+		   line "comment" */
+	],
+}`
+
+func TestDecodeJSONC(t *testing.T) {
+	doc, err := jsonschema.DecodeJSONC(strings.NewReader(jsoncSchema))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := doc.(map[string]interface{})
+	props := m["properties"].(map[string]interface{})
+	if got := props["url"].(map[string]interface{})["default"]; got != "http://example.com/a//b" {
+		t.Errorf("url: got %v", got)
+	}
+	if got := props["csv"].(map[string]interface{})["const"]; got != "a, b, ]" {
+		t.Errorf("csv: got %v", got)
+	}
+	if got := props["quote"].(map[string]interface{})["const"]; got != `say "/* hi */"` {
+		t.Errorf("quote: got %v", got)
+	}
+	if got := m["required"]; !reflect.DeepEqual(got, []interface{}{"url"}) {
+		t.Errorf("required: got %v", got)
+	}
+}
+
+func TestDecodeJSONC_position(t *testing.T) {
+	tests := []struct {
+		doc       string
+		line, col int
+	}{
+		{"{\n  /* a\n  b */ \"a\": 1,\n  \"b\" 2\n}", 4, 7},
+		{"// comment\n[1, 2,, 3]", 2, 7},
+		{"[1, /* unterminated", 1, 5},
+	}
+	for _, test := range tests {
+		_, err := jsonschema.DecodeJSONC(strings.NewReader(test.doc))
+		de, ok := err.(*jsonschema.DecodeError)
+		if !ok {
+			t.Errorf("%q: got %v, want *DecodeError", test.doc, err)
+			continue
+		}
+		if de.Line != test.line || de.Col != test.col {
+			t.Errorf("%q: got line %d column %d, want line %d column %d", test.doc, de.Line, de.Col, test.line, test.col)
+		}
+	}
+}
+
+func TestCompiler_AllowJSONC(t *testing.T) {
+	c := jsonschema.NewCompiler()
+	if err := c.AddResource("schema.json", strings.NewReader(jsoncSchema)); err == nil {
+		t.Fatal("want error without AllowJSONC")
+	}
+
+	c.AllowJSONC = true
+	if err := c.AddResource("schema.json", strings.NewReader(jsoncSchema)); err != nil {
+		t.Fatal(err)
+	}
+	sch, err := c.Compile("schema.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sch.Validate(map[string]interface{}{"url": "x"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := sch.Validate(map[string]interface{}{}); err == nil {
+		t.Fatal("want error")
+	}
+
+	// instance documents stay strict
+	if err := sch.ValidateBytes([]byte(`{"url": "x",}`)); err == nil {
+		t.Fatal("want error for trailing comma in instance")
+	}
+
+	err = c.AddResource("bad.json", strings.NewReader("{\n  // comment\n  \"a\": 1 2\n}"))
+	if err == nil || !strings.Contains(err.Error(), "line 3 column 10") {
+		t.Fatalf("got %v, want error at line 3", err)
+	}
+}
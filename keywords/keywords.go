@@ -0,0 +1,251 @@
+// Package keywords provides the checks of individual json-schema keywords,
+// as implemented by package jsonschema, for use without compiling a schema.
+// The validator uses these functions, so their semantics and errors are
+// same as those of validation.
+//
+// The values checked are json values, as decoded by encoding/json into
+// interface{}, preferably with json.Decoder.UseNumber. Numbers can also
+// be int, int32 and int64.
+package keywords
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"unicode/utf8"
+)
+
+// Error is the error returned by the checks, when the value does not
+// satisfy the keyword. It carries the parts of jsonschema.ValidationError
+// computed by the keyword.
+type Error struct {
+	Keyword string                 // keyword that failed, such as "minLength"
+	Format  string                 // format of message, as in fmt.Sprintf
+	Args    []interface{}          // arguments of Format
+	Details map[string]interface{} // same as jsonschema.ValidationError.Details. can be nil
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf(e.Format, e.Args...)
+}
+
+// TypeError is the error returned, when a value is not json value,
+// such as float64 NaN or a go struct.
+type TypeError struct {
+	Value interface{}
+}
+
+func (e *TypeError) Error() string {
+	switch v := e.Value.(type) {
+	case float64:
+		return fmt.Sprint(v)
+	case json.Number:
+		return string(v)
+	}
+	return fmt.Sprintf("%T", e.Value)
+}
+
+// TypeOf returns the json type of v, which is one of "null", "boolean",
+// "number", "string", "array" and "object". It returns "" if v is not
+// json value.
+func TypeOf(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return ""
+		}
+		return "number"
+	case json.Number, int, int32, int64:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return ""
+}
+
+// Rat converts json number v to *big.Rat.
+//
+// All representations of negative zero convert to zero. float64 is
+// converted using its shortest decimal representation, so that 0.1
+// converts to 1/10 rather than its binary approximation. It returns
+// *TypeError if v is not a number.
+func Rat(v interface{}) (*big.Rat, error) {
+	var s string
+	switch v := v.(type) {
+	case json.Number:
+		s = string(v)
+	case float64:
+		s = strconv.FormatFloat(v, 'g', -1, 64)
+	case int:
+		return new(big.Rat).SetInt64(int64(v)), nil
+	case int32:
+		return new(big.Rat).SetInt64(int64(v)), nil
+	case int64:
+		return new(big.Rat).SetInt64(v), nil
+	default:
+		return nil, &TypeError{v}
+	}
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return nil, &TypeError{v}
+	}
+	return r, nil
+}
+
+// MultipleOf checks json number v against keyword multipleOf with value d.
+// It returns *TypeError if v is not a number.
+func MultipleOf(v interface{}, d *big.Rat) error {
+	r, err := Rat(v)
+	if err != nil {
+		return err
+	}
+	if q := new(big.Rat).Quo(r, d); !q.IsInt() {
+		f, _ := d.Float64()
+		return &Error{Keyword: "multipleOf", Format: "%v not multipleOf %v", Args: []interface{}{v, f}}
+	}
+	return nil
+}
+
+// Length checks string v against keywords minLength and maxLength.
+// The length is number of unicode code points. -1 for min or max
+// means that keyword is not specified.
+func Length(v string, min, max int) error {
+	if min == -1 && max == -1 {
+		return nil
+	}
+	length := utf8.RuneCountInString(v)
+	if min != -1 && length < min {
+		return &Error{
+			Keyword: "minLength",
+			Format:  "length must be >= %d, but got %d",
+			Args:    []interface{}{min, length},
+			Details: map[string]interface{}{"limit": min, "length": length},
+		}
+	}
+	if max != -1 && length > max {
+		return &Error{
+			Keyword: "maxLength",
+			Format:  "length must be <= %d, but got %d",
+			Args:    []interface{}{max, length},
+			Details: map[string]interface{}{"limit": max, "length": length},
+		}
+	}
+	return nil
+}
+
+// Equal tells whether json values a and b are equal, as per keywords
+// const, enum and uniqueItems. Numbers are equal if their values are
+// equal, for example 1 and 1.0. It returns false if any of them is not
+// json value.
+func Equal(a, b interface{}) bool {
+	eq, err := Comparison{}.Equal(a, b)
+	return err == nil && eq
+}
+
+// ErrBudget is returned by Comparison.Equal, when its budget is exhausted.
+var ErrBudget = errors.New("keywords: comparison budget exhausted")
+
+// Comparison compares json values, like Equal, with the options
+// used by the validator.
+type Comparison struct {
+	// Convert, if not nil, is applied to each value compared, including
+	// the nested values, before comparing. It is used to support types
+	// other than json values. Its errors are returned as is.
+	Convert func(v interface{}) (interface{}, error)
+
+	// Budget, if not nil, is decremented for each pair of values
+	// compared, and ErrBudget is returned once it goes below zero.
+	Budget *int
+}
+
+// Equal tells whether json values a and b are equal. It returns
+// *TypeError if any of the values compared is not json value.
+//
+// It uses explicit stack rather than recursion, so that deeply nested
+// values do not exhaust goroutine stack.
+func (c Comparison) Equal(a, b interface{}) (bool, error) {
+	type pair struct {
+		v1, v2 interface{}
+	}
+	var buf [16]pair
+	stack := append(buf[:0], pair{a, b})
+	for len(stack) > 0 {
+		p := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if c.Convert != nil {
+			var err error
+			if p.v1, err = c.Convert(p.v1); err != nil {
+				return false, err
+			}
+			if p.v2, err = c.Convert(p.v2); err != nil {
+				return false, err
+			}
+		}
+		if c.Budget != nil {
+			if *c.Budget--; *c.Budget < 0 {
+				return false, ErrBudget
+			}
+		}
+		v1Type, v2Type := TypeOf(p.v1), TypeOf(p.v2)
+		if v1Type == "" {
+			return false, &TypeError{p.v1}
+		}
+		if v2Type == "" {
+			return false, &TypeError{p.v2}
+		}
+		if v1Type != v2Type {
+			return false, nil
+		}
+		switch v1Type {
+		case "array":
+			arr1, arr2 := p.v1.([]interface{}), p.v2.([]interface{})
+			if len(arr1) != len(arr2) {
+				return false, nil
+			}
+			// pushed in reverse, so that items are compared in order
+			for i := len(arr1) - 1; i >= 0; i-- {
+				stack = append(stack, pair{arr1[i], arr2[i]})
+			}
+		case "object":
+			obj1, obj2 := p.v1.(map[string]interface{}), p.v2.(map[string]interface{})
+			if len(obj1) != len(obj2) {
+				return false, nil
+			}
+			for k, v1 := range obj1 {
+				v2, ok := obj2[k]
+				if !ok {
+					return false, nil
+				}
+				stack = append(stack, pair{v1, v2})
+			}
+		case "number":
+			r1, err := Rat(p.v1)
+			if err != nil {
+				return false, err
+			}
+			r2, err := Rat(p.v2)
+			if err != nil {
+				return false, err
+			}
+			if r1.Cmp(r2) != 0 {
+				return false, nil
+			}
+		default:
+			if p.v1 != p.v2 {
+				return false, nil
+			}
+		}
+	}
+	return true, nil
+}
@@ -0,0 +1,182 @@
+package keywords_test
+
+import (
+	"encoding/json"
+	"math"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+	"github.com/santhosh-tekuri/jsonschema/v5/keywords"
+)
+
+func TestTypeOf(t *testing.T) {
+	tests := []struct {
+		v    interface{}
+		want string
+	}{
+		{nil, "null"},
+		{true, "boolean"},
+		{json.Number("1.5"), "number"},
+		{1.5, "number"},
+		{int64(1), "number"},
+		{"a", "string"},
+		{[]interface{}{}, "array"},
+		{map[string]interface{}{}, "object"},
+		{math.NaN(), ""},
+		{math.Inf(1), ""},
+		{struct{}{}, ""},
+		{uint(1), ""},
+	}
+	for _, test := range tests {
+		if got := keywords.TypeOf(test.v); got != test.want {
+			t.Errorf("TypeOf(%#v): got %q, want %q", test.v, got, test.want)
+		}
+	}
+}
+
+func TestRat(t *testing.T) {
+	tests := []struct {
+		v    interface{}
+		want string
+	}{
+		{json.Number("0.1"), "1/10"},
+		{0.1, "1/10"},
+		{json.Number("-0.0"), "0"},
+		{int32(-3), "-3"},
+		{json.Number("1e2"), "100"},
+	}
+	for _, test := range tests {
+		r, err := keywords.Rat(test.v)
+		if err != nil {
+			t.Errorf("Rat(%#v): %v", test.v, err)
+			continue
+		}
+		if got := r.RatString(); got != test.want {
+			t.Errorf("Rat(%#v): got %s, want %s", test.v, got, test.want)
+		}
+	}
+	for _, v := range []interface{}{"1", json.Number("abc"), nil} {
+		if _, err := keywords.Rat(v); err == nil {
+			t.Errorf("Rat(%#v): want error", v)
+		} else if _, ok := err.(*keywords.TypeError); !ok {
+			t.Errorf("Rat(%#v): got %#v, want *TypeError", v, err)
+		}
+	}
+}
+
+func TestMultipleOf(t *testing.T) {
+	d := big.NewRat(1, 100) // 0.01
+	for _, v := range []interface{}{json.Number("19.99"), 19.99, 20, json.Number("1e-2")} {
+		if err := keywords.MultipleOf(v, d); err != nil {
+			t.Errorf("MultipleOf(%v): %v", v, err)
+		}
+	}
+	err := keywords.MultipleOf(json.Number("19.999"), d)
+	e, ok := err.(*keywords.Error)
+	if !ok {
+		t.Fatalf("got %#v, want *Error", err)
+	}
+	if e.Keyword != "multipleOf" || e.Error() != "19.999 not multipleOf 0.01" {
+		t.Fatalf("got %s: %s", e.Keyword, e)
+	}
+	if _, ok := keywords.MultipleOf("1", d).(*keywords.TypeError); !ok {
+		t.Fatal("want *TypeError for string")
+	}
+}
+
+func TestLength(t *testing.T) {
+	if err := keywords.Length("héllo", 5, 5); err != nil {
+		t.Fatal(err)
+	}
+	if err := keywords.Length("", -1, -1); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		v        string
+		min, max int
+		keyword  string
+		message  string
+		details  map[string]interface{}
+	}{
+		{"ab", 3, -1, "minLength", "length must be >= 3, but got 2", map[string]interface{}{"limit": 3, "length": 2}},
+		{"日本語", -1, 2, "maxLength", "length must be <= 2, but got 3", map[string]interface{}{"limit": 2, "length": 3}},
+	}
+	for _, test := range tests {
+		e, ok := keywords.Length(test.v, test.min, test.max).(*keywords.Error)
+		if !ok {
+			t.Fatalf("%q: want *Error", test.v)
+		}
+		if e.Keyword != test.keyword || e.Error() != test.message || !reflect.DeepEqual(e.Details, test.details) {
+			t.Errorf("%q: got %s %q %v", test.v, e.Keyword, e, e.Details)
+		}
+	}
+}
+
+func TestEqual(t *testing.T) {
+	tests := []struct {
+		a, b interface{}
+		want bool
+	}{
+		{json.Number("1"), 1.0, true},
+		{json.Number("-0"), json.Number("0.0"), true},
+		{"1", json.Number("1"), false},
+		{nil, false, false},
+		{[]interface{}{1, "a"}, []interface{}{json.Number("1"), "a"}, true},
+		{[]interface{}{1, "a"}, []interface{}{"a", 1}, false},
+		{map[string]interface{}{"a": 1, "b": []interface{}{}}, map[string]interface{}{"b": []interface{}{}, "a": 1}, true},
+		{map[string]interface{}{"a": 1}, map[string]interface{}{"a": 1, "b": 2}, false},
+		{math.NaN(), math.NaN(), false},
+		{struct{}{}, struct{}{}, false},
+	}
+	for _, test := range tests {
+		if got := keywords.Equal(test.a, test.b); got != test.want {
+			t.Errorf("Equal(%#v, %#v): got %v, want %v", test.a, test.b, got, test.want)
+		}
+	}
+}
+
+func TestComparison(t *testing.T) {
+	a := []interface{}{1, 2, 3}
+	budget := 3
+	if _, err := (keywords.Comparison{Budget: &budget}).Equal(a, a); err != keywords.ErrBudget {
+		t.Fatalf("got %v, want ErrBudget", err)
+	}
+	budget = 4
+	if eq, err := (keywords.Comparison{Budget: &budget}).Equal(a, a); err != nil || !eq {
+		t.Fatalf("got %v %v, want true", eq, err)
+	}
+	if _, err := (keywords.Comparison{}).Equal([]interface{}{struct{}{}}, []interface{}{1}); err == nil {
+		t.Fatal("want *TypeError")
+	}
+
+	// Convert
+	double := keywords.Comparison{Convert: func(v interface{}) (interface{}, error) {
+		if v, ok := v.(int); ok {
+			return v * 2, nil
+		}
+		return v, nil
+	}}
+	if eq, err := double.Equal(1, json.Number("2")); err != nil || !eq {
+		t.Fatalf("got %v %v, want true", eq, err)
+	}
+}
+
+// TestSameAsValidator checks that the errors are same as those of validation.
+func TestSameAsValidator(t *testing.T) {
+	sch := jsonschema.MustCompileString("schema.json", `{"minLength": 3, "multipleOf": 0.5}`)
+	for _, test := range []struct {
+		v   interface{}
+		err error
+	}{
+		{"ab", keywords.Length("ab", 3, -1)},
+		{json.Number("1.2"), keywords.MultipleOf(json.Number("1.2"), big.NewRat(1, 2))},
+	} {
+		ve := sch.Validate(test.v).(*jsonschema.ValidationError).Causes[0]
+		e := test.err.(*keywords.Error)
+		if ve.Keyword != e.Keyword || ve.Message != e.Error() || !reflect.DeepEqual(ve.Details, e.Details) {
+			t.Errorf("%v: validator %s %q %v, keywords %s %q %v", test.v, ve.Keyword, ve.Message, ve.Details, e.Keyword, e, e.Details)
+		}
+	}
+}
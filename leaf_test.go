@@ -0,0 +1,122 @@
+package jsonschema_test
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// decimal is json.Marshaler, which is marshaled as json number.
+type decimal string
+
+func (d decimal) MarshalJSON() ([]byte, error) {
+	return []byte(d), nil
+}
+
+// uuid is JSONLeaf, which is validated as string.
+type uuid [16]byte
+
+func (u uuid) JSONValue() (interface{}, error) {
+	s := hex.EncodeToString(u[:])
+	return s[:8] + "-" + s[8:12] + "-" + s[12:16] + "-" + s[16:20] + "-" + s[20:], nil
+}
+
+// point is json.Marshaler, which is marshaled as json object.
+type point struct{ X, Y int }
+
+func (p point) MarshalJSON() ([]byte, error) {
+	return []byte(fmt.Sprintf(`{"x": %d, "y": %d}`, p.X, p.Y)), nil
+}
+
+// badLeaf is JSONLeaf, which fails.
+type badLeaf struct{}
+
+func (badLeaf) JSONValue() (interface{}, error) {
+	return nil, errors.New("no value")
+}
+
+func firstLeaf(ve *jsonschema.ValidationError) *jsonschema.ValidationError {
+	for len(ve.Causes) > 0 {
+		ve = ve.Causes[0]
+	}
+	return ve
+}
+
+func TestJSONLeaf(t *testing.T) {
+	sch := jsonschema.MustCompileString("schema.json", `{
+		"properties": {
+			"price": {"type": "number", "maximum": 0.1},
+			"id": {"type": "string", "pattern": "^[0-9a-f]{8}-0000-"},
+			"created": {"type": "string", "format": "date-time"},
+			"tags": {"items": {"type": "string"}}
+		}
+	}`)
+
+	valid := map[string]interface{}{
+		"price":   decimal("0.1000000000000000000000"),
+		"id":      uuid{1, 2, 3, 4},
+		"created": time.Date(2024, 2, 29, 10, 0, 0, 0, time.FixedZone("", 5*3600)),
+		"tags":    []interface{}{decimal(`"a"`)},
+	}
+	if err := sch.Validate(valid); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("decimalPrecision", func(t *testing.T) {
+		// 0.1000000000000000000001 is 0.1 in float64
+		err := sch.Validate(map[string]interface{}{"price": decimal("0.1000000000000000000001")})
+		ve, ok := err.(*jsonschema.ValidationError)
+		if !ok {
+			t.Fatalf("got %v, want *ValidationError", err)
+		}
+		if leaf := firstLeaf(ve); leaf.Keyword != "maximum" || leaf.InstanceLocation != "/price" {
+			t.Fatalf("got %s at %s", leaf.Keyword, leaf.InstanceLocation)
+		}
+	})
+
+	t.Run("uuidPattern", func(t *testing.T) {
+		err := sch.Validate(map[string]interface{}{"id": uuid{1, 2, 3, 4, 5}})
+		ve, ok := err.(*jsonschema.ValidationError)
+		if !ok {
+			t.Fatalf("got %v, want *ValidationError", err)
+		}
+		leaf := firstLeaf(ve)
+		if leaf.Keyword != "pattern" || leaf.InstanceLocation != "/id" {
+			t.Fatalf("got %s at %s", leaf.Keyword, leaf.InstanceLocation)
+		}
+	})
+
+	t.Run("notLeaf", func(t *testing.T) {
+		err := sch.Validate(map[string]interface{}{"tags": []interface{}{point{1, 2}}})
+		if _, ok := err.(jsonschema.InvalidJSONTypeError); !ok {
+			t.Fatalf("got %#v, want InvalidJSONTypeError", err)
+		}
+		if !strings.Contains(err.Error(), "/tags/0") {
+			t.Fatalf("error %q does not mention location", err)
+		}
+	})
+
+	t.Run("error", func(t *testing.T) {
+		err := sch.Validate(map[string]interface{}{"id": badLeaf{}})
+		if _, ok := err.(jsonschema.InvalidJSONTypeError); !ok {
+			t.Fatalf("got %#v, want InvalidJSONTypeError", err)
+		}
+		if !strings.Contains(err.Error(), "no value") {
+			t.Fatalf("error %q does not have cause", err)
+		}
+	})
+
+	t.Run("enum", func(t *testing.T) {
+		sch := jsonschema.MustCompileString("enum.json", `{"enum": [1.50, "2024-01-01T00:00:00Z"]}`)
+		for _, v := range []interface{}{decimal("1.5"), time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)} {
+			if err := sch.Validate(v); err != nil {
+				t.Errorf("%v: %v", v, err)
+			}
+		}
+	})
+}
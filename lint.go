@@ -0,0 +1,62 @@
+package jsonschema
+
+import (
+	"fmt"
+	"regexp/syntax"
+)
+
+// warnPattern reports the pattern of s, which is probably meant to
+// match whole string, but is not anchored. pattern keyword matches
+// a substring, so "[a-z]+" is satisfied by "ABCdef!".
+//
+// As heuristic, only the patterns with a single character class,
+// optionally quantified, are reported, such as "[a-z]+" or "\d{3}".
+func (c *Compiler) warnPattern(s *Schema, pattern string) error {
+	re, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return nil
+	}
+	begin, end := false, false
+	subs := []*syntax.Regexp{re}
+	if re.Op == syntax.OpConcat {
+		subs = re.Sub
+	}
+	if len(subs) > 0 && subs[0].Op == syntax.OpBeginText {
+		begin, subs = true, subs[1:]
+	}
+	if len(subs) > 0 && subs[len(subs)-1].Op == syntax.OpEndText {
+		end, subs = true, subs[:len(subs)-1]
+	}
+	if (begin && end) || len(subs) != 1 || !isCharClass(unquantified(subs[0])) {
+		return nil
+	}
+
+	suggest := pattern
+	if !begin {
+		suggest = "^" + suggest
+	}
+	if !end {
+		suggest += "$"
+	}
+	return c.warn(CompileWarning{
+		Location: s.Location,
+		Keywords: [2]string{joinPtr(s.Location, "pattern")},
+		Message:  fmt.Sprintf("pattern %s is not anchored, so it matches any string containing a match; use %s to match whole string", quote(pattern), quote(suggest)),
+	})
+}
+
+// unquantified returns re without its quantifier if any.
+func unquantified(re *syntax.Regexp) *syntax.Regexp {
+	switch re.Op {
+	case syntax.OpStar, syntax.OpPlus, syntax.OpQuest, syntax.OpRepeat:
+		return re.Sub[0]
+	}
+	return re
+}
+
+// isCharClass tells whether re matches a single character of a class,
+// such as [a-z] or \d. The dot is not considered, because ".*" is
+// meant to match anything.
+func isCharClass(re *syntax.Regexp) bool {
+	return re.Op == syntax.OpCharClass
+}
@@ -0,0 +1,123 @@
+package jsonschema_test
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+func patternWarnings(t *testing.T, schema string) []jsonschema.CompileWarning {
+	t.Helper()
+	c := jsonschema.NewCompiler()
+	var warnings []jsonschema.CompileWarning
+	c.OnWarning = func(w jsonschema.CompileWarning) {
+		if strings.HasSuffix(w.Keywords[0], "/pattern") {
+			warnings = append(warnings, w)
+		}
+	}
+	if err := c.AddResource("schema.json", strings.NewReader(schema)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Compile("schema.json"); err != nil {
+		t.Fatal(err)
+	}
+	return warnings
+}
+
+func TestCompiler_OnWarning_pattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		suggest string // empty if no warning
+	}{
+		{`[a-z]+`, `^[a-z]+$`},
+		{`\\d{3}`, `^\\d{3}$`},
+		{`[A-Z]*`, `^[A-Z]*$`},
+		{`\\w`, `^\\w$`},
+		{`^[a-z]+`, `^[a-z]+$`},
+		{`[a-z]+$`, `^[a-z]+$`},
+		{`^[a-z]+$`, ""},
+		{`\\A[a-z]+\\z`, ""},
+		{`abc`, ""},
+		{`.*`, ""},
+		{`[a-z]+@example\\.com`, ""},
+		{`foo|bar`, ""},
+		{`(ab)+`, ""},
+	}
+	for _, test := range tests {
+		warnings := patternWarnings(t, `{"pattern": "`+test.pattern+`"}`)
+		if test.suggest == "" {
+			if len(warnings) != 0 {
+				t.Errorf("%s: unexpected warning %s", test.pattern, warnings[0])
+			}
+			continue
+		}
+		if len(warnings) != 1 {
+			t.Errorf("%s: got %d warnings, want 1", test.pattern, len(warnings))
+			continue
+		}
+		w := warnings[0]
+		if !strings.Contains(w.Message, "'"+test.suggest+"'") {
+			t.Errorf("%s: message %q does not suggest %s", test.pattern, w.Message, test.suggest)
+		}
+		if w.Keywords[0] != w.Location+"/pattern" || w.Keywords[1] != "" {
+			t.Errorf("%s: keywords %q", test.pattern, w.Keywords)
+		}
+	}
+
+	// partial match is idiomatic in patternProperties
+	if warnings := patternWarnings(t, `{"patternProperties": {"[a-z]+": {}}}`); len(warnings) != 0 {
+		t.Fatalf("unexpected warning %s", warnings[0])
+	}
+}
+
+func TestCompiler_FullMatchPatterns(t *testing.T) {
+	compile := func(fullMatch bool, schema string) *jsonschema.Schema {
+		t.Helper()
+		c := jsonschema.NewCompiler()
+		c.FullMatchPatterns = fullMatch
+		c.OnWarning = func(w jsonschema.CompileWarning) {
+			if fullMatch {
+				t.Errorf("unexpected warning %s", w)
+			}
+		}
+		if err := c.AddResource("schema.json", strings.NewReader(schema)); err != nil {
+			t.Fatal(err)
+		}
+		sch, err := c.Compile("schema.json")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return sch
+	}
+
+	tests := []struct {
+		pattern   string
+		value     string
+		substring bool // valid by default
+		full      bool // valid with FullMatchPatterns
+	}{
+		{`[a-z]+`, "abc", true, true},
+		{`[a-z]+`, "ABCdef!", true, false},
+		{`a|b`, "a", true, true},
+		{`a|b`, "ab", true, false},
+		{`^[a-z]+$`, "abc", true, true},
+		{`^a|b$`, "ab", true, false},
+	}
+	for _, test := range tests {
+		schema := `{"pattern": ` + strconv.Quote(test.pattern) + `}`
+		if got := compile(false, schema).Validate(test.value) == nil; got != test.substring {
+			t.Errorf("%s %q: got %v, want %v", test.pattern, test.value, got, test.substring)
+		}
+		if got := compile(true, schema).Validate(test.value) == nil; got != test.full {
+			t.Errorf("FullMatchPatterns %s %q: got %v, want %v", test.pattern, test.value, got, test.full)
+		}
+	}
+
+	// patternProperties is not affected
+	sch := compile(true, `{"patternProperties": {"[a-z]+": {"type": "string"}}}`)
+	if err := sch.Validate(map[string]interface{}{"ABCdef!": 1}); err == nil {
+		t.Fatal("want error")
+	}
+}
@@ -0,0 +1,259 @@
+package jsonschema
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+)
+
+// Macro expands a custom keyword at compile time, by rewriting the raw
+// schema document containing it. Unlike ExtCompiler, the keyword is not
+// validated at runtime; the schema compiled is the expanded one.
+type Macro interface {
+	// Expand returns the schema replacing m, which contains the keyword
+	// of this macro. The replacement must not contain the keyword again,
+	// but it can contain other macros, which are expanded in turn.
+	//
+	// m must not be modified, because it may be shared with other schemas.
+	Expand(ctx MacroContext, m map[string]interface{}) (map[string]interface{}, error)
+}
+
+// maxMacroDepth limits the nesting of macro expansions, to detect
+// macros expanding infinitely.
+const maxMacroDepth = 32
+
+// RegisterMacro registers macro m for keyword in this compiler.
+//
+// Macros are expanded when a resource is loaded, before the resource is
+// validated against metaschema and compiled. Thus $id, anchors and all
+// keywords in expanded schema work as if they were written in the document,
+// and Schema.MarshalJSON returns the expanded schema.
+func (c *Compiler) RegisterMacro(keyword string, m Macro) {
+	if c.macros == nil {
+		c.macros = make(map[string]Macro)
+	}
+	c.macros[keyword] = m
+}
+
+// MacroContext provides the context required by Macro for expansion.
+type MacroContext struct {
+	c     *Compiler
+	r     *resource
+	base  string // base url of the schema being expanded
+	depth int
+}
+
+// Resolve returns the raw schema document referred by ref, with its
+// macros expanded. ref is resolved against the base url of the schema
+// being expanded; its fragment if any, must be a json-pointer. The
+// references in the document returned are relative to the url of its
+// resource.
+//
+// The document returned must not be modified. Use a copy instead.
+func (ctx MacroContext) Resolve(ref string) (interface{}, error) {
+	ref, err := resolveURL(ctx.base, ref)
+	if err != nil {
+		return nil, err
+	}
+	u, f := split(ref)
+	r := ctx.r
+	if normalizeURL(u) != normalizeURL(r.url) {
+		if r, err = ctx.c.findResource(u); err != nil {
+			return nil, err
+		}
+	}
+	ptr, err := url.PathUnescape(f[1:])
+	if err != nil {
+		return nil, &InvalidFragmentError{URL: u, Fragment: f, Err: err}
+	}
+	doc, ok := lookup(r.doc, ptr)
+	if !ok {
+		return nil, &FragmentNotFoundError{URL: u, Fragment: f, Prefix: "#"}
+	}
+	return ctx.c.expand(r, r.url, f, doc, ctx.depth)
+}
+
+// expandMacros expands macros in r.doc.
+func (c *Compiler) expandMacros(r *resource) error {
+	if len(c.macros) == 0 {
+		return nil
+	}
+	doc, err := c.expand(r, r.url, "#", r.doc, 0)
+	if err != nil {
+		return err
+	}
+	r.doc = doc
+	return nil
+}
+
+// expand returns schema sch at floc in r, with macros in it and
+// in its subschemas expanded. The subschemas are replaced in place.
+func (c *Compiler) expand(r *resource, base, floc string, sch interface{}, depth int) (interface{}, error) {
+	m, ok := sch.(map[string]interface{})
+	if !ok {
+		return sch, nil
+	}
+
+	// expand macros of m itself
+	keywords := make([]string, 0, len(c.macros))
+	for keyword := range c.macros {
+		keywords = append(keywords, keyword)
+	}
+	sort.Strings(keywords)
+	for expanded := true; expanded; {
+		expanded = false
+		for _, keyword := range keywords {
+			if _, ok := m[keyword]; !ok {
+				continue
+			}
+			if depth >= maxMacroDepth {
+				return nil, fmt.Errorf("jsonschema: macro %s at %s exceeds expansion depth %d", keyword, r.url+floc, maxMacroDepth)
+			}
+			depth++
+			var err error
+			m, err = c.macros[keyword].Expand(MacroContext{c, r, base, depth}, m)
+			if err != nil {
+				return nil, fmt.Errorf("jsonschema: macro %s at %s failed: %v", keyword, r.url+floc, err)
+			}
+			expanded = true
+			break
+		}
+	}
+
+	// expand macros of subschemas
+	if id, err := r.draft.resolveID(base, m); err != nil {
+		return nil, err
+	} else if id != "" {
+		base = id
+	}
+	add := func(loc string, sch interface{}) (interface{}, error) {
+		return c.expand(r, base, floc+"/"+loc, sch, depth)
+	}
+	for kw, pos := range r.draft.subschemas {
+		v, ok := m[kw]
+		if !ok {
+			continue
+		}
+		var err error
+		if pos&self != 0 {
+			if _, ok := v.(map[string]interface{}); ok {
+				if m[kw], err = add(kw, v); err != nil {
+					return nil, err
+				}
+			}
+		}
+		if pos&item != 0 {
+			if v, ok := v.([]interface{}); ok {
+				for i, item := range v {
+					if v[i], err = add(kw+"/"+strconv.Itoa(i), item); err != nil {
+						return nil, err
+					}
+				}
+			}
+		}
+		if pos&prop != 0 {
+			if v, ok := v.(map[string]interface{}); ok {
+				for pname, pval := range v {
+					if v[pname], err = add(kw+"/"+escape(pname), pval); err != nil {
+						return nil, err
+					}
+				}
+			}
+		}
+	}
+	return m, nil
+}
+
+// Merge is Macro for keyword "$merge", which applies json merge patch
+// (RFC 7386) to a schema. It is not registered by default:
+//
+//	c.RegisterMacro("$merge", jsonschema.Merge)
+//
+// The value of $merge is an object with "source" and "with". source is the
+// schema to be patched, which can be {"$ref": "..."} to patch the referred
+// schema. with is the merge patch. For example:
+//
+//	{"$merge": {"source": {"$ref": "person.json"}, "with": {"required": ["email"]}}}
+//
+// Other keywords alongside $merge are applied as another merge patch.
+var Merge Macro = mergeMacro{}
+
+type mergeMacro struct{}
+
+func (mergeMacro) Expand(ctx MacroContext, m map[string]interface{}) (map[string]interface{}, error) {
+	merge, ok := m["$merge"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("$merge must be object")
+	}
+	source, ok := merge["source"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("$merge/source must be object")
+	}
+	if ref, ok := source["$ref"]; ok && len(source) == 1 {
+		ref, ok := ref.(string)
+		if !ok {
+			return nil, fmt.Errorf("$merge/source/$ref must be string")
+		}
+		doc, err := ctx.Resolve(ref)
+		if err != nil {
+			return nil, err
+		}
+		if source, ok = doc.(map[string]interface{}); !ok {
+			return nil, fmt.Errorf("$merge/source/$ref %s must refer to object", ref)
+		}
+	}
+	with, ok := merge["with"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("$merge/with must be object")
+	}
+	siblings := make(map[string]interface{})
+	for k, v := range m {
+		if k != "$merge" {
+			siblings[k] = v
+		}
+	}
+	return mergePatch(mergePatch(source, with), siblings).(map[string]interface{}), nil
+}
+
+// mergePatch returns the result of applying merge patch to target, as per
+// RFC 7386. target is not modified.
+func mergePatch(target, patch interface{}) interface{} {
+	p, ok := patch.(map[string]interface{})
+	if !ok {
+		return deepCopy(patch)
+	}
+	result := make(map[string]interface{})
+	if t, ok := target.(map[string]interface{}); ok {
+		for k, v := range t {
+			result[k] = deepCopy(v)
+		}
+	}
+	for k, v := range p {
+		if v == nil {
+			delete(result, k)
+		} else {
+			result[k] = mergePatch(result[k], v)
+		}
+	}
+	return result
+}
+
+// deepCopy returns copy of json value v, that shares nothing with v.
+func deepCopy(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			m[k] = deepCopy(item)
+		}
+		return m
+	case []interface{}:
+		arr := make([]interface{}, len(v))
+		for i, item := range v {
+			arr[i] = deepCopy(item)
+		}
+		return arr
+	}
+	return v
+}
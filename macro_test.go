@@ -0,0 +1,139 @@
+package jsonschema_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+func compileWithMerge(t *testing.T, resources map[string]string, url string) (*jsonschema.Schema, error) {
+	t.Helper()
+	c := jsonschema.NewCompiler()
+	c.RegisterMacro("$merge", jsonschema.Merge)
+	for u, doc := range resources {
+		if err := c.AddResource(u, strings.NewReader(doc)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return c.Compile(url)
+}
+
+func TestMerge(t *testing.T) {
+	sch, err := compileWithMerge(t, map[string]string{
+		"schema.json": `{
+			"$defs": {
+				"person": {
+					"type": "object",
+					"properties": {"name": {"type": "string"}, "age": {"type": "integer"}},
+					"required": ["name"]
+				}
+			},
+			"$merge": {
+				"source": {"$ref": "#/$defs/person"},
+				"with": {"properties": {"age": null, "email": {"type": "string"}}, "required": ["email"]}
+			},
+			"additionalProperties": false
+		}`,
+	}, "schema.json")
+	if err != nil {
+		t.Fatalf("%#v", err)
+	}
+	tests := []struct {
+		doc   string
+		valid bool
+	}{
+		{`{"email": "a@b.com", "name": "x"}`, true},
+		{`{"name": "x"}`, false},                             // email required
+		{`{"email": "a@b.com", "age": 1}`, false},            // age removed
+		{`{"email": "a@b.com", "name": 1}`, false},           // name kept
+		{`{"email": "a@b.com", "name": "x", "z": 1}`, false}, // sibling keyword applied
+	}
+	for _, test := range tests {
+		if err := sch.Validate(decodeString(t, test.doc)); (err == nil) != test.valid {
+			t.Errorf("%s: valid: got %v, want %v", test.doc, err == nil, test.valid)
+		}
+	}
+
+	// MarshalJSON returns expanded schema
+	b, err := json.Marshal(sch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(b), "$merge") || !strings.Contains(string(b), `"email"`) {
+		t.Errorf("got %s, want expanded schema", b)
+	}
+}
+
+func TestMerge_nested(t *testing.T) {
+	sch, err := compileWithMerge(t, map[string]string{
+		"base.json": `{"type": "object", "properties": {"a": {"type": "string"}}}`,
+		"middle.json": `{
+			"$merge": {"source": {"$ref": "base.json"}, "with": {"properties": {"b": {"type": "integer"}}}}
+		}`,
+		"schema.json": `{
+			"properties": {
+				"item": {
+					"$merge": {
+						"source": {"$merge": {"source": {"$ref": "middle.json"}, "with": {"required": ["a"]}}},
+						"with": {"properties": {"c": {"const": 1}}}
+					}
+				}
+			}
+		}`,
+	}, "schema.json")
+	if err != nil {
+		t.Fatalf("%#v", err)
+	}
+	tests := []struct {
+		doc   string
+		valid bool
+	}{
+		{`{"item": {"a": "x", "b": 1, "c": 1}}`, true},
+		{`{"item": {"b": 1}}`, false},
+		{`{"item": {"a": 1}}`, false},
+		{`{"item": {"a": "x", "b": "y"}}`, false},
+		{`{"item": {"a": "x", "c": 2}}`, false},
+	}
+	for _, test := range tests {
+		if err := sch.Validate(decodeString(t, test.doc)); (err == nil) != test.valid {
+			t.Errorf("%s: valid: got %v, want %v", test.doc, err == nil, test.valid)
+		}
+	}
+}
+
+func TestMerge_errors(t *testing.T) {
+	tests := []struct {
+		schema string
+		err    string
+	}{
+		{`{"$merge": 1}`, "$merge must be object"},
+		{`{"$merge": {"source": {"$ref": "#/$defs/missing"}, "with": {}}}`, "not found"},
+		{`{"$merge": {"source": {"type": "string"}}}`, "$merge/with must be object"},
+		{`{"$defs": {"a": {"$merge": {"source": {"$ref": "#/$defs/a"}, "with": {}}}}, "$ref": "#/$defs/a"}`, "exceeds expansion depth"},
+	}
+	for _, test := range tests {
+		_, err := compileWithMerge(t, map[string]string{"schema.json": test.schema}, "schema.json")
+		if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("%s: got %v, want error containing %q", test.schema, err, test.err)
+		}
+	}
+}
+
+type loopMacro struct{}
+
+func (loopMacro) Expand(ctx jsonschema.MacroContext, m map[string]interface{}) (map[string]interface{}, error) {
+	return map[string]interface{}{"$loop": true}, nil
+}
+
+func TestCompiler_RegisterMacro_loop(t *testing.T) {
+	c := jsonschema.NewCompiler()
+	c.RegisterMacro("$loop", loopMacro{})
+	if err := c.AddResource("schema.json", strings.NewReader(`{"items": {"$loop": true}}`)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Compile("schema.json"); err == nil || !strings.Contains(err.Error(), "exceeds expansion depth") {
+		t.Fatalf("got %v, want depth error", err)
+	}
+}
@@ -0,0 +1,202 @@
+package jsonschema_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+const lazySchema = `{
+	"properties": {
+		"name": {"type": "string", "minLength": 3},
+		"age": {"type": "integer", "minimum": 18, "messages": {"minimum": "must be adult, got {{index . 1}}"}},
+		"tags": {"items": {"anyOf": [{"type": "string"}, {"type": "integer"}]}},
+		"secret": {"x-sensitive": true, "const": "abc"},
+		"kind": {"oneOf": [{"const": "a"}, {"const": "b"}], "not": {"const": "c"}}
+	},
+	"required": ["name"]
+}`
+
+func lazyCompile(t testing.TB) *jsonschema.Schema {
+	c := jsonschema.NewCompiler()
+	if err := c.AddResource("http://example.com/lazy.json", strings.NewReader(lazySchema)); err != nil {
+		t.Fatal(err)
+	}
+	sch, err := c.Compile("http://example.com/lazy.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sch
+}
+
+// leafMessages returns the number of leaf errors in ve with message.
+func leafMessages(ve *jsonschema.ValidationError) int {
+	if len(ve.Causes) == 0 {
+		if ve.Message != "" {
+			return 1
+		}
+		return 0
+	}
+	n := 0
+	for _, c := range ve.Causes {
+		n += leafMessages(c)
+	}
+	return n
+}
+
+func TestValidateOptions_LazyMessages(t *testing.T) {
+	sch := lazyCompile(t)
+	docs := []string{
+		`{"name": "ab", "age": 10, "tags": [1, "a", true], "secret": "xyz", "kind": "d"}`,
+		`{"age": "x"}`,
+		`{"name": "abc", "kind": "a", "tags": ["a"]}`,
+	}
+	for _, doc := range docs {
+		v := decodeString(t, doc)
+		eager := sch.ValidateWith(v, jsonschema.ValidateOptions{})
+		lazy := sch.ValidateWith(v, jsonschema.ValidateOptions{LazyMessages: true})
+		if (eager == nil) != (lazy == nil) {
+			t.Fatalf("%s: got %v and %v", doc, eager, lazy)
+		}
+		if eager == nil {
+			continue
+		}
+		eve, lve := eager.(*jsonschema.ValidationError), lazy.(*jsonschema.ValidationError)
+		if n := leafMessages(lve); n != 0 {
+			t.Errorf("%s: %d lazy messages formatted before use", doc, n)
+		}
+		if n := leafMessages(eve); n == 0 {
+			t.Errorf("%s: eager messages not formatted", doc)
+		}
+		if got, want := lve.Error(), eve.Error(); got != want {
+			t.Errorf("%s: Error\n got %s\nwant %s", doc, got, want)
+		}
+		if got, want := fmt.Sprintf("%#v", lve), fmt.Sprintf("%#v", eve); got != want {
+			t.Errorf("%s: GoString\n got %s\nwant %s", doc, got, want)
+		}
+		if got, want := fmt.Sprint(lve.BasicOutput()), fmt.Sprint(eve.BasicOutput()); got != want {
+			t.Errorf("%s: BasicOutput\n got %s\nwant %s", doc, got, want)
+		}
+	}
+}
+
+func TestValidate_discardedErrors(t *testing.T) {
+	// discarded errors are reused, which must not affect the errors returned
+	sch := lazyCompile(t)
+	valid := decodeString(t, `{"name": "abc", "tags": [1, "a", 2], "kind": "b"}`)
+	invalid := decodeString(t, `{"name": "abc", "tags": [1, true], "kind": "c"}`)
+	want := fmt.Sprintf("%#v", sch.Validate(invalid))
+	for i := 0; i < 10; i++ {
+		if err := sch.Validate(valid); err != nil {
+			t.Fatal(err)
+		}
+		err := sch.Validate(invalid)
+		if got := fmt.Sprintf("%#v", err); got != want {
+			t.Fatalf("got %s\nwant %s", got, want)
+		}
+	}
+}
+
+// keepCompiler compiles keyword x-keep, which validates its schema and
+// retains the error, for the test to inspect after validation.
+type keepCompiler struct {
+	kept *[]*jsonschema.ValidationError
+}
+
+type keepSchema struct {
+	sch  *jsonschema.Schema
+	kept *[]*jsonschema.ValidationError
+}
+
+func (c keepCompiler) Compile(ctx jsonschema.CompilerContext, m map[string]interface{}) (jsonschema.ExtSchema, error) {
+	if _, ok := m["x-keep"]; !ok {
+		return nil, nil
+	}
+	sch, err := ctx.Compile("x-keep", true)
+	if err != nil {
+		return nil, err
+	}
+	return keepSchema{sch, c.kept}, nil
+}
+
+func (s keepSchema) Validate(ctx jsonschema.ValidationContext, v interface{}) error {
+	err := ctx.Validate(s.sch, "x-keep", v, "")
+	if ve, ok := err.(*jsonschema.ValidationError); ok {
+		*s.kept = append(*s.kept, ve)
+	}
+	return err
+}
+
+func TestValidationContext_Validate_retained(t *testing.T) {
+	var kept []*jsonschema.ValidationError
+	c := jsonschema.NewCompiler()
+	if err := c.RegisterExtension("x-keep", nil, keepCompiler{&kept}); err != nil {
+		t.Fatal(err)
+	}
+	// error of first branch is discarded, after second branch passes
+	schema := `{"anyOf": [{"x-keep": {"type": "string"}}, {"type": "integer"}]}`
+	if err := c.AddResource("schema.json", strings.NewReader(schema)); err != nil {
+		t.Fatal(err)
+	}
+	sch := c.MustCompile("schema.json")
+	if err := sch.Validate(1); err != nil {
+		t.Fatal(err)
+	}
+	if len(kept) != 1 {
+		t.Fatalf("got %d errors kept, want 1", len(kept))
+	}
+	want := fmt.Sprintf("%#v", kept[0])
+
+	// later validations reuse the discarded errors
+	other := lazyCompile(t)
+	invalid := decodeString(t, `{"name": "abc", "tags": [1, true], "kind": "c"}`)
+	for i := 0; i < 10; i++ {
+		_ = other.Validate(invalid)
+		_ = sch.Validate(true)
+	}
+	if got := fmt.Sprintf("%#v", kept[0]); got != want || !strings.Contains(got, "expected string") {
+		t.Fatalf("kept error changed:\n got %s\nwant %s", got, want)
+	}
+}
+
+func benchmarkManyErrors(b *testing.B, opts jsonschema.ValidateOptions) {
+	sch := jsonschema.MustCompileString("http://example.com/items.json", `{"items": {"type": "string"}}`)
+	doc := make([]interface{}, 10000)
+	for i := range doc {
+		doc[i] = i
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := sch.ValidateWith(doc, opts); err == nil {
+			b.Fatal("want error")
+		}
+	}
+}
+
+func BenchmarkValidate_manyErrors(b *testing.B) {
+	benchmarkManyErrors(b, jsonschema.ValidateOptions{})
+}
+
+func BenchmarkValidate_manyErrorsLazy(b *testing.B) {
+	benchmarkManyErrors(b, jsonschema.ValidateOptions{LazyMessages: true})
+}
+
+func BenchmarkValidate_discardedErrors(b *testing.B) {
+	sch := jsonschema.MustCompileString("http://example.com/items.json", `{
+		"items": {"anyOf": [{"type": "string", "minLength": 1}, {"type": "integer", "minimum": 0}]}
+	}`)
+	doc := make([]interface{}, 10000)
+	for i := range doc {
+		doc[i] = i
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := sch.Validate(doc); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package jsonschema
+
+import "time"
+
+// MetricsSink receives the outcome of validations done with
+// ValidateOptions.Metrics. See package expvarsink for an implementation
+// over expvar.
+type MetricsSink interface {
+	// Observe is called once per Schema.ValidateWith, after validation.
+	//
+	// schemaURL is the location of schema validated against. leafErrors is
+	// the number of leaf errors in *ValidationError, and topKeyword is the
+	// keyword of the error reported by its Error method. For errors other
+	// than *ValidationError, such as InvalidJSONTypeError, valid is false,
+	// leafErrors is zero and topKeyword is empty.
+	Observe(schemaURL string, valid bool, durationNs int64, leafErrors int, topKeyword string)
+}
+
+// observe reports the validation started at start, which returned *err, to m.
+func (s *Schema) observe(m MetricsSink, start time.Time, err *error) {
+	duration := time.Since(start).Nanoseconds()
+	if *err == nil {
+		m.Observe(s.Location, true, duration, 0, "")
+		return
+	}
+	leafErrors, topKeyword := 0, ""
+	if ve, ok := (*err).(*ValidationError); ok {
+		leafErrors, topKeyword = ve.leafCount(), ve.leaf().Keyword
+	}
+	m.Observe(s.Location, false, duration, leafErrors, topKeyword)
+}
+
+// leafCount returns the number of errors without causes, in the tree rooted at ve.
+func (ve *ValidationError) leafCount() int {
+	if len(ve.Causes) == 0 {
+		return 1
+	}
+	n := 0
+	for _, c := range ve.Causes {
+		n += c.leafCount()
+	}
+	return n
+}
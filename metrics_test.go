@@ -0,0 +1,84 @@
+package jsonschema_test
+
+import (
+	"expvar"
+	"strings"
+	"testing"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+	"github.com/santhosh-tekuri/jsonschema/v5/expvarsink"
+)
+
+type observation struct {
+	schemaURL  string
+	valid      bool
+	durationNs int64
+	leafErrors int
+	topKeyword string
+}
+
+type recordingSink []observation
+
+func (s *recordingSink) Observe(schemaURL string, valid bool, durationNs int64, leafErrors int, topKeyword string) {
+	*s = append(*s, observation{schemaURL, valid, durationNs, leafErrors, topKeyword})
+}
+
+func TestValidateWith_metrics(t *testing.T) {
+	sch, err := jsonschema.CompileString("schema.json", `{
+		"properties": {
+			"a": {"type": "string", "minLength": 2},
+			"b": {"type": "integer"}
+		}
+	}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		doc        interface{}
+		valid      bool
+		leafErrors int
+		topKeyword string
+	}{
+		{map[string]interface{}{"a": "xy"}, true, 0, ""},
+		{map[string]interface{}{"a": "x", "b": "y"}, false, 2, "minLength"},
+		{map[string]interface{}{"b": struct{}{}}, false, 0, ""}, // InvalidJSONTypeError
+	}
+	for i, test := range tests {
+		var sink recordingSink
+		err := sch.ValidateWith(test.doc, jsonschema.ValidateOptions{Metrics: &sink})
+		if len(sink) != 1 {
+			t.Fatalf("#%d: got %d observations, want 1", i, len(sink))
+		}
+		o := sink[0]
+		if o.valid != (err == nil) {
+			t.Errorf("#%d: valid is %v, but error is %v", i, o.valid, err)
+		}
+		if !strings.HasSuffix(o.schemaURL, "schema.json#") || o.valid != test.valid || o.leafErrors != test.leafErrors || o.topKeyword != test.topKeyword {
+			t.Errorf("#%d: got %+v", i, o)
+		}
+		if o.durationNs <= 0 {
+			t.Errorf("#%d: durationNs is %d", i, o.durationNs)
+		}
+	}
+}
+
+func TestExpvarSink(t *testing.T) {
+	sch, err := jsonschema.CompileString("expvar.json", `{"type": "string"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sink := expvarsink.New("jsonschema_test")
+	opts := jsonschema.ValidateOptions{Metrics: sink}
+	for _, doc := range []interface{}{"a", "b", true} {
+		_ = sch.ValidateWith(doc, opts)
+	}
+	m := expvar.Get("jsonschema_test").(*expvar.Map).Get(sch.Location).(*expvar.Map)
+	for name, want := range map[string]string{"valid": "2", "invalid": "1", "leafErrors": "1"} {
+		if got := m.Get(name).String(); got != want {
+			t.Errorf("%s: got %s, want %s", name, got, want)
+		}
+	}
+	if got := m.Get("keywords").String(); got != `{"type": 1}` {
+		t.Errorf("keywords: got %s", got)
+	}
+}
@@ -0,0 +1,93 @@
+package jsonschema
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// NarrowedTypes returns the json types, which a value valid against s
+// can have, as per its type, const and enum keywords. For example
+// {"type": ["string", "integer"], "enum": ["a", "b"]} allows only "string".
+//
+// returns Types if s has neither const nor enum, and empty slice if
+// no value satisfies these keywords together.
+func (s *Schema) NarrowedTypes() []string {
+	return s.narrowedTypes
+}
+
+// narrowTypes computes s.narrowedTypes and s.typesImplied, from Types,
+// Constant and Enum. It returns the declared types, not possible with
+// const or enum.
+func (s *Schema) narrowTypes() (dropped []string) {
+	values, _ := allowedValues(s)
+	s.narrowedTypes, s.typesImplied = s.Types, false
+	if values == nil {
+		return nil
+	}
+
+	if len(s.Types) == 0 {
+		// types of values
+		seen := make(map[string]bool)
+		for _, v := range values {
+			t, err := checkJSONType(v)
+			if err != nil {
+				return nil
+			}
+			seen[t] = true
+		}
+		types := make([]string, 0, len(seen))
+		for t := range seen {
+			types = append(types, t)
+		}
+		sort.Strings(types)
+		s.narrowedTypes = types
+		return nil
+	}
+
+	narrowed := []string{}
+	for _, t := range s.Types {
+		possible := false
+		for _, v := range values {
+			if valueOfTypes(v, []string{t}) {
+				possible = true
+				break
+			}
+		}
+		if possible {
+			narrowed = append(narrowed, t)
+		} else {
+			dropped = append(dropped, t)
+		}
+	}
+	s.narrowedTypes = narrowed
+
+	// a value equal to any of values, has same type as that value
+	s.typesImplied = true
+	for _, v := range values {
+		if !valueOfTypes(v, s.Types) {
+			s.typesImplied = false
+		}
+	}
+	return dropped
+}
+
+// warnNarrowed reports the declared types of s, which are not possible
+// with its const or enum.
+func (c *Compiler) warnNarrowed(s *Schema, dropped []string) error {
+	if len(dropped) == 0 {
+		return nil
+	}
+	_, kw := allowedValues(s)
+	var msg string
+	if len(s.narrowedTypes) == 0 {
+		msg = fmt.Sprintf("no value of %s matches type", kw)
+	} else {
+		msg = fmt.Sprintf("type %s not possible with %s", strings.Join(dropped, ", "), kw)
+	}
+	return c.warn(CompileWarning{
+		Location: s.Location,
+		Keywords: [2]string{joinPtr(s.Location, "type"), joinPtr(s.Location, kw)},
+		Message:  msg,
+	})
+}
@@ -0,0 +1,94 @@
+package jsonschema_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+func TestSchema_NarrowedTypes(t *testing.T) {
+	tests := []struct {
+		schema   string
+		types    []string
+		warnings []string
+	}{
+		{`{"type": ["string", "integer"], "enum": ["a", "b", "c"]}`, []string{"string"}, []string{"type integer not possible with enum"}},
+		{`{"type": ["string", "integer"], "enum": ["a", 1]}`, []string{"string", "integer"}, nil},
+		{`{"type": "integer", "enum": [1.5, "x"]}`, []string{}, []string{"no value of enum matches type"}},
+		{`{"type": ["number", "null"], "const": 2}`, []string{"number"}, []string{"type null not possible with const"}},
+		{`{"type": "integer", "const": 2.0}`, []string{"integer"}, nil},
+		{`{"enum": ["a", 1, null]}`, []string{"null", "number", "string"}, nil},
+		{`{"type": "string"}`, []string{"string"}, nil},
+		{`{}`, nil, nil},
+	}
+	for _, test := range tests {
+		c := jsonschema.NewCompiler()
+		if err := c.AddResource("schema.json", strings.NewReader(test.schema)); err != nil {
+			t.Fatal(err)
+		}
+		var warnings []string
+		c.OnWarning = func(w jsonschema.CompileWarning) {
+			warnings = append(warnings, w.Message)
+		}
+		sch, err := c.Compile("schema.json")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := sch.NarrowedTypes(); !reflect.DeepEqual(got, test.types) {
+			t.Errorf("%s: got %q, want %q", test.schema, got, test.types)
+		}
+		if !reflect.DeepEqual(warnings, test.warnings) {
+			t.Errorf("%s: warnings: got %q, want %q", test.schema, warnings, test.warnings)
+		}
+	}
+}
+
+// type check is skipped, when value matches enum, which implies type.
+// the errors must be same as without skipping.
+func TestSchema_NarrowedTypes_validation(t *testing.T) {
+	sch := jsonschema.MustCompileString("schema.json", `{"type": ["string", "integer"], "enum": ["a", 1.0, 2]}`)
+	for _, v := range []interface{}{"a", json.Number("1"), json.Number("1.0"), float64(2)} {
+		if err := sch.Validate(v); err != nil {
+			t.Errorf("%v: %v", v, err)
+		}
+	}
+	tests := []struct {
+		v       interface{}
+		keyword string
+	}{
+		{true, "type"},
+		{json.Number("1.5"), "type"},
+		{"b", "enum"},
+		{json.Number("3"), "enum"},
+	}
+	for _, test := range tests {
+		err := sch.Validate(test.v)
+		ve, ok := err.(*jsonschema.ValidationError)
+		if !ok {
+			t.Errorf("%v: got %v, want *ValidationError", test.v, err)
+			continue
+		}
+		if len(ve.Causes) != 1 || ve.Causes[0].Keyword != test.keyword {
+			t.Errorf("%v: got %#v, want single %s error", test.v, ve, test.keyword)
+		}
+	}
+
+	// value equal to integer, may not be integer with StrictIntegers
+	opts := jsonschema.ValidateOptions{StrictIntegers: true}
+	if err := sch.ValidateWith(json.Number("1.0"), opts); err == nil {
+		t.Error("1.0 must not be integer with StrictIntegers")
+	}
+	if err := sch.ValidateWith(json.Number("2"), opts); err != nil {
+		t.Error(err)
+	}
+
+	// quoted number is converted before enum check
+	opts = jsonschema.ValidateOptions{TreatQuotedNumbers: true}
+	sch = jsonschema.MustCompileString("schema.json", `{"type": "integer", "enum": [1, 2]}`)
+	if err := sch.ValidateWith("2", opts); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,217 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/santhosh-tekuri/jsonschema/v5/keywords"
+)
+
+// ObjectNode is a json object, decoded into a type other than
+// map[string]interface{}, such as the trees of alternative json decoders.
+//
+// Validate accepts ObjectNode and ArrayNode anywhere in the value,
+// alongside the native types. A node is copied shallowly into native
+// type, when it is first validated; its nested values are not copied
+// until they are validated. Nodes which are maps, slices or pointers
+// are copied once per validation, others for each schema applied to them.
+type ObjectNode interface {
+	// Len returns the number of properties.
+	Len() int
+
+	// Get returns the value of property key.
+	Get(key string) (interface{}, bool)
+
+	// Range calls f for each property, until f returns false.
+	Range(f func(key string, value interface{}) bool)
+}
+
+// ArrayNode is a json array, decoded into a type other than []interface{}.
+// See ObjectNode.
+type ArrayNode interface {
+	// Len returns the number of items.
+	Len() int
+
+	// Index returns the item at index i.
+	Index(i int) interface{}
+}
+
+// MapNode is ObjectNode backed by map[string]interface{}.
+// It is the reference implementation of ObjectNode.
+type MapNode map[string]interface{}
+
+func (m MapNode) Len() int {
+	return len(m)
+}
+
+func (m MapNode) Get(key string) (interface{}, bool) {
+	v, ok := m[key]
+	return v, ok
+}
+
+func (m MapNode) Range(f func(key string, value interface{}) bool) {
+	for k, v := range m {
+		if !f(k, v) {
+			return
+		}
+	}
+}
+
+// SliceNode is ArrayNode backed by []interface{}.
+// It is the reference implementation of ArrayNode.
+type SliceNode []interface{}
+
+func (s SliceNode) Len() int {
+	return len(s)
+}
+
+func (s SliceNode) Index(i int) interface{} {
+	return s[i]
+}
+
+// JSONLeaf is implemented by the types, which are json null, boolean,
+// number or string, but decoded into a domain type, such as a decimal.
+//
+// Validate accepts JSONLeaf anywhere in the value. The value returned by
+// JSONValue is validated in its place.
+type JSONLeaf interface {
+	// JSONValue returns the json value, which must be nil, bool,
+	// string, json.Number, float64, int, int32 or int64.
+	JSONValue() (interface{}, error)
+}
+
+// native returns v with ObjectNode converted to map[string]interface{}
+// and ArrayNode converted to []interface{}. Only the top level is
+// converted; the nested values are converted when they are visited.
+//
+// json.RawMessage is decoded. map[string]json.RawMessage and
+// []json.RawMessage are converted without decoding their values,
+// so that only the values visited are decoded.
+//
+// JSONLeaf is converted to its json value. time.Time is converted to
+// RFC 3339 string, and json.Marshaler to its json value, if it is
+// not object or array.
+func native(v interface{}) (interface{}, error) {
+	switch n := v.(type) {
+	case nil, bool, string, json.Number, float64, int, int32, int64, map[string]interface{}, []interface{}:
+		return v, nil
+	case json.RawMessage:
+		v, err := decodeBytes(n)
+		if err != nil {
+			return nil, fmt.Errorf("json.RawMessage: %v", err)
+		}
+		return v, nil
+	case map[string]json.RawMessage:
+		m := make(map[string]interface{}, len(n))
+		for key, value := range n {
+			m[key] = value
+		}
+		return m, nil
+	case []json.RawMessage:
+		arr := make([]interface{}, len(n))
+		for i, item := range n {
+			arr[i] = item
+		}
+		return arr, nil
+	case ObjectNode:
+		m := make(map[string]interface{}, n.Len())
+		n.Range(func(key string, value interface{}) bool {
+			m[key] = value
+			return true
+		})
+		return m, nil
+	case ArrayNode:
+		arr := make([]interface{}, n.Len())
+		for i := range arr {
+			arr[i] = n.Index(i)
+		}
+		return arr, nil
+	case JSONLeaf:
+		lv, err := n.JSONValue()
+		if err != nil {
+			return nil, fmt.Errorf("%T: %v", v, err)
+		}
+		return leaf(v, lv)
+	case time.Time:
+		return n.Format(time.RFC3339Nano), nil
+	case json.Marshaler:
+		b, err := json.Marshal(n)
+		if err != nil {
+			return nil, fmt.Errorf("%T: %v", v, err)
+		}
+		lv, err := decodeBytes(b)
+		if err != nil {
+			return nil, fmt.Errorf("%T: %v", v, err)
+		}
+		return leaf(v, lv)
+	}
+	return v, nil
+}
+
+// nativeValue is the native value v, of json value orig.
+type nativeValue struct {
+	orig interface{}
+	v    interface{}
+}
+
+// native is native(v) for the value at vloc, which decodes a json.RawMessage,
+// or copies a node, at most once per validation, though it is validated
+// against many schemas.
+func (vd *validation) native(v interface{}, vloc string) (interface{}, error) {
+	if !cacheable(v) {
+		return native(v)
+	}
+	if nv, ok := vd.natives[vloc]; ok && sameValue(nv.orig, v) {
+		return nv.v, nil
+	}
+	n, err := native(v)
+	if err != nil {
+		return nil, err
+	}
+	if vd.natives == nil {
+		vd.natives = make(map[string]nativeValue)
+	}
+	vd.natives[vloc] = nativeValue{v, n}
+	return n, nil
+}
+
+// cacheable tells whether native value of v is cached by validation.native.
+func cacheable(v interface{}) bool {
+	switch v.(type) {
+	case json.RawMessage, map[string]json.RawMessage, []json.RawMessage:
+		return true
+	case ObjectNode, ArrayNode:
+		// nodes, which are not maps, slices or pointers, cannot be told apart
+		switch reflect.TypeOf(v).Kind() {
+		case reflect.Map, reflect.Slice, reflect.Ptr:
+			return true
+		}
+	}
+	return false
+}
+
+// sameValue tells whether a and b are the same map, slice or pointer.
+func sameValue(a, b interface{}) bool {
+	ra, rb := reflect.ValueOf(a), reflect.ValueOf(b)
+	if ra.Type() != rb.Type() {
+		return false
+	}
+	switch ra.Kind() {
+	case reflect.Map, reflect.Ptr:
+		return ra.Pointer() == rb.Pointer()
+	case reflect.Slice:
+		return ra.Pointer() == rb.Pointer() && ra.Len() == rb.Len()
+	}
+	return false
+}
+
+// leaf returns lv, the json value of v, if it is not object or array.
+func leaf(v, lv interface{}) (interface{}, error) {
+	switch keywords.TypeOf(lv) {
+	case "", "object", "array":
+		return nil, fmt.Errorf("%T: json value %T is not null, boolean, number or string", v, lv)
+	}
+	return lv, nil
+}
@@ -0,0 +1,183 @@
+package jsonschema_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// pairsNode is an object node, which keeps properties in order.
+type pairsNode []pair
+
+type pair struct {
+	key   string
+	value interface{}
+}
+
+func (n pairsNode) Len() int {
+	return len(n)
+}
+
+func (n pairsNode) Get(key string) (interface{}, bool) {
+	for _, p := range n {
+		if p.key == key {
+			return p.value, true
+		}
+	}
+	return nil, false
+}
+
+func (n pairsNode) Range(f func(key string, value interface{}) bool) {
+	for _, p := range n {
+		if !f(p.key, p.value) {
+			return
+		}
+	}
+}
+
+// listNode is an array node.
+type listNode struct {
+	items []interface{}
+}
+
+func (n *listNode) Len() int {
+	return len(n.items)
+}
+
+func (n *listNode) Index(i int) interface{} {
+	return n.items[i]
+}
+
+// toNodes converts the objects and arrays in v to pairsNode and listNode.
+func toNodes(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		var n pairsNode
+		for key, value := range v {
+			n = append(n, pair{key, toNodes(value)})
+		}
+		return n
+	case []interface{}:
+		n := &listNode{}
+		for _, item := range v {
+			n.items = append(n.items, toNodes(item))
+		}
+		return n
+	}
+	return v
+}
+
+func TestNodes(t *testing.T) {
+	folder := testSuite + "/tests/draft2020-12"
+	fis, err := ioutil.ReadDir(folder)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, fi := range fis {
+		if fi.IsDir() || path.Ext(fi.Name()) != ".json" {
+			continue
+		}
+		t.Run(fi.Name(), func(t *testing.T) {
+			skip := skipTests["TestDraft2020/"+fi.Name()]
+			if skip != nil && len(skip) == 0 {
+				t.Skip()
+			}
+			f, err := os.Open(path.Join(folder, fi.Name()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+			var tg []struct {
+				Description string
+				Schema      json.RawMessage
+				Tests       []struct {
+					Description string
+					Data        interface{}
+					Valid       bool
+				}
+			}
+			dec := json.NewDecoder(f)
+			dec.UseNumber()
+			if err = dec.Decode(&tg); err != nil {
+				t.Fatal(err)
+			}
+			for _, group := range tg {
+				if skip := skip[group.Description]; skip != nil {
+					continue
+				}
+				c := jsonschema.NewCompiler()
+				c.Draft = jsonschema.Draft2020
+				if err := c.AddResource("schema.json", bytes.NewReader(group.Schema)); err != nil {
+					t.Fatal(err)
+				}
+				schema, err := c.Compile("schema.json")
+				if err != nil {
+					t.Fatalf("%#v", err)
+				}
+				for _, test := range group.Tests {
+					err := schema.Validate(toNodes(test.Data))
+					if _, ok := err.(*jsonschema.ValidationError); err != nil && !ok {
+						t.Fatalf("%s/%s: got %#v, want *jsonschema.ValidationError", group.Description, test.Description, err)
+					}
+					if valid := err == nil; valid != test.Valid {
+						t.Errorf("%s/%s: valid: got %v, want %v", group.Description, test.Description, valid, test.Valid)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestNodes_mapNode(t *testing.T) {
+	sch, err := jsonschema.CompileString("schema.json", `{
+		"type": "object",
+		"required": ["a"],
+		"properties": {"a": {"type": "array", "minItems": 2, "uniqueItems": true}},
+		"const": {"a": [1, 2]}
+	}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc := jsonschema.MapNode{"a": jsonschema.SliceNode{json.Number("1"), json.Number("2")}}
+	if err := sch.Validate(doc); err != nil {
+		t.Fatal(err)
+	}
+	doc = jsonschema.MapNode{"a": jsonschema.SliceNode{json.Number("1"), json.Number("1")}}
+	if err := sch.Validate(doc); err == nil {
+		t.Fatal("error expected")
+	}
+}
+
+func TestNodes_copiedOnce(t *testing.T) {
+	allOf := func(n int) *jsonschema.Schema {
+		branches := make([]string, n)
+		for i := range branches {
+			branches[i] = `{"type": "object", "properties": {"a": {"items": {"properties": {"x": {"type": "integer"}}}}}}`
+		}
+		return jsonschema.MustCompileString("schema.json", `{"allOf": [`+strings.Join(branches, ",")+`]}`)
+	}
+	decoded := decodeString(t, `{"a": [{"x": 1}, {"x": 2}, {"x": 3}, {"x": 4}, {"x": 5}, {"x": 6}, {"x": 7}, {"x": 8}], "b": "value"}`)
+	nodes := toNodes(decoded)
+	// copying cost is the difference in allocations,
+	// between validating nodes and decoded value
+	cost := func(sch *jsonschema.Schema) float64 {
+		allocs := func(v interface{}) float64 {
+			return testing.AllocsPerRun(20, func() {
+				if err := sch.Validate(v); err != nil {
+					t.Fatal(err)
+				}
+			})
+		}
+		return allocs(nodes) - allocs(decoded)
+	}
+	// allow some noise, as pooled values may be collected during the runs
+	if one, many := cost(allOf(1)), cost(allOf(10)); many > 2*one {
+		t.Errorf("copying cost with 10 branches is %v allocations, with 1 branch %v", many, one)
+	}
+}
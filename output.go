@@ -1,5 +1,11 @@
 package jsonschema
 
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
 // Flag is output format with simple boolean property valid.
 type Flag struct {
 	Valid bool `json:"valid"`
@@ -35,7 +41,7 @@ func (ve *ValidationError) BasicOutput() Basic {
 			KeywordLocation:         ve.KeywordLocation,
 			AbsoluteKeywordLocation: ve.AbsoluteKeywordLocation,
 			InstanceLocation:        ve.InstanceLocation,
-			Error:                   ve.Message,
+			Error:                   ve.message(),
 		})
 		for _, cause := range ve.Causes {
 			flatten(cause)
@@ -63,7 +69,7 @@ func (ve *ValidationError) DetailedOutput() Detailed {
 	for _, cause := range ve.Causes {
 		errors = append(errors, cause.DetailedOutput())
 	}
-	var message = ve.Message
+	var message = ve.message()
 	if len(ve.Causes) > 0 {
 		message = ""
 	}
@@ -75,3 +81,186 @@ func (ve *ValidationError) DetailedOutput() Detailed {
 		Errors:                  errors,
 	}
 }
+
+// Detailed String ---
+
+// RenderOptions controls the output of ValidationError.Render.
+type RenderOptions struct {
+	// AllBranches shows causes from all branches of failed anyOf/oneOf.
+	// By default, only the branches with fewest errors are shown, preferring
+	// the branches which did not fail on type of the value, or on const or
+	// enum of the value or its direct children, like a discriminator property.
+	AllBranches bool
+
+	// MaxLength limits the length of output in bytes. Zero means no limit.
+	MaxLength int
+}
+
+// DetailedString returns human-readable rendering of ve, with causes
+// indented. It is same as Render with MaxLength 4096.
+func (ve *ValidationError) DetailedString() string {
+	return ve.Render(RenderOptions{MaxLength: 4096})
+}
+
+// Render returns human-readable rendering of ve, with causes indented.
+//
+// Leaf errors with same message, reported for different items of
+// same array, are rendered as single line.
+func (ve *ValidationError) Render(opts RenderOptions) string {
+	r := &renderer{opts: opts}
+	r.render(ve, 0)
+	return strings.Join(r.lines, "\n")
+}
+
+type renderer struct {
+	opts      RenderOptions
+	lines     []string
+	length    int
+	truncated bool
+}
+
+func (r *renderer) line(indent int, format string, a ...interface{}) {
+	if r.truncated {
+		return
+	}
+	line := strings.Repeat("  ", indent) + fmt.Sprintf(format, a...)
+	if r.opts.MaxLength > 0 && r.length+len(line)+1 > r.opts.MaxLength {
+		r.lines = append(r.lines, strings.Repeat("  ", indent)+"... (output truncated)")
+		r.truncated = true
+		return
+	}
+	r.lines = append(r.lines, line)
+	r.length += len(line) + 1
+}
+
+func (r *renderer) render(ve *ValidationError, indent int) {
+	if msg := ve.message(); msg != "" {
+		r.line(indent, "at %s: %s", quote(ve.InstanceLocation), msg)
+		indent++
+	}
+	if (ve.Keyword == "anyOf" || ve.Keyword == "oneOf") && len(ve.Causes) > 1 {
+		shown := ve.Causes
+		if !r.opts.AllBranches {
+			shown = intendedBranches(ve)
+		}
+		for _, branch := range shown {
+			r.line(indent, "%s:", branchName(ve, branch))
+			r.render(branch, indent+1)
+		}
+		if omitted := len(ve.Causes) - len(shown); omitted > 0 {
+			r.line(indent, "(%d other branches omitted)", omitted)
+		}
+		return
+	}
+	r.causes(flattenCauses(ve), indent)
+}
+
+// causes renders the given causes, grouping leaves which differ
+// only in array index.
+func (r *renderer) causes(causes []*ValidationError, indent int) {
+	// key returns the group key of leaf error c, and its array location and index
+	key := func(c *ValidationError) (key, arrayLoc, index string) {
+		if len(c.Causes) > 0 {
+			return "", "", ""
+		}
+		slash := strings.LastIndexByte(c.InstanceLocation, '/')
+		if slash == -1 {
+			return "", "", ""
+		}
+		arrayLoc, index = c.InstanceLocation[:slash], c.InstanceLocation[slash+1:]
+		if _, err := strconv.Atoi(index); err != nil {
+			return "", "", ""
+		}
+		return arrayLoc + "\x00" + c.Keyword + "\x00" + c.message(), arrayLoc, index
+	}
+	groups := make(map[string][]string)
+	for _, c := range causes {
+		if k, _, index := key(c); k != "" {
+			groups[k] = append(groups[k], index)
+		}
+	}
+	for _, c := range causes {
+		if k, arrayLoc, _ := key(c); len(groups[k]) > 1 {
+			r.line(indent, "at %s items %s: %s", quote(arrayLoc), strings.Join(groups[k], ","), c.message())
+			groups[k] = nil // rendered
+			continue
+		} else if k != "" && groups[k] == nil {
+			continue // rendered with group
+		}
+		r.render(c, indent)
+	}
+}
+
+// flattenCauses returns causes of ve, with the causes of
+// wrapping errors with empty message inlined.
+func flattenCauses(ve *ValidationError) []*ValidationError {
+	var causes []*ValidationError
+	for _, c := range ve.Causes {
+		if c.message() == "" {
+			causes = append(causes, flattenCauses(c)...)
+		} else {
+			causes = append(causes, c)
+		}
+	}
+	return causes
+}
+
+// branchName returns keyword location of branch relative to anyOf/oneOf ve.
+// for example "oneOf/2".
+func branchName(ve, branch *ValidationError) string {
+	name := strings.TrimPrefix(branch.KeywordLocation, ve.KeywordLocation)
+	name = strings.TrimPrefix(name, "/")
+	if slash := strings.IndexByte(name, '/'); slash != -1 {
+		name = name[:slash]
+	}
+	return ve.Keyword + "/" + name
+}
+
+// intendedBranches returns the causes of failed anyOf/oneOf ve, which
+// were most likely intended to match.
+//
+// a branch that fails on type of the value, or on const or enum of the
+// value or its direct children is unlikely to be intended, as it is
+// typically a mismatch of discriminator. among the remaining branches, the ones
+// with fewest leaf errors are chosen.
+func intendedBranches(ve *ValidationError) []*ValidationError {
+	type score struct{ mismatches, leaves int }
+	score1 := func(branch *ValidationError) score {
+		var sc score
+		var walk func(*ValidationError)
+		walk = func(e *ValidationError) {
+			if len(e.Causes) == 0 {
+				sc.leaves++
+				loc := e.InstanceLocation
+				switch e.Keyword {
+				case "type", "false":
+					if loc == ve.InstanceLocation {
+						sc.mismatches++
+					}
+				case "const", "enum":
+					if loc == ve.InstanceLocation || loc[:strings.LastIndexByte(loc, '/')] == ve.InstanceLocation {
+						sc.mismatches++
+					}
+				}
+			}
+			for _, c := range e.Causes {
+				walk(c)
+			}
+		}
+		walk(branch)
+		return sc
+	}
+	var best []*ValidationError
+	var bestScore score
+	for _, branch := range ve.Causes {
+		sc := score1(branch)
+		switch {
+		case best == nil || sc.mismatches < bestScore.mismatches ||
+			(sc.mismatches == bestScore.mismatches && sc.leaves < bestScore.leaves):
+			best, bestScore = []*ValidationError{branch}, sc
+		case sc == bestScore:
+			best = append(best, branch)
+		}
+	}
+	return best
+}
@@ -0,0 +1,106 @@
+package jsonschema_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+var shapesSchema = `{
+	"type": "array",
+	"items": {
+		"oneOf": [
+			{
+				"properties": {
+					"kind": {"const": "circle"},
+					"radius": {"type": "number", "exclusiveMinimum": 0}
+				},
+				"required": ["kind", "radius"]
+			},
+			{
+				"properties": {
+					"kind": {"const": "rect"},
+					"width": {"type": "number"},
+					"height": {"type": "number"}
+				},
+				"required": ["kind", "width", "height"]
+			},
+			{
+				"properties": {
+					"kind": {"const": "polygon"},
+					"points": {"type": "array", "items": {"type": "number"}},
+					"color": {"type": "string"},
+					"label": {"type": "string"}
+				},
+				"required": ["kind", "points"]
+			}
+		]
+	}
+}`
+
+func TestValidationError_DetailedString(t *testing.T) {
+	sch := jsonschema.MustCompileString("shapes.json", shapesSchema)
+	tests := []struct {
+		doc     string
+		want    []string // expected substrings
+		notWant []string // unexpected substrings
+	}{
+		{
+			// fewest errors
+			doc:     `[{"kind": "circle", "radius": -1}]`,
+			want:    []string{"oneOf/0:", "must be > 0", "(2 other branches omitted)"},
+			notWant: []string{"oneOf/1:", "oneOf/2:"},
+		},
+		{
+			// more errors in intended branch, than in others
+			doc:     `[{"kind": "polygon", "points": [1, "a", 3, "b", 5, "c"], "color": 1, "label": 2}]`,
+			want:    []string{"oneOf/2:", "at '/0/points' items 1,3,5: expected number, but got string", "(2 other branches omitted)"},
+			notWant: []string{"oneOf/0:", "oneOf/1:", "at '/0/points/1'"},
+		},
+		{
+			// no branch intended
+			doc:  `[{"kind": "hexagon"}]`,
+			want: []string{"oneOf/0:", "oneOf/1:", "oneOf/2:"},
+		},
+	}
+	for _, test := range tests {
+		ve, ok := sch.Validate(decodeString(t, test.doc)).(*jsonschema.ValidationError)
+		if !ok {
+			t.Fatalf("%s: *ValidationError expected", test.doc)
+		}
+		got := ve.DetailedString()
+		t.Logf("%s\n%s", test.doc, got)
+		for _, want := range test.want {
+			if !strings.Contains(got, want) {
+				t.Errorf("%s: %q not found in\n%s", test.doc, want, got)
+			}
+		}
+		for _, notWant := range test.notWant {
+			if strings.Contains(got, notWant) {
+				t.Errorf("%s: %q found in\n%s", test.doc, notWant, got)
+			}
+		}
+
+		all := ve.Render(jsonschema.RenderOptions{AllBranches: true})
+		if !strings.Contains(all, "oneOf/0:") || !strings.Contains(all, "oneOf/2:") || strings.Contains(all, "omitted") {
+			t.Errorf("%s: all branches must be shown\n%s", test.doc, all)
+		}
+	}
+}
+
+func TestValidationError_Render_maxLength(t *testing.T) {
+	sch := jsonschema.MustCompileString("shapes.json", shapesSchema)
+	doc := make([]interface{}, 100)
+	for i := range doc {
+		doc[i] = map[string]interface{}{"kind": "circle", "radius": "x"}
+	}
+	ve := sch.Validate(doc).(*jsonschema.ValidationError)
+	got := ve.Render(jsonschema.RenderOptions{MaxLength: 500})
+	if len(got) > 500+len("... (output truncated)")+10 {
+		t.Fatalf("length: got %d", len(got))
+	}
+	if !strings.HasSuffix(got, "... (output truncated)") {
+		t.Fatalf("got\n%s", got)
+	}
+}
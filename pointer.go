@@ -0,0 +1,275 @@
+package jsonschema
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// InstanceAccessor gives access to values in decoded json document,
+// addressed by json-pointer. Pointers are parsed in place, thus
+// lookups do not allocate, unless a reference token has escapes
+// longer than 64 bytes.
+type InstanceAccessor struct {
+	doc interface{}
+}
+
+// NewInstanceAccessor returns InstanceAccessor for the decoded json document doc.
+func NewInstanceAccessor(doc interface{}) *InstanceAccessor {
+	return &InstanceAccessor{doc}
+}
+
+// Get returns the value at json-pointer ptr. Empty ptr refers to whole document.
+//
+// returns false, if ptr is invalid or there is no value at ptr.
+// Array indexes with leading zeros are invalid.
+func (a *InstanceAccessor) Get(ptr string) (interface{}, bool) {
+	return lookup(a.doc, ptr)
+}
+
+// Parent returns the value containing the value at json-pointer ptr,
+// along with the unescaped reference token of the value in its parent.
+//
+// returns false, if ptr refers to whole document or there is no value at ptr.
+func (a *InstanceAccessor) Parent(ptr string) (interface{}, string, bool) {
+	slash := strings.LastIndexByte(ptr, '/')
+	if slash == -1 {
+		return nil, "", false
+	}
+	parent, ok := lookup(a.doc, ptr[:slash])
+	if !ok {
+		return nil, "", false
+	}
+	token := ptr[slash+1:]
+	switch parent := parent.(type) {
+	case map[string]interface{}:
+		if strings.IndexByte(token, '~') != -1 {
+			b, ok := unescapeToken(nil, token)
+			if !ok {
+				return nil, "", false
+			}
+			token = string(b)
+		}
+		if _, ok := parent[token]; !ok {
+			return nil, "", false
+		}
+	case []interface{}:
+		if i, ok := arrayIndex(token); !ok || i >= len(parent) {
+			return nil, "", false
+		}
+	default:
+		return nil, "", false
+	}
+	return parent, token, true
+}
+
+// Len returns the number of items in array, number of properties in
+// object or number of characters in string, at json-pointer ptr.
+//
+// returns false, if there is no value at ptr, or the value is of other type.
+func (a *InstanceAccessor) Len(ptr string) (int, bool) {
+	v, ok := lookup(a.doc, ptr)
+	if !ok {
+		return 0, false
+	}
+	switch v := v.(type) {
+	case []interface{}:
+		return len(v), true
+	case map[string]interface{}:
+		return len(v), true
+	case string:
+		return utf8.RuneCountInString(v), true
+	}
+	return 0, false
+}
+
+// lookup returns the value in v at json-pointer ptr.
+func lookup(v interface{}, ptr string) (interface{}, bool) {
+	if ptr == "" {
+		return v, true
+	}
+	if ptr[0] != '/' {
+		return nil, false
+	}
+	var buf [64]byte
+	for ptr != "" {
+		token := ptr[1:]
+		if slash := strings.IndexByte(token, '/'); slash != -1 {
+			token, ptr = token[:slash], token[slash:]
+		} else {
+			ptr = ""
+		}
+		if n, err := native(v); err == nil {
+			v = n
+		}
+		switch obj := v.(type) {
+		case map[string]interface{}:
+			var ok bool
+			if strings.IndexByte(token, '~') == -1 {
+				v, ok = obj[token]
+			} else {
+				var b []byte
+				if b, ok = unescapeToken(buf[:0], token); ok {
+					v, ok = obj[string(b)] // no allocation for map index
+				}
+			}
+			if !ok {
+				return nil, false
+			}
+		case []interface{}:
+			i, ok := arrayIndex(token)
+			if !ok || i >= len(obj) {
+				return nil, false
+			}
+			v = obj[i]
+		default:
+			return nil, false
+		}
+	}
+	return v, true
+}
+
+// unescapeToken appends the unescaped json-pointer reference token to b.
+//
+// returns false, if token has invalid escape sequence.
+func unescapeToken(b []byte, token string) ([]byte, bool) {
+	for i := 0; i < len(token); i++ {
+		ch := token[i]
+		if ch == '~' {
+			if i == len(token)-1 {
+				return nil, false
+			}
+			switch i++; token[i] {
+			case '0':
+				ch = '~'
+			case '1':
+				ch = '/'
+			default:
+				return nil, false
+			}
+		}
+		b = append(b, ch)
+	}
+	return b, true
+}
+
+// arrayIndex parses json-pointer reference token as array index.
+//
+// returns false, if token is not a number, or has leading zeros.
+func arrayIndex(token string) (int, bool) {
+	if token == "" || len(token) > 9 || (token[0] == '0' && len(token) > 1) {
+		return 0, false
+	}
+	i := 0
+	for _, ch := range []byte(token) {
+		if ch < '0' || ch > '9' {
+			return 0, false
+		}
+		i = i*10 + int(ch-'0')
+	}
+	return i, true
+}
+
+// EvalRelativePointer evaluates the relative json-pointer rel, starting from
+// the location base in doc. base is json-pointer.
+//
+// It returns the value referred along with its json-pointer. If rel ends
+// with '#', the value returned is the property name of type string, or
+// the array index of type int, of the location referred.
+//
+// see https://datatracker.ietf.org/doc/html/draft-handrews-relative-json-pointer
+func EvalRelativePointer(doc interface{}, base string, rel string) (interface{}, string, error) {
+	invalid := func() error {
+		return fmt.Errorf("jsonschema: invalid relative json-pointer %q", rel)
+	}
+	notFound := func(ptr string) error {
+		return fmt.Errorf("jsonschema: relative json-pointer %q from %q: no value at %q", rel, base, ptr)
+	}
+	if _, ok := lookup(doc, base); !ok {
+		return nil, "", notFound(base)
+	}
+	var tokens []string // escaped tokens of base
+	if base != "" {
+		tokens = strings.Split(base[1:], "/")
+	}
+
+	// non-negative-integer prefix
+	i := 0
+	for i < len(rel) && rel[i] >= '0' && rel[i] <= '9' {
+		i++
+	}
+	up, ok := arrayIndex(rel[:i])
+	if !ok {
+		return nil, "", invalid()
+	}
+	if up > len(tokens) {
+		return nil, "", notFound("")
+	}
+	tokens = tokens[:len(tokens)-up]
+
+	// index manipulation
+	if i < len(rel) && (rel[i] == '+' || rel[i] == '-') {
+		sign := rel[i]
+		i++
+		start := i
+		for i < len(rel) && rel[i] >= '0' && rel[i] <= '9' {
+			i++
+		}
+		delta, ok := arrayIndex(rel[start:i])
+		if !ok {
+			return nil, "", invalid()
+		}
+		if sign == '-' {
+			delta = -delta
+		}
+		if len(tokens) == 0 {
+			return nil, "", notFound("")
+		}
+		parentPtr := joinTokens(tokens[:len(tokens)-1])
+		parent, _ := lookup(doc, parentPtr)
+		arr, ok := parent.([]interface{})
+		if !ok {
+			return nil, "", fmt.Errorf("jsonschema: relative json-pointer %q from %q: %q is not array", rel, base, parentPtr)
+		}
+		index, _ := arrayIndex(tokens[len(tokens)-1])
+		index += delta
+		if index < 0 || index >= len(arr) {
+			return nil, "", notFound(parentPtr + "/" + strconv.Itoa(index))
+		}
+		tokens[len(tokens)-1] = strconv.Itoa(index)
+	}
+
+	ptr := joinTokens(tokens)
+	switch rest := rel[i:]; {
+	case rest == "#":
+		if len(tokens) == 0 {
+			return nil, "", fmt.Errorf("jsonschema: relative json-pointer %q from %q: root has no name", rel, base)
+		}
+		parent, _ := lookup(doc, joinTokens(tokens[:len(tokens)-1]))
+		token := tokens[len(tokens)-1]
+		if _, ok := parent.([]interface{}); ok {
+			index, _ := arrayIndex(token)
+			return index, ptr, nil
+		}
+		name, _ := unescapeToken(nil, token)
+		return string(name), ptr, nil
+	case rest == "" || rest[0] == '/':
+		ptr += rest
+		v, ok := lookup(doc, ptr)
+		if !ok {
+			return nil, "", notFound(ptr)
+		}
+		return v, ptr, nil
+	default:
+		return nil, "", invalid()
+	}
+}
+
+// joinTokens returns json-pointer with given escaped reference tokens.
+func joinTokens(tokens []string) string {
+	if len(tokens) == 0 {
+		return ""
+	}
+	return "/" + strings.Join(tokens, "/")
+}
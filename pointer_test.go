@@ -0,0 +1,205 @@
+package jsonschema_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+const accessorDoc = `{
+	"a/b": {"c~d": [10, 20, {"": "empty"}]},
+	"list": ["x", "yz", "héllo"],
+	"deep": {"l1": {"l2": {"l3": {"l4": [[0, [1, {"leaf": true}]]]}}}}
+}`
+
+func TestInstanceAccessor_Get(t *testing.T) {
+	a := jsonschema.NewInstanceAccessor(decodeString(t, accessorDoc))
+	tests := []struct {
+		ptr  string
+		want interface{}
+		ok   bool
+	}{
+		{"/a~1b/c~0d/1", "20", true},
+		{"/a~1b/c~0d/2/", "empty", true},
+		{"/list/0", "x", true},
+		{"/deep/l1/l2/l3/l4/0/1/1/leaf", true, true},
+		{"/list/01", nil, false},  // leading zero
+		{"/list/-", nil, false},   // past the end
+		{"/list/3", nil, false},   // out of range
+		{"/list/-1", nil, false},  // negative
+		{"/list/1e0", nil, false}, // not integer
+		{"/a/b", nil, false},      // unescaped slash
+		{"/a~2b", nil, false},     // invalid escape
+		{"/a~", nil, false},       // incomplete escape
+		{"list", nil, false},      // no leading slash
+		{"/list/0/x", nil, false}, // descend into string
+	}
+	for _, test := range tests {
+		got, ok := a.Get(test.ptr)
+		if ok != test.ok {
+			t.Errorf("%q: got ok=%v, want %v", test.ptr, ok, test.ok)
+			continue
+		}
+		if n, isNum := got.(interface{ String() string }); isNum {
+			got = n.String()
+		}
+		if ok && !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%q: got %v, want %v", test.ptr, got, test.want)
+		}
+	}
+	if v, ok := a.Get(""); !ok || len(v.(map[string]interface{})) != 3 {
+		t.Errorf("empty pointer must return whole document, got %v", v)
+	}
+}
+
+func TestInstanceAccessor_Parent(t *testing.T) {
+	a := jsonschema.NewInstanceAccessor(decodeString(t, accessorDoc))
+	parent, token, ok := a.Parent("/a~1b/c~0d")
+	if !ok || token != "c~d" {
+		t.Fatalf("got %q, %v", token, ok)
+	}
+	if _, ok := parent.(map[string]interface{})["c~d"]; !ok {
+		t.Fatalf("got parent %v", parent)
+	}
+	if parent, token, ok := a.Parent("/list/2"); !ok || token != "2" || len(parent.([]interface{})) != 3 {
+		t.Fatalf("got %v, %q, %v", parent, token, ok)
+	}
+	for _, ptr := range []string{"", "/missing", "/list/3", "/list/02", "/list/0/x"} {
+		if _, _, ok := a.Parent(ptr); ok {
+			t.Errorf("%q: parent must not be found", ptr)
+		}
+	}
+}
+
+func TestInstanceAccessor_Len(t *testing.T) {
+	a := jsonschema.NewInstanceAccessor(decodeString(t, accessorDoc))
+	tests := []struct {
+		ptr  string
+		want int
+		ok   bool
+	}{
+		{"", 3, true},
+		{"/list", 3, true},
+		{"/list/2", 5, true},
+		{"/a~1b/c~0d/0", 0, false},
+		{"/missing", 0, false},
+	}
+	for _, test := range tests {
+		if got, ok := a.Len(test.ptr); got != test.want || ok != test.ok {
+			t.Errorf("%q: got %d, %v, want %d, %v", test.ptr, got, ok, test.want, test.ok)
+		}
+	}
+}
+
+func TestInstanceAccessor_allocs(t *testing.T) {
+	a := jsonschema.NewInstanceAccessor(decodeString(t, accessorDoc))
+	for _, ptr := range []string{"/deep/l1/l2/l3/l4/0/1/1/leaf", "/a~1b/c~0d/2/"} {
+		allocs := testing.AllocsPerRun(100, func() {
+			if _, ok := a.Get(ptr); !ok {
+				t.Fatal("not found")
+			}
+		})
+		if allocs != 0 {
+			t.Errorf("%q: got %v allocations", ptr, allocs)
+		}
+	}
+}
+
+func BenchmarkInstanceAccessor_Get(b *testing.B) {
+	doc, err := jsonschema.DecodeJSON(strings.NewReader(accessorDoc))
+	if err != nil {
+		b.Fatal(err)
+	}
+	a := jsonschema.NewInstanceAccessor(doc)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		a.Get("/deep/l1/l2/l3/l4/0/1/1/leaf")
+	}
+}
+
+func TestEvalRelativePointer(t *testing.T) {
+	// test vectors from draft-handrews-relative-json-pointer
+	doc := decodeString(t, `{
+		"foo": ["bar", "baz"],
+		"highly": {"nested": {"objects": true}}
+	}`)
+	tests := []struct {
+		base, rel string
+		want      interface{}
+		ptr       string
+	}{
+		{"/foo/1", "0", "baz", "/foo/1"},
+		{"/foo/1", "1/0", "bar", "/foo/0"},
+		{"/foo/1", "0-1", "bar", "/foo/0"},
+		{"/foo/1", "2/highly/nested/objects", true, "/highly/nested/objects"},
+		{"/foo/1", "0#", 1, "/foo/1"},
+		{"/foo/1", "0-1#", 0, "/foo/0"},
+		{"/foo/1", "1#", "foo", "/foo"},
+		{"/highly/nested", "0/objects", true, "/highly/nested/objects"},
+		{"/highly/nested", "1/nested/objects", true, "/highly/nested/objects"},
+		{"/highly/nested", "2/foo/0", "bar", "/foo/0"},
+		{"/highly/nested", "0#", "nested", "/highly/nested"},
+		{"/highly/nested", "1#", "highly", "/highly"},
+		{"/foo/0", "0+1", "baz", "/foo/1"},
+	}
+	for _, test := range tests {
+		got, ptr, err := jsonschema.EvalRelativePointer(doc, test.base, test.rel)
+		if err != nil {
+			t.Errorf("%s from %s: %v", test.rel, test.base, err)
+			continue
+		}
+		if s, ok := got.(interface{ String() string }); ok {
+			got = s.String()
+		}
+		if !reflect.DeepEqual(got, test.want) || ptr != test.ptr {
+			t.Errorf("%s from %s: got %#v at %q, want %#v at %q", test.rel, test.base, got, ptr, test.want, test.ptr)
+		}
+	}
+
+	errorTests := []struct {
+		base, rel string
+	}{
+		{"/foo/1", "3"},        // above root
+		{"", "0#"},             // root has no name
+		{"/foo/1", "01"},       // leading zero
+		{"/foo/1", "0+1"},      // index out of range
+		{"/foo/0", "0-01"},     // leading zero in index manipulation
+		{"/highly", "0+1"},     // not array item
+		{"/foo/1", "0/x"},      // missing
+		{"/foo/1", "x"},        // no prefix
+		{"/foo/1", "0x"},       // invalid suffix
+		{"/foo/1", "0##"},      // invalid suffix
+		{"/missing", "0"},      // base missing
+		{"/foo/1", "1/a~2"},    // invalid escape
+		{"/foo/1", "-1"},       // negative prefix
+		{"/foo/1", "0#/foo/0"}, // pointer after '#'
+	}
+	for _, test := range errorTests {
+		if got, _, err := jsonschema.EvalRelativePointer(doc, test.base, test.rel); err == nil {
+			t.Errorf("%s from %s: got %v, want error", test.rel, test.base, got)
+		}
+	}
+}
+
+func TestDependentRequired_details(t *testing.T) {
+	sch := jsonschema.MustCompileString("test.json", `{
+		"properties": {
+			"card": {"dependentRequired": {"number": ["cvv"]}}
+		}
+	}`)
+	doc := decodeString(t, `{"card": {"number": "1234"}}`)
+	ve := sch.Validate(doc).(*jsonschema.ValidationError)
+	for len(ve.Causes) > 0 {
+		ve = ve.Causes[0]
+	}
+	present, missing := ve.Details["presentLocation"].(string), ve.Details["missingLocation"].(string)
+	if present != "/card/number" || missing != "/card/cvv" {
+		t.Fatalf("got %q and %q", present, missing)
+	}
+	// sibling of missing property is reachable by relative pointer
+	if v, _, err := jsonschema.EvalRelativePointer(doc, ve.InstanceLocation, "0/number"); err != nil || v != "1234" {
+		t.Fatalf("got %v, %v", v, err)
+	}
+}
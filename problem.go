@@ -0,0 +1,105 @@
+package jsonschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+// ProblemOptions controls the problem document returned by ProblemDetails.
+type ProblemOptions struct {
+	// Type is uri reference identifying the problem type.
+	// Empty means "about:blank".
+	Type string
+
+	// Title is short summary of the problem type. Empty means the
+	// reason phrase of Status, such as "Unprocessable Entity".
+	Title string
+
+	// Status is http status code. Zero means 422.
+	Status int
+
+	// Detail and Instance are included, if not empty.
+	Detail   string
+	Instance string
+
+	// AllBranches includes errors from all branches of failed anyOf/oneOf.
+	// By default, only the branches most likely intended are included, as
+	// in ValidationError.Render.
+	AllBranches bool
+}
+
+// Problem is problem details document, as per RFC 9457, with extension
+// member "errors" listing the validation errors.
+type Problem struct {
+	Type     string         `json:"type"`
+	Title    string         `json:"title"`
+	Status   int            `json:"status"`
+	Detail   string         `json:"detail,omitempty"`
+	Instance string         `json:"instance,omitempty"`
+	Errors   []ProblemError `json:"errors"`
+}
+
+// ProblemError is an entry in Problem.Errors, for a leaf validation error.
+type ProblemError struct {
+	Detail  string `json:"detail"`  // ValidationError.Message
+	Pointer string `json:"pointer"` // ValidationError.InstanceLocation, as uri fragment. for example "#/a~1b"
+	Code    string `json:"code"`    // ValidationError.Keyword
+}
+
+// ProblemDetails returns err as problem details json document of RFC 9457,
+// to be served with media type "application/problem+json".
+//
+// The errors member lists the leaf errors of err in order.
+func ProblemDetails(err *ValidationError, opts ProblemOptions) ([]byte, error) {
+	if err == nil {
+		return nil, errors.New("jsonschema: ProblemDetails of nil error")
+	}
+	p := Problem{
+		Type:     opts.Type,
+		Title:    opts.Title,
+		Status:   opts.Status,
+		Detail:   opts.Detail,
+		Instance: opts.Instance,
+		Errors:   []ProblemError{},
+	}
+	if p.Type == "" {
+		p.Type = "about:blank"
+	}
+	if p.Status == 0 {
+		p.Status = http.StatusUnprocessableEntity
+	}
+	if p.Title == "" {
+		p.Title = http.StatusText(p.Status)
+	}
+
+	var walk func(ve *ValidationError)
+	walk = func(ve *ValidationError) {
+		if len(ve.Causes) == 0 {
+			// InstanceLocation is already escaped for use in uri
+			p.Errors = append(p.Errors, ProblemError{
+				Detail:  ve.message(),
+				Pointer: "#" + ve.InstanceLocation,
+				Code:    ve.Keyword,
+			})
+			return
+		}
+		causes := ve.Causes
+		if (ve.Keyword == "anyOf" || ve.Keyword == "oneOf") && len(causes) > 1 && !opts.AllBranches {
+			causes = intendedBranches(ve)
+		}
+		for _, c := range causes {
+			walk(c)
+		}
+	}
+	walk(err)
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(p); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
@@ -0,0 +1,80 @@
+package jsonschema_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+func TestProblemDetails(t *testing.T) {
+	sch := jsonschema.MustCompileString("http://example.com/order.json", `{
+		"type": "object",
+		"required": ["id", "payment"],
+		"properties": {
+			"id": {"type": "integer"},
+			"a/b": {"type": "string"},
+			"c~d": {"maximum": 10},
+			"e f": {"minLength": 2},
+			"payment": {
+				"oneOf": [
+					{"properties": {"kind": {"const": "card"}, "number": {"pattern": "^[0-9]{16}$"}}},
+					{"properties": {"kind": {"const": "bank"}, "iban": {"minLength": 15}}}
+				]
+			}
+		}
+	}`)
+	doc := `{
+		"id": "x",
+		"a/b": 1,
+		"c~d": 11,
+		"e f": "<",
+		"payment": {"kind": "card", "number": "1234"}
+	}`
+	ve, ok := sch.Validate(decodeString(t, doc)).(*jsonschema.ValidationError)
+	if !ok {
+		t.Fatal("*ValidationError expected")
+	}
+
+	tests := []struct {
+		golden string
+		opts   jsonschema.ProblemOptions
+	}{
+		{"testdata/problem/default.json", jsonschema.ProblemOptions{}},
+		{"testdata/problem/custom.json", jsonschema.ProblemOptions{
+			Type:        "https://example.com/problems/invalid-order",
+			Title:       "Invalid order",
+			Status:      400,
+			Detail:      "order has 5 errors",
+			Instance:    "/orders/1",
+			AllBranches: true,
+		}},
+	}
+	for _, test := range tests {
+		t.Run(test.golden, func(t *testing.T) {
+			b, err := jsonschema.ProblemDetails(ve, test.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got bytes.Buffer
+			if err := json.Indent(&got, b, "", "  "); err != nil {
+				t.Fatal(err)
+			}
+			want, err := ioutil.ReadFile(test.golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.String() != string(bytes.TrimSpace(want)) {
+				t.Fatalf("got:\n%s\nwant:\n%s", got.String(), want)
+			}
+		})
+	}
+}
+
+func TestProblemDetails_nil(t *testing.T) {
+	if _, err := jsonschema.ProblemDetails(nil, jsonschema.ProblemOptions{}); err == nil {
+		t.Fatal("want error")
+	}
+}
@@ -0,0 +1,85 @@
+package jsonschema
+
+import (
+	"fmt"
+	"io"
+)
+
+// CompileEventKind tells the kind of CompileEvent.
+type CompileEventKind int
+
+const (
+	// ResourceLoadStart is emitted before loading a resource using LoadURL.
+	// Resources added with AddResource are not loaded, thus have no load events.
+	ResourceLoadStart CompileEventKind = iota
+
+	// ResourceLoadEnd is emitted after a resource is loaded and parsed.
+	// CompileEvent.Size has the number of bytes read.
+	ResourceLoadEnd
+
+	// ResourceCompiled is emitted once for each resource, when its
+	// root schema is compiled. CompileEvent.Schemas has the number of
+	// schemas from that resource compiled so far.
+	ResourceCompiled
+
+	// RefResolved is emitted after the schema referred by $ref,
+	// $recursiveRef or $dynamicRef is compiled. CompileEvent.From has
+	// the location of referring schema.
+	RefResolved
+)
+
+func (k CompileEventKind) String() string {
+	switch k {
+	case ResourceLoadStart:
+		return "ResourceLoadStart"
+	case ResourceLoadEnd:
+		return "ResourceLoadEnd"
+	case ResourceCompiled:
+		return "ResourceCompiled"
+	case RefResolved:
+		return "RefResolved"
+	}
+	return fmt.Sprintf("CompileEventKind(%d)", int(k))
+}
+
+// CompileEvent reports progress of compilation to Compiler.OnProgress.
+//
+// Events are emitted in the order the work is done. For a resource,
+// ResourceLoadStart and ResourceLoadEnd precede any other event of it.
+// Because referred schemas are compiled depth first, ResourceCompiled
+// and RefResolved of referred resources precede those of the referring
+// resource, unless the references form a cycle.
+type CompileEvent struct {
+	Kind    CompileEventKind
+	URL     string // url of resource, or location of referred schema for RefResolved
+	From    string // location of referring schema, for RefResolved
+	Size    int64  // number of bytes read, for ResourceLoadEnd
+	Schemas int    // number of schemas compiled, for ResourceCompiled
+}
+
+// emit calls c.OnProgress with ev. If OnProgress panics, it is
+// returned as error, so that it does not leave compiler in bad state.
+func (c *Compiler) emit(ev CompileEvent) (err error) {
+	if c.OnProgress == nil {
+		return nil
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("jsonschema: OnProgress panicked on %v of %s: %v", ev.Kind, ev.URL, r)
+		}
+	}()
+	c.OnProgress(ev)
+	return nil
+}
+
+// countingReader counts the number of bytes read.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.n += int64(n)
+	return n, err
+}
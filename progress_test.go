@@ -0,0 +1,102 @@
+package jsonschema_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+var progressResources = map[string]string{
+	"http://example.com/a.json": `{"properties": {"b": {"$ref": "b.json"}, "c": {"$ref": "c.json"}}}`,
+	"http://example.com/b.json": `{"items": {"$ref": "c.json#/$defs/x"}}`,
+	"http://example.com/c.json": `{"$defs": {"x": {"type": "string"}}, "properties": {"c": {"$ref": "#/$defs/x"}}}`,
+}
+
+func progressCompiler() *jsonschema.Compiler {
+	c := jsonschema.NewCompiler()
+	c.LoadURL = func(url string) (io.ReadCloser, error) {
+		s, ok := progressResources[url]
+		if !ok {
+			return nil, fmt.Errorf("%s not found", url)
+		}
+		return ioutil.NopCloser(strings.NewReader(s)), nil
+	}
+	return c
+}
+
+func TestCompiler_OnProgress(t *testing.T) {
+	c := progressCompiler()
+	var got []string
+	c.OnProgress = func(ev jsonschema.CompileEvent) {
+		var s string
+		switch ev.Kind {
+		case jsonschema.ResourceLoadStart:
+			s = fmt.Sprintf("%v %s", ev.Kind, ev.URL)
+		case jsonschema.ResourceLoadEnd:
+			s = fmt.Sprintf("%v %s %d", ev.Kind, ev.URL, ev.Size)
+		case jsonschema.ResourceCompiled:
+			s = fmt.Sprintf("%v %s %d", ev.Kind, ev.URL, ev.Schemas)
+		case jsonschema.RefResolved:
+			s = fmt.Sprintf("%v %s -> %s", ev.Kind, ev.From, ev.URL)
+		}
+		got = append(got, strings.Replace(s, "http://example.com/", "", -1))
+	}
+	if _, err := c.Compile("http://example.com/a.json"); err != nil {
+		t.Fatal(err)
+	}
+	size := func(url string) int {
+		return len(progressResources["http://example.com/"+url])
+	}
+	want := []string{
+		"ResourceLoadStart a.json",
+		fmt.Sprintf("ResourceLoadEnd a.json %d", size("a.json")),
+		"ResourceLoadStart b.json",
+		fmt.Sprintf("ResourceLoadEnd b.json %d", size("b.json")),
+		"ResourceLoadStart c.json",
+		fmt.Sprintf("ResourceLoadEnd c.json %d", size("c.json")),
+		"RefResolved b.json#/items -> c.json#/$defs/x",
+		"ResourceCompiled b.json 2",
+		"RefResolved a.json#/properties/b -> b.json#",
+		"RefResolved c.json#/properties/c -> c.json#/$defs/x",
+		"ResourceCompiled c.json 3",
+		"RefResolved a.json#/properties/c -> c.json#",
+		"ResourceCompiled a.json 3",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestCompiler_OnProgress_panic(t *testing.T) {
+	c := progressCompiler()
+	c.OnProgress = func(ev jsonschema.CompileEvent) {
+		if ev.Kind == jsonschema.ResourceCompiled {
+			panic("boom")
+		}
+	}
+	_, err := c.Compile("http://example.com/a.json")
+	if _, ok := err.(*jsonschema.SchemaError); !ok || !strings.Contains(err.Error(), "boom") {
+		t.Fatalf("got %v, want *SchemaError", err)
+	}
+}
+
+func TestCompiler_CompileContext(t *testing.T) {
+	c := progressCompiler()
+	ctx, cancel := context.WithCancel(context.Background())
+	c.OnProgress = func(ev jsonschema.CompileEvent) {
+		if ev.Kind == jsonschema.ResourceLoadEnd && strings.HasSuffix(ev.URL, "b.json") {
+			cancel()
+		}
+	}
+	_, err := c.CompileContext(ctx, "http://example.com/a.json")
+	if _, ok := err.(*jsonschema.SchemaError); !ok || !errors.Is(err, context.Canceled) {
+		t.Fatalf("got %v, want *SchemaError wrapping context.Canceled", err)
+	}
+}
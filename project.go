@@ -0,0 +1,232 @@
+package jsonschema
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/santhosh-tekuri/jsonschema/v5/keywords"
+)
+
+// ProjectOptions controls the document produced by Schema.ProjectWith.
+type ProjectOptions struct {
+	// Keep tells whether the value at instance location ptr, with
+	// governing schema s, is kept. ptr is escaped json-pointer, as in
+	// ValidationError.InstanceLocation.
+	Keep func(s *Schema, ptr string) bool
+
+	// KeepUnevaluated keeps the values with no governing schema, such as
+	// the properties not declared by the schema, as they are. They are
+	// dropped by default.
+	KeepUnevaluated bool
+}
+
+// Project is ProjectWith, which drops the values with no governing schema.
+//
+// For example, to log a document without its writeOnly values:
+//
+//	doc, err := sch.Project(doc, func(s *jsonschema.Schema, ptr string) bool {
+//		return !s.WriteOnly
+//	})
+func (s *Schema) Project(doc interface{}, keep func(s *Schema, ptr string) bool) (interface{}, error) {
+	return s.ProjectWith(doc, ProjectOptions{Keep: keep})
+}
+
+// ProjectWith returns a copy of doc, with only the values kept by
+// opts.Keep. doc is walked alongside s; a value dropped is dropped
+// with all its nested values. The items of arrays are projected
+// individually, so the items following a dropped item are shifted.
+// Returns nil if doc itself is dropped. doc is not modified.
+//
+// The governing schema of a value is the schema declaring it, such as
+// the schema in properties or items, after following pure references.
+// If that schema has oneOf or anyOf, the branch matched by the value
+// is the governing schema instead.
+//
+// The nested values are looked up in the governing schema, and the
+// other schemas applied to the value in place, such as $ref, allOf,
+// if-then-else and dependentSchemas.
+//
+// Note that annotations such as readOnly and writeOnly are available
+// only if Compiler.ExtractAnnotations was true.
+func (s *Schema) ProjectWith(doc interface{}, opts ProjectOptions) (interface{}, error) {
+	p := &projector{opts}
+	v, ok, err := p.project(s, doc, "")
+	if err != nil || !ok {
+		return nil, err
+	}
+	return v, nil
+}
+
+type projector struct {
+	opts ProjectOptions
+}
+
+// project returns projection of v at ptr, declared by schema s, and
+// whether it is kept.
+func (p *projector) project(s *Schema, v interface{}, ptr string) (interface{}, bool, error) {
+	v, err := native(v)
+	if err != nil {
+		return nil, false, InvalidJSONTypeError(fmt.Sprintf("%v at %s", err, quote(ptr)))
+	}
+	if keywords.TypeOf(v) == "" {
+		return nil, false, InvalidJSONTypeError(fmt.Sprintf("%T at %s", v, quote(ptr)))
+	}
+
+	var schemas []*Schema
+	if s != nil {
+		schemas = appliedSchemas(s, v)
+		if !p.opts.Keep(schemas[0], ptr) {
+			return nil, false, nil
+		}
+	} else if !p.opts.KeepUnevaluated {
+		return nil, false, nil
+	}
+
+	switch v := v.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for pname, pvalue := range v {
+			pv, ok, err := p.project(propertySchema(schemas, pname), pvalue, ptr+"/"+escape(pname))
+			if err != nil {
+				return nil, false, err
+			}
+			if ok {
+				m[pname] = pv
+			}
+		}
+		return m, true, nil
+	case []interface{}:
+		arr := make([]interface{}, 0, len(v))
+		for i, item := range v {
+			iv, ok, err := p.project(itemSchema(schemas, i, item), item, ptr+"/"+strconv.Itoa(i))
+			if err != nil {
+				return nil, false, err
+			}
+			if ok {
+				arr = append(arr, iv)
+			}
+		}
+		return arr, true, nil
+	}
+	return v, true, nil
+}
+
+// appliedSchemas returns the schemas applied to v in place, starting from
+// s. The first schema is the governing schema of v.
+func appliedSchemas(s *Schema, v interface{}) []*Schema {
+	var list []*Schema
+	seen := make(map[*Schema]bool)
+	var add func(s *Schema)
+	add = func(s *Schema) {
+		if s == nil {
+			return
+		}
+		s = s.Resolve()
+		if seen[s] {
+			return
+		}
+		seen[s] = true
+
+		// matched branch is more specific, so it comes first
+		add(matchedBranch(s.OneOf, v))
+		add(matchedBranch(s.AnyOf, v))
+		list = append(list, s)
+
+		if s.If != nil {
+			if s.If.Validate(v) == nil {
+				add(s.Then)
+			} else {
+				add(s.Else)
+			}
+		}
+		for _, sch := range s.AllOf {
+			add(sch)
+		}
+		add(s.Ref)
+		add(s.RecursiveRef)
+		add(s.DynamicRef)
+		if m, ok := v.(map[string]interface{}); ok {
+			for pname, sch := range s.DependentSchemas {
+				if _, ok := m[pname]; ok {
+					add(sch)
+				}
+			}
+		}
+	}
+	add(s)
+	return list
+}
+
+// matchedBranch returns the first schema in branches, which v is valid against.
+func matchedBranch(branches []*Schema, v interface{}) *Schema {
+	for _, sch := range branches {
+		if sch.Validate(v) == nil {
+			return sch
+		}
+	}
+	return nil
+}
+
+// propertySchema returns the schema declaring property pname, in the
+// schemas applied to an object. returns nil, if it is not declared.
+func propertySchema(schemas []*Schema, pname string) *Schema {
+	for _, s := range schemas {
+		if sch, ok := s.Properties[pname]; ok {
+			return sch
+		}
+	}
+	for _, s := range schemas {
+		for pattern, sch := range s.PatternProperties {
+			if pattern.MatchString(pname) {
+				return sch
+			}
+		}
+	}
+	// pname is neither in properties nor in patternProperties of any schema
+	for _, s := range schemas {
+		if sch, ok := s.AdditionalProperties.(*Schema); ok {
+			return sch
+		}
+	}
+	for _, s := range schemas {
+		if s.UnevaluatedProperties != nil {
+			return s.UnevaluatedProperties
+		}
+	}
+	return nil
+}
+
+// itemSchema returns the schema declaring item at index i, in the
+// schemas applied to an array. returns nil, if it is not declared.
+func itemSchema(schemas []*Schema, i int, item interface{}) *Schema {
+	for _, s := range schemas {
+		if i < len(s.PrefixItems) {
+			return s.PrefixItems[i]
+		}
+		if s.Items2020 != nil {
+			return s.Items2020
+		}
+		switch items := s.Items.(type) {
+		case *Schema:
+			return items
+		case []*Schema:
+			if i < len(items) {
+				return items[i]
+			}
+			if sch, ok := s.AdditionalItems.(*Schema); ok {
+				return sch
+			}
+		}
+	}
+	for _, s := range schemas {
+		if s.Contains != nil && s.Contains.Validate(item) == nil {
+			return s.Contains
+		}
+	}
+	for _, s := range schemas {
+		if s.UnevaluatedItems != nil {
+			return s.UnevaluatedItems
+		}
+	}
+	return nil
+}
@@ -0,0 +1,167 @@
+package jsonschema_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+func compileProjectSchema(t *testing.T, schema string) *jsonschema.Schema {
+	t.Helper()
+	c := jsonschema.NewCompiler()
+	c.ExtractAnnotations = true
+	if err := c.AddResource("project.json", strings.NewReader(schema)); err != nil {
+		t.Fatal(err)
+	}
+	sch, err := c.Compile("project.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sch
+}
+
+func notWriteOnly(s *jsonschema.Schema, ptr string) bool {
+	return !s.WriteOnly
+}
+
+func TestSchema_Project(t *testing.T) {
+	sch := compileProjectSchema(t, `{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"$defs": {
+			"secret": {"type": "string", "writeOnly": true}
+		},
+		"properties": {
+			"user": {
+				"properties": {
+					"name": {"type": "string"},
+					"password": {"$ref": "#/$defs/secret"},
+					"address": {
+						"properties": {
+							"city": {"type": "string"}
+						}
+					}
+				}
+			},
+			"tags": {"items": {"type": "string"}},
+			"payment": {
+				"oneOf": [
+					{
+						"properties": {
+							"card": {"type": "string"},
+							"cvv": {"type": "string"}
+						},
+						"required": ["card"],
+						"writeOnly": true
+					},
+					{
+						"properties": {
+							"iban": {"type": "string"}
+						},
+						"required": ["iban"]
+					}
+				]
+			}
+		}
+	}`)
+	doc := decodeString(t, `{
+		"user": {
+			"name": "john",
+			"password": "secret",
+			"address": {"city": "paris", "zip": "75001"},
+			"age": 30
+		},
+		"tags": ["a", "b"],
+		"payment": {"iban": "FR76"},
+		"extra": true
+	}`)
+
+	got, err := sch.Project(doc, notWriteOnly)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := decodeString(t, `{
+		"user": {
+			"name": "john",
+			"address": {"city": "paris"}
+		},
+		"tags": ["a", "b"],
+		"payment": {"iban": "FR76"}
+	}`)
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+
+	// card branch is writeOnly
+	got, err = sch.Project(decodeString(t, `{"payment": {"card": "4111", "cvv": "123"}}`), notWriteOnly)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]interface{}{}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+
+	// doc must not be modified
+	if _, ok := doc.(map[string]interface{})["extra"]; !ok {
+		t.Fatal("doc is modified")
+	}
+}
+
+func TestSchema_ProjectWith_keepUnevaluated(t *testing.T) {
+	sch := compileProjectSchema(t, `{
+		"properties": {
+			"password": {"type": "string", "writeOnly": true}
+		}
+	}`)
+	doc := decodeString(t, `{"password": "secret", "extra": {"a": [1, 2]}}`)
+	got, err := sch.ProjectWith(doc, jsonschema.ProjectOptions{
+		Keep:            notWriteOnly,
+		KeepUnevaluated: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := decodeString(t, `{"extra": {"a": [1, 2]}}`)
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+
+	// the copy is independent of doc
+	got.(map[string]interface{})["extra"].(map[string]interface{})["a"].([]interface{})[0] = "x"
+	if v := doc.(map[string]interface{})["extra"].(map[string]interface{})["a"].([]interface{})[0]; v == "x" {
+		t.Fatal("doc is modified")
+	}
+}
+
+func TestSchema_Project_ptr(t *testing.T) {
+	sch := compileProjectSchema(t, `{
+		"properties": {
+			"items": {
+				"items": {
+					"properties": {
+						"a/b": {"type": "string"}
+					}
+				}
+			}
+		}
+	}`)
+	doc := decodeString(t, `{"items": [{"a/b": "x"}, {"a/b": "y"}]}`)
+	got, err := sch.Project(doc, func(s *jsonschema.Schema, ptr string) bool {
+		return ptr != "/items/0"
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := decodeString(t, `{"items": [{"a/b": "y"}]}`)
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+
+	got, err = sch.Project(doc, func(s *jsonschema.Schema, ptr string) bool {
+		return ptr != ""
+	})
+	if err != nil || got != nil {
+		t.Fatalf("got %v, %v, want nil", got, err)
+	}
+}
@@ -0,0 +1,159 @@
+package jsonschema_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+func TestValidate_RawMessage(t *testing.T) {
+	sch, err := jsonschema.CompileString("schema.json", `{
+		"type": "object",
+		"required": ["kind", "payload"],
+		"minProperties": 2,
+		"properties": {
+			"kind": {"enum": ["a", "b"]},
+			"payload": {
+				"type": "object",
+				"properties": {"x": {"type": "integer"}}
+			},
+			"items": {"maxItems": 2}
+		}
+	}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("lazy", func(t *testing.T) {
+		// "other" is not valid json, but is never decoded
+		env := map[string]json.RawMessage{
+			"kind":    json.RawMessage(`"a"`),
+			"payload": json.RawMessage(`{"x": 1}`),
+			"other":   json.RawMessage(`{not json`),
+		}
+		if err := sch.Validate(env); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("instanceLocation", func(t *testing.T) {
+		env := map[string]json.RawMessage{
+			"kind":    json.RawMessage(`"a"`),
+			"payload": json.RawMessage(`{"x": "one"}`),
+		}
+		err := sch.Validate(env)
+		verr, ok := err.(*jsonschema.ValidationError)
+		if !ok {
+			t.Fatalf("got %v, want *ValidationError", err)
+		}
+		leaf := verr
+		for len(leaf.Causes) > 0 {
+			leaf = leaf.Causes[0]
+		}
+		if leaf.InstanceLocation != "/payload/x" {
+			t.Fatalf("instanceLocation: got %q, want %q", leaf.InstanceLocation, "/payload/x")
+		}
+	})
+
+	t.Run("array", func(t *testing.T) {
+		env := map[string]interface{}{
+			"kind":    "b",
+			"payload": json.RawMessage(`{}`),
+			"items":   []json.RawMessage{json.RawMessage(`1`), json.RawMessage(`{bad`), json.RawMessage(`3`)},
+		}
+		err := sch.Validate(env)
+		if _, ok := err.(*jsonschema.ValidationError); !ok {
+			t.Fatalf("got %v, want *ValidationError", err)
+		}
+		env["items"] = []json.RawMessage{json.RawMessage(`1`), json.RawMessage(`{bad`)}
+		if err := sch.Validate(env); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("plain", func(t *testing.T) {
+		if err := sch.Validate(json.RawMessage(`{"kind": "a", "payload": {}}`)); err != nil {
+			t.Fatal(err)
+		}
+		if err := sch.Validate(json.RawMessage(`{"kind": "c", "payload": {}}`)); err == nil {
+			t.Fatal("want error")
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		env := map[string]json.RawMessage{
+			"kind":    json.RawMessage(`"a"`),
+			"payload": json.RawMessage(`{"x": `),
+		}
+		err := sch.Validate(env)
+		var typeErr jsonschema.InvalidJSONTypeError
+		if !errors.As(err, &typeErr) {
+			t.Fatalf("got %#v, want InvalidJSONTypeError", err)
+		}
+		if !strings.Contains(err.Error(), "/payload") {
+			t.Fatalf("error %q does not mention location", err)
+		}
+	})
+}
+
+func BenchmarkValidate_RawMessage(b *testing.B) {
+	sch, err := jsonschema.CompileString("schema.json", `{
+		"required": ["id"],
+		"properties": {
+			"id": {"type": "string"},
+			"count": {"type": "integer"}
+		}
+	}`)
+	if err != nil {
+		b.Fatal(err)
+	}
+	env := map[string]json.RawMessage{
+		"id":    json.RawMessage(`"abc"`),
+		"count": json.RawMessage(`10`),
+	}
+	for i := 0; i < 18; i++ {
+		env[fmt.Sprintf("field%d", i)] = json.RawMessage(`{"a": [1, 2, 3], "b": {"c": "some string value"}}`)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := sch.Validate(env); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestValidate_RawMessageDecodedOnce(t *testing.T) {
+	allOf := func(n int) *jsonschema.Schema {
+		branches := make([]string, n)
+		for i := range branches {
+			branches[i] = `{"properties": {"payload": {"type": "object"}}}`
+		}
+		return jsonschema.MustCompileString("schema.json", `{"allOf": [`+strings.Join(branches, ",")+`]}`)
+	}
+	payload := `{"a": [1, 2, 3], "b": {"c": "some string value"}}`
+	raw := map[string]json.RawMessage{"payload": json.RawMessage(payload)}
+	decoded := map[string]interface{}{"payload": decodeString(t, payload)}
+	for _, opts := range []jsonschema.ValidateOptions{{}, {Limits: jsonschema.Limits{MaxValues: 100}}} {
+		// decoding cost is the difference in allocations,
+		// between validating raw and decoded value
+		cost := func(sch *jsonschema.Schema) float64 {
+			allocs := func(v interface{}) float64 {
+				return testing.AllocsPerRun(20, func() {
+					if err := sch.ValidateWith(v, opts); err != nil {
+						t.Fatal(err)
+					}
+				})
+			}
+			return allocs(raw) - allocs(decoded)
+		}
+		// allow some noise, as pooled values may be collected during the runs
+		if one, many := cost(allOf(1)), cost(allOf(10)); many > 2*one {
+			t.Errorf("%+v: decoding cost with 10 branches is %v allocations, with 1 branch %v", opts.Limits, many, one)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package jsonschema
+
+// collapseRefs makes $ref of each schema reachable from s, which refers to
+// a chain of pure references, to refer to the end of chain directly. This
+// saves a level of validation and error wrapping for each pure reference
+// skipped. The schemas skipped are recorded in refChain, so that
+// validation with ValidateOptions.Trace still goes through them.
+//
+// The chains which loop are not collapsed.
+func collapseRefs(s *Schema) {
+	seen := make(map[*Schema]struct{})
+	var visit func(s *Schema)
+	visit = func(s *Schema) {
+		if _, ok := seen[s]; ok {
+			return
+		}
+		seen[s] = struct{}{}
+		if s.Ref != nil && s.refChain == nil {
+			s.collapseRef()
+		}
+		s.subschemas(func(sch *Schema, _ bool) {
+			visit(sch)
+		})
+	}
+	visit(s)
+}
+
+// collapseRef collapses the chain of pure references starting at s.Ref.
+func (s *Schema) collapseRef() {
+	var chain []*Schema
+	target := s.Ref
+	for target.isPureRef() {
+		if target == s {
+			return // loop
+		}
+		for _, sch := range chain {
+			if sch == target {
+				return // loop
+			}
+		}
+		chain = append(chain, target)
+		target = target.Ref
+	}
+	if len(chain) > 0 {
+		s.Ref, s.refChain = target, chain
+	}
+}
@@ -0,0 +1,118 @@
+package jsonschema_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// refChainSchema has 6-deep chain of references from #/properties/x.
+const refChainSchema = `{
+	"properties": {
+		"x": {"$ref": "#/$defs/a1"}
+	},
+	"$defs": {
+		"a1": {"$ref": "#/$defs/a2"},
+		"a2": {"$ref": "#/$defs/a3", "description": "annotations do not matter"},
+		"a3": {"$ref": "#/$defs/a4"},
+		"a4": {"$ref": "#/$defs/a5"},
+		"a5": {"$ref": "#/$defs/a6"},
+		"a6": {"type": "string", "maxLength": 3}
+	}
+}`
+
+func TestRefChain(t *testing.T) {
+	sch := jsonschema.MustCompileString("http://example.com/chain.json", refChainSchema)
+	x := sch.Properties["x"]
+	if got, want := x.Ref.Location, "http://example.com/chain.json#/$defs/a6"; got != want {
+		t.Fatalf("Ref: got %s, want %s", got, want)
+	}
+
+	tests := []struct {
+		golden string
+		opts   jsonschema.ValidateOptions
+	}{
+		{"testdata/refchain/default.txt", jsonschema.ValidateOptions{}},
+		{"testdata/refchain/trace.txt", jsonschema.ValidateOptions{Trace: true}},
+	}
+	for _, test := range tests {
+		t.Run(test.golden, func(t *testing.T) {
+			if err := sch.ValidateWith(map[string]interface{}{"x": "abc"}, test.opts); err != nil {
+				t.Fatal(err)
+			}
+			ve, ok := sch.ValidateWith(map[string]interface{}{"x": "abcd"}, test.opts).(*jsonschema.ValidationError)
+			if !ok {
+				t.Fatal("*ValidationError expected")
+			}
+			want, err := ioutil.ReadFile(test.golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := fmt.Sprintf("%#v\n", ve); got != string(want) {
+				t.Fatalf("got:\n%s\nwant:\n%s", got, want)
+			}
+		})
+	}
+
+	t.Run("trace", func(t *testing.T) {
+		ve := sch.ValidateWith(map[string]interface{}{"x": 1}, jsonschema.ValidateOptions{Trace: true}).(*jsonschema.ValidationError)
+		leaf := ve
+		for len(leaf.Causes) > 0 {
+			leaf = leaf.Causes[0]
+		}
+		if want := "/properties/x" + strings.Repeat("/$ref", 6) + "/type"; leaf.KeywordLocation != want {
+			t.Fatalf("KeywordLocation: got %s, want %s", leaf.KeywordLocation, want)
+		}
+		if len(leaf.Trace) != 8 {
+			t.Fatalf("Trace: got %d steps, want 8", len(leaf.Trace))
+		}
+	})
+
+	t.Run("clone", func(t *testing.T) {
+		clone := sch.Clone()
+		ve := clone.ValidateWith(map[string]interface{}{"x": 1}, jsonschema.ValidateOptions{Trace: true}).(*jsonschema.ValidationError)
+		leaf := ve
+		for len(leaf.Causes) > 0 {
+			leaf = leaf.Causes[0]
+		}
+		if want := "/properties/x" + strings.Repeat("/$ref", 6) + "/type"; leaf.KeywordLocation != want {
+			t.Fatalf("KeywordLocation: got %s, want %s", leaf.KeywordLocation, want)
+		}
+	})
+}
+
+func TestRefChain_recursive(t *testing.T) {
+	sch := jsonschema.MustCompileString("tree.json", `{
+		"$ref": "#/$defs/node",
+		"$defs": {
+			"node": {"$ref": "#/$defs/tree"},
+			"tree": {
+				"type": "object",
+				"properties": {
+					"children": {"items": {"$ref": "#/$defs/node"}}
+				}
+			}
+		}
+	}`)
+	if err := sch.Validate(decodeString(t, `{"children": [{"children": []}, {}]}`)); err != nil {
+		t.Fatal(err)
+	}
+	if err := sch.Validate(decodeString(t, `{"children": [{"children": [1]}]}`)); err == nil {
+		t.Fatal("want error")
+	}
+}
+
+func BenchmarkRefChain(b *testing.B) {
+	sch := jsonschema.MustCompileString("http://example.com/chain.json", refChainSchema)
+	doc := map[string]interface{}{"x": "abc"}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := sch.Validate(doc); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
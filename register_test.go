@@ -0,0 +1,120 @@
+package jsonschema_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+func isDecimal(v interface{}) bool {
+	s, ok := v.(string)
+	return !ok || strings.Trim(s, "0123456789.") == ""
+}
+
+func TestCompiler_RegisterFormat(t *testing.T) {
+	c := jsonschema.NewCompiler()
+	if err := c.RegisterFormat("decimal", isDecimal); err != nil {
+		t.Fatal(err)
+	}
+
+	// duplicate
+	never := func(interface{}) bool { return false }
+	if err := c.RegisterFormat("decimal", never); err == nil {
+		t.Fatal("want error for duplicate format")
+	}
+	c.AssertFormat = true
+	if err := c.AddResource("schema.json", strings.NewReader(`{"format": "decimal"}`)); err != nil {
+		t.Fatal(err)
+	}
+	sch := c.MustCompile("schema.json")
+	if err := sch.Validate("1.5"); err != nil {
+		t.Fatalf("first registration must be retained: %v", err)
+	}
+
+	// override
+	c = jsonschema.NewCompiler()
+	c.AssertFormat = true
+	_ = c.RegisterFormat("decimal", isDecimal)
+	if err := c.RegisterFormatWith("decimal", never, jsonschema.RegisterOptions{Override: true}); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.AddResource("schema.json", strings.NewReader(`{"format": "decimal"}`)); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.MustCompile("schema.json").Validate("1.5"); err == nil {
+		t.Fatal("override must replace format")
+	}
+}
+
+func TestCompiler_RegisterFormat_builtin(t *testing.T) {
+	c := jsonschema.NewCompiler()
+	c.AssertFormat = true
+	anyEmail := func(interface{}) bool { return true }
+	if err := c.RegisterFormat("email", anyEmail); err == nil {
+		t.Fatal("want error for built-in format")
+	}
+	if err := c.RegisterFormat("date-time-future", anyEmail); err == nil {
+		t.Fatal("want error for time format")
+	}
+	if err := c.RegisterFormatWith("email", anyEmail, jsonschema.RegisterOptions{Override: true}); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.AddResource("schema.json", strings.NewReader(`{"format": "email"}`)); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.MustCompile("schema.json").Validate("not-an-email"); err != nil {
+		t.Fatalf("override must replace built-in: %v", err)
+	}
+
+	// other compilers are not affected
+	sch, err := jsonschema.CompileString("schema.json", `{"$schema": "http://json-schema.org/draft-07/schema#", "format": "email"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sch.Validate("not-an-email"); err == nil {
+		t.Fatal("built-in format must be used by other compilers")
+	}
+}
+
+func TestCompiler_RegisteredFormats(t *testing.T) {
+	c := jsonschema.NewCompiler()
+	_ = c.RegisterFormat("decimal", isDecimal)
+	names := c.RegisteredFormats()
+	has := func(name string) bool {
+		for _, n := range names {
+			if n == name {
+				return true
+			}
+		}
+		return false
+	}
+	if !has("decimal") || !has("email") || has("date-time-future") {
+		t.Fatalf("got %v", names)
+	}
+	c.EnableTimeFormats = true
+	names = c.RegisteredFormats()
+	if !has("date-time-future") {
+		t.Fatalf("got %v", names)
+	}
+}
+
+func TestCompiler_RegisterExtension_duplicate(t *testing.T) {
+	c := jsonschema.NewCompiler()
+	if err := c.RegisterExtension("powerOf", powerOfMeta, powerOfCompiler{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.RegisterExtension("powerOf", powerOfMeta, powerOfCompiler{}); err == nil {
+		t.Fatal("want error for duplicate extension")
+	}
+	if err := c.RegisterExtensionWith("powerOf", powerOfMeta, powerOfCompiler{}, jsonschema.RegisterOptions{Override: true}); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.RegisterExtension("x-count", nil, powerOfCompiler{}); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := c.RegisteredExtensions(), []string{"powerOf", "x-count"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
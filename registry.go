@@ -0,0 +1,103 @@
+package jsonschema
+
+import (
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// SchemaRegistry holds a consistent set of compiled schemas, which can be
+// replaced atomically by Load. It is safe for concurrent use.
+//
+// Readers see either the old set or the new set, never a mix of both.
+// Use Current, to look up more than one schema from same set.
+type SchemaRegistry struct {
+	// NewCompiler returns the compiler used by Load.
+	// If nil, NewCompiler of this package is used.
+	NewCompiler func() *Compiler
+
+	// OnSwap is called by Load, after the new set is made current.
+	OnSwap func(set *SchemaSet)
+
+	mu         sync.Mutex   // serializes Load
+	generation uint64       // generation of last set loaded
+	current    atomic.Value // *SchemaSet
+}
+
+// SchemaSet is a set of schemas compiled together by SchemaRegistry.Load.
+type SchemaSet struct {
+	// Generation tells the number of sets loaded into registry so far,
+	// including this set. It is used to detect staleness.
+	Generation uint64
+
+	schemas map[string]*Schema
+}
+
+// Schema returns the schema compiled for url. returns nil if url was
+// not loaded in this set.
+func (set *SchemaSet) Schema(url string) *Schema {
+	if set == nil {
+		return nil
+	}
+	return set.schemas[url]
+}
+
+// Load compiles all resources together, and makes them the current set.
+// resources maps url of each resource to its json content.
+//
+// If any resource fails to compile, the current set is left unchanged
+// and the error is returned.
+func (r *SchemaRegistry) Load(resources map[string]string) (*SchemaSet, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var c *Compiler
+	if r.NewCompiler != nil {
+		c = r.NewCompiler()
+	} else {
+		c = NewCompiler()
+	}
+	for url, content := range resources {
+		if err := c.AddResource(url, strings.NewReader(content)); err != nil {
+			return nil, err
+		}
+	}
+	set := &SchemaSet{
+		Generation: r.generation + 1,
+		schemas:    make(map[string]*Schema, len(resources)),
+	}
+	for url := range resources {
+		sch, err := c.Compile(url)
+		if err != nil {
+			return nil, err
+		}
+		set.schemas[url] = sch
+	}
+	r.generation = set.Generation
+	r.current.Store(set)
+	if r.OnSwap != nil {
+		r.OnSwap(set)
+	}
+	return set, nil
+}
+
+// Current returns the current set. returns nil if nothing is loaded yet.
+func (r *SchemaRegistry) Current() *SchemaSet {
+	set, _ := r.current.Load().(*SchemaSet)
+	return set
+}
+
+// Schema returns the schema compiled for url in the current set.
+// returns nil if url is not in current set.
+func (r *SchemaRegistry) Schema(url string) *Schema {
+	return r.Current().Schema(url)
+}
+
+// Generation returns the generation of current set. returns 0 if nothing
+// is loaded yet.
+func (r *SchemaRegistry) Generation() uint64 {
+	if set := r.Current(); set != nil {
+		return set.Generation
+	}
+	return 0
+}
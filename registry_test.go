@@ -0,0 +1,91 @@
+package jsonschema_test
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// registryResources returns resources, where version.json accepts only
+// the given version. Thus validating with a mix of two generations fails.
+func registryResources(version uint64) map[string]string {
+	return map[string]string{
+		"doc.json":     `{"properties": {"version": {"$ref": "version.json"}}, "required": ["version"]}`,
+		"version.json": fmt.Sprintf(`{"const": %d}`, version),
+	}
+}
+
+func TestSchemaRegistry(t *testing.T) {
+	reg := &jsonschema.SchemaRegistry{}
+	if reg.Schema("doc.json") != nil || reg.Generation() != 0 {
+		t.Fatal("registry must be empty")
+	}
+	var swapped []uint64
+	reg.OnSwap = func(set *jsonschema.SchemaSet) {
+		swapped = append(swapped, set.Generation)
+	}
+	for g := uint64(1); g <= 2; g++ {
+		set, err := reg.Load(registryResources(g))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if set.Generation != g || reg.Generation() != g || reg.Current() != set {
+			t.Fatalf("got generation %d, want %d", reg.Generation(), g)
+		}
+		if err := reg.Schema("doc.json").Validate(map[string]interface{}{"version": int(g)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// failed load leaves current set
+	current := reg.Current()
+	_, err := reg.Load(map[string]string{"doc.json": `{"$ref": "missing.json"}`})
+	if err == nil {
+		t.Fatal("error expected")
+	}
+	if reg.Current() != current {
+		t.Fatal("current set must not change on failure")
+	}
+	if len(swapped) != 2 || swapped[0] != 1 || swapped[1] != 2 {
+		t.Fatalf("got swaps %v", swapped)
+	}
+	if reg.Schema("missing.json") != nil {
+		t.Fatal("schema not in set must be nil")
+	}
+}
+
+func TestSchemaRegistry_concurrent(t *testing.T) {
+	reg := &jsonschema.SchemaRegistry{}
+	if _, err := reg.Load(registryResources(1)); err != nil {
+		t.Fatal(err)
+	}
+	var done int32
+	var failures int64
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for atomic.LoadInt32(&done) == 0 {
+				set := reg.Current()
+				doc := map[string]interface{}{"version": int(set.Generation)}
+				if err := set.Schema("doc.json").Validate(doc); err != nil {
+					atomic.AddInt64(&failures, 1)
+				}
+			}
+		}()
+	}
+	for g := uint64(2); g <= 20; g++ {
+		if _, err := reg.Load(registryResources(g)); err != nil {
+			t.Error(err)
+		}
+	}
+	atomic.StoreInt32(&done, 1)
+	wg.Wait()
+	if failures != 0 {
+		t.Fatalf("%d validations saw mixed generations", failures)
+	}
+}
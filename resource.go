@@ -1,14 +1,19 @@
 package jsonschema
 
 import (
+	"bytes"
+	"crypto/sha256"
 	"encoding/json"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"net/url"
 	"path/filepath"
 	"runtime"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 )
 
 type resource struct {
@@ -18,17 +23,33 @@ type resource struct {
 	draft        *Draft
 	subresources map[string]*resource // key is floc. only applicable for root resource
 	schema       *Schema
+
+	// only applicable for root resource, see ResourceInfo
+	hash   string
+	loaded time.Time
+
+	// flocs of schemas embedded in document, added by AddDocument.
+	// nil if the resource itself is a schema.
+	schemaLocs []string
 }
 
 func (r *resource) String() string {
 	return r.url + r.floc
 }
 
-func newResource(url string, r io.Reader) (*resource, error) {
+func newResource(url string, r io.Reader, jsonc bool) (*resource, error) {
 	if strings.IndexByte(url, '#') != -1 {
 		panic(fmt.Sprintf("BUG: newResource(%q)", url))
 	}
-	doc, err := unmarshal(r)
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("jsonschema: invalid json %s: %v", url, err)
+	}
+	decode := unmarshal
+	if jsonc {
+		decode = decodeJSONCResource
+	}
+	doc, err := decode(bytes.NewReader(b))
 	if err != nil {
 		return nil, fmt.Errorf("jsonschema: invalid json %s: %v", url, err)
 	}
@@ -37,9 +58,11 @@ func newResource(url string, r io.Reader) (*resource, error) {
 		return nil, err
 	}
 	return &resource{
-		url:  url,
-		floc: "#",
-		doc:  doc,
+		url:    url,
+		floc:   "#",
+		doc:    doc,
+		hash:   fmt.Sprintf("%x", sha256.Sum256(b)),
+		loaded: time.Now(),
 	}, nil
 }
 
@@ -60,10 +83,11 @@ func (r *resource) fillSubschemas(c *Compiler, res *resource) error {
 	url2floc := make(map[string]string)
 	for _, sr := range r.subresources {
 		if sr.url != "" {
-			if floc, ok := url2floc[sr.url]; ok {
+			key := normalizeURL(sr.url)
+			if floc, ok := url2floc[key]; ok {
 				return fmt.Errorf("jsonschema: %q and %q in %s have same canonical-uri", floc[1:], sr.floc[1:], r.url)
 			}
-			url2floc[sr.url] = sr.floc
+			url2floc[key] = sr.floc
 		}
 	}
 
@@ -79,15 +103,142 @@ func (r *resource) listResources(res *resource) []*resource {
 			result = append(result, sr)
 		}
 	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].floc < result[j].floc
+	})
 	return result
 }
 
+// schemaLocations returns the flocs of schemas in doc, at given
+// json-pointers. A pointer ending with "/*" refers to each member of
+// the object or array at its prefix.
+func schemaLocations(doc interface{}, ptrs []string) ([]string, error) {
+	var locs []string
+	for _, ptr := range ptrs {
+		wildcard := strings.HasSuffix(ptr, "/*")
+		if wildcard {
+			ptr = ptr[:len(ptr)-2]
+		}
+		if (ptr == "" && !wildcard) || (ptr != "" && !strings.HasPrefix(ptr, "/")) {
+			return nil, fmt.Errorf("invalid json-pointer %q", ptr)
+		}
+		var tokens []string
+		if ptr != "" {
+			tokens = strings.Split(ptr[1:], "/")
+		}
+		v, floc := doc, "#"
+		for _, token := range tokens {
+			token = strings.Replace(token, "~1", "/", -1)
+			token = strings.Replace(token, "~0", "~", -1)
+			switch d := v.(type) {
+			case map[string]interface{}:
+				item, ok := d[token]
+				if !ok {
+					return nil, fmt.Errorf("%q not found", ptr)
+				}
+				v = item
+			case []interface{}:
+				index, err := strconv.Atoi(token)
+				if err != nil || index < 0 || index >= len(d) {
+					return nil, fmt.Errorf("%q not found", ptr)
+				}
+				v = d[index]
+			default:
+				return nil, fmt.Errorf("%q not found", ptr)
+			}
+			floc += "/" + escape(token)
+		}
+		if !wildcard {
+			locs = append(locs, floc)
+			continue
+		}
+		switch v := v.(type) {
+		case map[string]interface{}:
+			for key := range v {
+				locs = append(locs, floc+"/"+escape(key))
+			}
+		case []interface{}:
+			for i := range v {
+				locs = append(locs, floc+"/"+strconv.Itoa(i))
+			}
+		default:
+			return nil, fmt.Errorf("%q is neither object nor array", ptr)
+		}
+	}
+	// enclosing schemas first, so that their subschemas are not listed again
+	sort.Strings(locs)
+	return locs, nil
+}
+
+// fillEmbedded fills the schemas embedded in r, and their subschemas,
+// into r.subresources. r must be a document added by AddDocument.
+func (r *resource) fillEmbedded(c *Compiler) error {
+	r.subresources = make(map[string]*resource)
+	for _, floc := range r.schemaLocs {
+		if _, ok := r.subresources[floc]; ok {
+			// listed as subschema of enclosing schema
+			continue
+		}
+		doc, err := r.docAt(floc)
+		if err != nil {
+			return err
+		}
+		if err := r.draft.checkID(floc, doc); err != nil {
+			return err
+		}
+		id, err := r.draft.resolveID(r.baseURL(floc), doc)
+		if err != nil {
+			return err
+		}
+		res := &resource{url: id, floc: floc, doc: doc}
+		r.subresources[floc] = res
+		if err := r.fillSubschemas(c, res); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// docAt returns the value at floc in r.doc.
+func (r *resource) docAt(floc string) (interface{}, error) {
+	doc := r.doc
+	for _, token := range strings.Split(floc[2:], "/") {
+		item, err := unescape(token)
+		if err != nil {
+			return nil, err
+		}
+		switch d := doc.(type) {
+		case map[string]interface{}:
+			doc = d[item]
+		case []interface{}:
+			index, _ := strconv.Atoi(item)
+			doc = d[index]
+		}
+	}
+	return doc, nil
+}
+
+// inSchema tells whether floc is within the schemas embedded in r.
+// It is always true, if r itself is a schema.
+func (r *resource) inSchema(floc string) bool {
+	if r.schemaLocs == nil {
+		return true
+	}
+	for _, loc := range r.schemaLocs {
+		if floc == loc || strings.HasPrefix(floc, loc+"/") {
+			return true
+		}
+	}
+	return false
+}
+
 func (r *resource) findResource(url string) *resource {
-	if r.url == url {
+	url = normalizeURL(url)
+	if normalizeURL(r.url) == url {
 		return r
 	}
 	for _, res := range r.subresources {
-		if res.url == url {
+		if res.url != "" && normalizeURL(res.url) == url {
 			return res
 		}
 	}
@@ -95,24 +246,32 @@ func (r *resource) findResource(url string) *resource {
 }
 
 // resolve fragment f with sr as base
+//
+// returns *FragmentNotFoundError if f could not be resolved,
+// *InvalidFragmentError if f is malformed.
 func (r *resource) resolveFragment(c *Compiler, sr *resource, f string) (*resource, error) {
 	if f == "#" || f == "#/" {
+		if !r.inSchema(sr.floc) {
+			return nil, r.notSchemaError(sr.floc)
+		}
 		return sr, nil
 	}
 
 	// resolve by anchor
 	if !strings.HasPrefix(f, "#/") {
 		// check in given resource
-		for _, anchor := range r.draft.anchors(sr.doc) {
-			if anchor == f[1:] {
-				return sr, nil
+		if r.inSchema(sr.floc) {
+			for _, anchor := range r.draft.anchors(sr.doc) {
+				if anchor == f[1:] {
+					return sr, nil
+				}
 			}
 		}
 
 		// check in subresources that has same base url
 		prefix := sr.floc + "/"
 		for _, res := range r.subresources {
-			if strings.HasPrefix(res.floc, prefix) && r.baseURL(res.floc) == sr.url {
+			if strings.HasPrefix(res.floc, prefix) && normalizeURL(r.baseURL(res.floc)) == normalizeURL(sr.url) {
 				for _, anchor := range r.draft.anchors(res.doc) {
 					if anchor == f[1:] {
 						return res, nil
@@ -120,7 +279,10 @@ func (r *resource) resolveFragment(c *Compiler, sr *resource, f string) (*resour
 				}
 			}
 		}
-		return nil, nil
+		if !isAnchor(f[1:]) {
+			return nil, &InvalidFragmentError{URL: sr.url, Fragment: f, Err: fmt.Errorf("invalid anchor %q", f[1:])}
+		}
+		return nil, &FragmentNotFoundError{URL: sr.url, Fragment: f, Prefix: "#"}
 	}
 
 	// resolve by ptr
@@ -128,34 +290,37 @@ func (r *resource) resolveFragment(c *Compiler, sr *resource, f string) (*resour
 	if res, ok := r.subresources[floc]; ok {
 		return res, nil
 	}
+	if !r.inSchema(floc) {
+		return nil, r.notSchemaError(floc)
+	}
 
 	// non-standrad location
-	doc := r.doc
-	for _, item := range strings.Split(floc[2:], "/") {
-		item = strings.Replace(item, "~1", "/", -1)
-		item = strings.Replace(item, "~0", "~", -1)
-		item, err := url.PathUnescape(item)
+	doc := sr.doc
+	resolved := "#" // deepest prefix of f resolved so far
+	notFound := func() error {
+		return &FragmentNotFoundError{URL: sr.url, Fragment: f, Prefix: resolved}
+	}
+	for _, token := range strings.Split(f[2:], "/") {
+		item, err := unescape(token)
 		if err != nil {
-			return nil, err
+			return nil, &InvalidFragmentError{URL: sr.url, Fragment: f, Err: err}
 		}
 		switch d := doc.(type) {
 		case map[string]interface{}:
 			if _, ok := d[item]; !ok {
-				return nil, nil
+				return nil, notFound()
 			}
 			doc = d[item]
 		case []interface{}:
 			index, err := strconv.Atoi(item)
-			if err != nil {
-				return nil, err
-			}
-			if index < 0 || index >= len(d) {
-				return nil, nil
+			if err != nil || index < 0 || index >= len(d) {
+				return nil, notFound()
 			}
 			doc = d[index]
 		default:
-			return nil, nil
+			return nil, notFound()
 		}
+		resolved += "/" + token
 	}
 
 	id, err := r.draft.resolveID(r.baseURL(floc), doc)
@@ -170,6 +335,37 @@ func (r *resource) resolveFragment(c *Compiler, sr *resource, f string) (*resour
 	return res, nil
 }
 
+// notSchemaError is the error for floc outside the schemas embedded in r.
+func (r *resource) notSchemaError(floc string) error {
+	return fmt.Errorf("jsonschema: %s is not a schema, as it is outside the schema pointers of document", r.url+floc)
+}
+
+// unescape returns the reference token represented by given
+// json-pointer token in uri fragment.
+func unescape(token string) (string, error) {
+	for i := 0; i < len(token); i++ {
+		if token[i] == '~' && (i == len(token)-1 || (token[i+1] != '0' && token[i+1] != '1')) {
+			return "", fmt.Errorf("invalid escape sequence in %q", token)
+		}
+	}
+	token = strings.Replace(token, "~1", "/", -1)
+	token = strings.Replace(token, "~0", "~", -1)
+	return url.PathUnescape(token)
+}
+
+// isAnchor tells whether s is syntactically valid anchor name.
+func isAnchor(s string) bool {
+	for i, ch := range s {
+		switch {
+		case ch >= 'A' && ch <= 'Z', ch >= 'a' && ch <= 'z', ch == '_':
+		case i > 0 && (ch >= '0' && ch <= '9' || ch == '-' || ch == '.' || ch == ':'):
+		default:
+			return false
+		}
+	}
+	return s != ""
+}
+
 func (r *resource) baseURL(floc string) string {
 	for {
 		if sr, ok := r.subresources[floc]; ok {
@@ -236,6 +432,98 @@ func resolveURL(base, ref string) (string, error) {
 	return baseURL.ResolveReference(refURL).String(), nil
 }
 
+// defaultPorts maps url schemes to their default ports.
+var defaultPorts = map[string]string{
+	"http":  "80",
+	"https": "443",
+	"ws":    "80",
+	"wss":   "443",
+	"ftp":   "21",
+}
+
+// normalizeURL returns absolute url s in the normal form of RFC 3986,
+// section 6.2.2, so that equivalent urls refer to same resource. It is
+// used only to compare urls and to load them; the urls displayed, such
+// as in Schema.Location, retain their original form. It
+// lowercases scheme and host, removes default port and dot segments,
+// and uppercases hex digits of percent-encodings. The fragment is not
+// changed. s is returned as is, if it is not absolute url.
+func normalizeURL(s string) string {
+	f := ""
+	if hash := strings.IndexByte(s, '#'); hash != -1 {
+		s, f = s[:hash], s[hash:]
+	}
+	u, err := url.Parse(s)
+	if err != nil || !u.IsAbs() {
+		return s + f
+	}
+	if u.Opaque != "" {
+		// such as urn:example:schema
+		s = strings.ToLower(u.Scheme) + s[len(u.Scheme):]
+	} else {
+		u.Scheme = strings.ToLower(u.Scheme)
+		u.Host = strings.ToLower(u.Host)
+		if port := u.Port(); port != "" && defaultPorts[u.Scheme] == port {
+			u.Host = u.Host[:len(u.Host)-len(port)-1]
+		}
+		u.Path = removeDotSegments(u.Path)
+		u.RawPath = removeDotSegments(u.RawPath)
+		s = u.String()
+	}
+	return upperPercentEncodings(s) + f
+}
+
+// removeDotSegments removes "." and ".." segments from path p,
+// as per RFC 3986, section 5.2.4.
+func removeDotSegments(p string) string {
+	if !strings.Contains(p, ".") {
+		return p
+	}
+	var out []string
+	segments := strings.Split(p, "/")
+	for i, seg := range segments {
+		last := i == len(segments)-1
+		switch seg {
+		case ".":
+			if last {
+				out = append(out, "")
+			}
+		case "..":
+			if len(out) > 1 {
+				out = out[:len(out)-1]
+			}
+			if last {
+				out = append(out, "")
+			}
+		default:
+			out = append(out, seg)
+		}
+	}
+	return strings.Join(out, "/")
+}
+
+// upperPercentEncodings uppercases the hex digits in percent-encodings of s.
+func upperPercentEncodings(s string) string {
+	if strings.IndexByte(s, '%') == -1 {
+		return s
+	}
+	b := []byte(s)
+	for i := 0; i+2 < len(b); i++ {
+		if b[i] == '%' {
+			b[i+1], b[i+2] = upperHex(b[i+1]), upperHex(b[i+2])
+			i += 2
+		}
+	}
+	return string(b)
+}
+
+func upperHex(c byte) byte {
+	if c >= 'a' && c <= 'f' {
+		return c - 'a' + 'A'
+	}
+	return c
+}
+
 func split(uri string) (string, string) {
 	hash := strings.IndexByte(uri, '#')
 	if hash == -1 {
@@ -265,8 +553,8 @@ func unmarshal(r io.Reader) (interface{}, error) {
 	if err := decoder.Decode(&doc); err != nil {
 		return nil, err
 	}
-	if t, _ := decoder.Token(); t != nil {
-		return nil, fmt.Errorf("invalid character %v after top-level value", t)
+	if err := checkEOF(decoder); err != nil {
+		return nil, err
 	}
 	return doc, nil
 }
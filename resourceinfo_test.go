@@ -0,0 +1,82 @@
+package jsonschema_test
+
+import (
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+const addressSchema = `{
+	"$defs": {"zip": {"type": "string"}},
+	"properties": {"zip": {"$ref": "#/$defs/zip"}}
+}`
+
+func TestCompiler_Resources(t *testing.T) {
+	compile := func() (*jsonschema.Compiler, *jsonschema.Schema) {
+		c := jsonschema.NewCompiler()
+		c.LoadURL = func(s string) (io.ReadCloser, error) {
+			return ioutil.NopCloser(strings.NewReader(addressSchema)), nil
+		}
+		if err := c.AddResource("http://example.com/user.json", strings.NewReader(`{"properties": {"address": {"$ref": "address.json"}}}`)); err != nil {
+			t.Fatal(err)
+		}
+		return c, c.MustCompile("http://example.com/user.json")
+	}
+	c1, sch1 := compile()
+	c2, sch2 := compile()
+
+	infos1, infos2 := c1.Resources(), c2.Resources()
+	if len(infos1) != 2 || infos1[0].URL != "http://example.com/address.json" || infos1[1].URL != "http://example.com/user.json" {
+		t.Fatalf("got %+v", infos1)
+	}
+	for i := range infos1 {
+		if infos1[i].Hash != infos2[i].Hash {
+			t.Errorf("%s: hashes differ: %s, %s", infos1[i].URL, infos1[i].Hash, infos2[i].Hash)
+		}
+		if infos1[i].Loaded.IsZero() {
+			t.Errorf("%s: Loaded is zero", infos1[i].URL)
+		}
+	}
+
+	// sha256 of addressSchema
+	const want = "7f11da0ac4b2f35b014f51b215b955045480107af192dc09275d38bb87c5985e"
+	if infos1[0].Hash != want {
+		t.Errorf("hash: got %s, want %s", infos1[0].Hash, want)
+	}
+
+	zip := sch1.Properties["address"].Ref.Properties["zip"]
+	if got := zip.ResourceHash(); got != infos1[0].Hash {
+		t.Errorf("ResourceHash: got %s, want %s", got, infos1[0].Hash)
+	}
+	if got := sch2.ResourceHash(); got != infos1[1].Hash {
+		t.Errorf("ResourceHash: got %s, want %s", got, infos1[1].Hash)
+	}
+}
+
+func TestCompiler_AddResourceInterface(t *testing.T) {
+	hash := func(doc interface{}) string {
+		c := jsonschema.NewCompiler()
+		if err := c.AddResourceInterface("schema.json", doc); err != nil {
+			t.Fatal(err)
+		}
+		sch, err := c.Compile("schema.json")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := sch.Validate("x"); err == nil {
+			t.Fatal("want validation error")
+		}
+		return sch.ResourceHash()
+	}
+	h1 := hash(map[string]interface{}{"type": "integer", "minimum": 1})
+	h2 := hash(map[string]interface{}{"minimum": 1, "type": "integer"})
+	if h1 == "" || h1 != h2 {
+		t.Fatalf("hashes differ: %s, %s", h1, h2)
+	}
+	if h3 := hash(map[string]interface{}{"type": "integer", "minimum": 2}); h3 == h1 {
+		t.Fatal("hashes of different content are same")
+	}
+}
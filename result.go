@@ -0,0 +1,61 @@
+package jsonschema
+
+// Result is the outcome of Schema.Evaluate. In addition to the
+// validation error, it has the annotations collected from the
+// schemas which passed validation.
+type Result struct {
+	// Err is the error returned by ValidateWith. nil if valid.
+	Err error
+
+	contains []containsMatch
+}
+
+// containsMatch is the indexes of array at instance location, which
+// matched the contains keyword at absolute location keyword.
+type containsMatch struct {
+	keyword  string
+	instance string
+	indexes  []int
+}
+
+// Evaluate validates v with given options, like ValidateWith, and
+// returns the result with annotations. Annotations are collected only
+// by Evaluate, so that ValidateWith does not pay for them.
+func (s *Schema) Evaluate(v interface{}, opts ValidateOptions) *Result {
+	r := &Result{}
+	r.Err = s.validateWith(&validation{opts: opts, result: r}, v)
+	return r
+}
+
+// Valid tells whether the value evaluated is valid.
+func (r *Result) Valid() bool {
+	return r.Err == nil
+}
+
+// ContainsMatches returns the indexes of the array items, which matched
+// the contains keyword at absolute location schemaPtr, such as
+// "http://example.com/schema.json#/properties/tags/contains".
+//
+// If the keyword was applied to several arrays, it returns the indexes
+// of the first array evaluated. Use ContainsMatchesAt to select
+// the array. Returns nil, if no item matched, or the schema containing
+// the keyword failed.
+func (r *Result) ContainsMatches(schemaPtr string) []int {
+	for _, m := range r.contains {
+		if m.keyword == schemaPtr {
+			return m.indexes
+		}
+	}
+	return nil
+}
+
+// ContainsMatchesAt is like ContainsMatches, but for the array at
+// instance location instancePtr, such as "/orders/0/lines".
+func (r *Result) ContainsMatchesAt(schemaPtr, instancePtr string) []int {
+	for _, m := range r.contains {
+		if m.keyword == schemaPtr && m.instance == instancePtr {
+			return m.indexes
+		}
+	}
+	return nil
+}
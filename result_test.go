@@ -0,0 +1,77 @@
+package jsonschema_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+func TestSchema_Evaluate_containsMatches(t *testing.T) {
+	sch := jsonschema.MustCompileString("http://example.com/order.json", `{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"properties": {
+			"lines": {"contains": {"required": ["gift"]}},
+			"groups": {"items": {"contains": {"const": "x"}}},
+			"either": {
+				"anyOf": [
+					{"contains": {"type": "string"}, "minContains": 2},
+					{"contains": {"type": "number"}}
+				]
+			}
+		}
+	}`)
+	doc := decodeString(t, `{
+		"lines": [{"gift": true}, {"sku": 1}, {"gift": false}],
+		"groups": [["a", "x"], ["x", "x", "b"]],
+		"either": ["a", 1, 2]
+	}`)
+	r := sch.Evaluate(doc, jsonschema.ValidateOptions{})
+	if !r.Valid() {
+		t.Fatal(r.Err)
+	}
+
+	const base = "http://example.com/order.json#/properties/"
+	tests := []struct {
+		schemaPtr, instancePtr string
+		want                   []int
+	}{
+		{base + "lines/contains", "", []int{0, 2}},
+		{base + "groups/items/contains", "", []int{1}},
+		{base + "groups/items/contains", "/groups/1", []int{0, 1}},
+		{base + "either/anyOf/0/contains", "", nil}, // failed branch
+		{base + "either/anyOf/1/contains", "", []int{1, 2}},
+		{base + "missing/contains", "", nil},
+	}
+	for _, test := range tests {
+		var got []int
+		if test.instancePtr == "" {
+			got = r.ContainsMatches(test.schemaPtr)
+		} else {
+			got = r.ContainsMatchesAt(test.schemaPtr, test.instancePtr)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s %s: got %v, want %v", test.schemaPtr, test.instancePtr, got, test.want)
+		}
+	}
+}
+
+func TestSchema_Evaluate_invalid(t *testing.T) {
+	sch := jsonschema.MustCompileString("http://example.com/schema.json", `{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"properties": {
+			"a": {"contains": {"type": "string"}},
+			"b": {"type": "string"}
+		}
+	}`)
+	r := sch.Evaluate(decodeString(t, `{"a": [1, "x"], "b": 1}`), jsonschema.ValidateOptions{})
+	if r.Valid() {
+		t.Fatal("want invalid")
+	}
+	if _, ok := r.Err.(*jsonschema.ValidationError); !ok {
+		t.Fatalf("got %v, want *ValidationError", r.Err)
+	}
+	if got := r.ContainsMatches("http://example.com/schema.json#/properties/a/contains"); got != nil {
+		t.Fatalf("got %v, want nil from failed schema", got)
+	}
+}
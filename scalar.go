@@ -0,0 +1,276 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// ValidateScalar is like Validate, but optimized for scalar values, i.e.
+// null, bool, string and numbers.
+//
+// It does not allocate, if v is valid against s, and s and its subschemas
+// have no Extensions, format, contentEncoding and contentMediaType assertions.
+// Otherwise, or if the result cannot be decided cheaply, for example a
+// number on the boundary of minimum, it falls back to Validate.
+func (s *Schema) ValidateScalar(v interface{}) error {
+	if scalarType(v) != "" && s.validScalar(v, 0) {
+		return nil
+	}
+	return s.Validate(v)
+}
+
+// AcceptsNull tells whether json null is valid against s. This is
+// useful to decide whether a missing optional document is acceptable.
+func (s *Schema) AcceptsNull() bool {
+	return s.ValidateScalar(nil) == nil
+}
+
+// maxScalarDepth limits the schemas followed by validScalar, to give up on
+// reference loops.
+const maxScalarDepth = 32
+
+// validScalar tells whether scalar v is surely valid against s, with the
+// default ValidateOptions. false means v is either invalid or could not
+// be decided without allocations, such as applicators like not and oneOf,
+// which need the errors of subschemas.
+func (s *Schema) validScalar(v interface{}, depth int) bool {
+	if depth > maxScalarDepth {
+		return false
+	}
+	if s.always != nil {
+		return *s.always
+	}
+	if len(s.Extensions) > 0 || s.RecursiveRef != nil || s.DynamicRef != nil ||
+		s.Not != nil || len(s.OneOf) > 0 || (s.If != nil && (s.Then != nil || s.Else != nil)) {
+		return false
+	}
+
+	if len(s.Types) > 0 {
+		vType := scalarType(v)
+		matched := false
+		for _, t := range s.Types {
+			if t == vType || (t == "integer" && vType == "number" && isScalarInteger(v)) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	if len(s.Constant) > 0 && !equalScalar(v, s.Constant[0]) {
+		return false
+	}
+	if len(s.Enum) > 0 {
+		matched := false
+		for _, item := range s.Enum {
+			if equalScalar(v, item) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	if s.format != nil && s.assertFormat && !s.format(v) {
+		return false
+	}
+	if s.timeFormat != nil && s.assertFormat {
+		return false
+	}
+
+	switch v := v.(type) {
+	case nil, bool:
+	case string:
+		if s.MinLength != -1 || s.MaxLength != -1 {
+			length := utf8.RuneCountInString(v)
+			if s.MinLength != -1 && length < s.MinLength {
+				return false
+			}
+			if s.MaxLength != -1 && length > s.MaxLength {
+				return false
+			}
+		}
+		if s.Pattern != nil && !s.Pattern.MatchString(v) {
+			return false
+		}
+		if (s.decoder != nil || s.mediaType != nil) && s.assertContent {
+			return false
+		}
+	case json.Number, float64, int, int32, int64:
+		if !s.validNumber(v) {
+			return false
+		}
+	default:
+		// not a scalar, or not json value
+		return false
+	}
+
+	if s.Ref != nil && !s.Ref.validScalar(v, depth+1) {
+		return false
+	}
+	for _, sch := range s.AllOf {
+		if !sch.validScalar(v, depth+1) {
+			return false
+		}
+	}
+	if len(s.AnyOf) > 0 {
+		matched := false
+		for _, sch := range s.AnyOf {
+			if sch.validScalar(v, depth+1) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+// validNumber tells whether number v surely satisfies the numeric keywords
+// of s. The comparisons are done in float64; because rounding to float64
+// preserves order, only strict inequality of rounded values is conclusive.
+func (s *Schema) validNumber(v interface{}) bool {
+	if s.Minimum == nil && s.ExclusiveMinimum == nil && s.Maximum == nil &&
+		s.ExclusiveMaximum == nil && s.MultipleOf == nil {
+		return true
+	}
+	f, ok := scalarFloat(v)
+	if !ok {
+		return false
+	}
+	for i, r := range []*big.Rat{s.Minimum, s.ExclusiveMinimum, s.Maximum, s.ExclusiveMaximum} {
+		if r == nil {
+			continue
+		}
+		bound := s.bounds[i]
+		if bound.rat != r {
+			// bound changed after compilation
+			return false
+		}
+		if i < 2 && !(f > bound.f) || i >= 2 && !(f < bound.f) {
+			return false
+		}
+	}
+	if s.MultipleOf != nil {
+		// only integer multiple of integer is decided
+		m := s.MultipleOf
+		if !m.IsInt() || !m.Num().IsInt64() || m.Num().Int64() == 0 {
+			return false
+		}
+		if f != math.Trunc(f) || math.Abs(f) > 1<<53 {
+			return false
+		}
+		if int64(f)%m.Num().Int64() != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// ratFloat is float64 approximation of rat.
+type ratFloat struct {
+	rat *big.Rat
+	f   float64
+}
+
+// cacheBounds computes s.bounds, so that ValidateScalar does not
+// convert them on each validation.
+func (s *Schema) cacheBounds() {
+	for i, r := range []*big.Rat{s.Minimum, s.ExclusiveMinimum, s.Maximum, s.ExclusiveMaximum} {
+		s.bounds[i] = ratFloat{}
+		if r != nil {
+			f, _ := r.Float64()
+			s.bounds[i] = ratFloat{r, f}
+		}
+	}
+}
+
+// scalarType is jsonType for scalar values, without panicking.
+// returns "" if v is not scalar json value, such as NaN.
+func scalarType(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case json.Number:
+		if !isJSONNumber(string(v)) {
+			return ""
+		}
+		return "number"
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return ""
+		}
+		return "number"
+	case int, int32, int64:
+		return "number"
+	case string:
+		return "string"
+	}
+	return ""
+}
+
+// scalarFloat returns number v as float64. returns false if it cannot
+// be converted without allocation.
+func scalarFloat(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case json.Number:
+		if !isJSONNumber(string(v)) {
+			return 0, false
+		}
+		f, err := strconv.ParseFloat(string(v), 64)
+		return f, err == nil
+	case float64:
+		return v, !math.IsNaN(v) && !math.IsInf(v, 0)
+	case int:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	}
+	return 0, false
+}
+
+// isScalarInteger tells whether number v is surely an integer.
+func isScalarInteger(v interface{}) bool {
+	switch v := v.(type) {
+	case json.Number:
+		return isJSONNumber(string(v)) && strings.IndexAny(string(v), ".eE") == -1
+	case float64:
+		return v == math.Trunc(v) && !math.IsInf(v, 0)
+	case int, int32, int64:
+		return true
+	}
+	return false
+}
+
+// equalScalar tells whether scalar v is surely equal to v2.
+func equalScalar(v, v2 interface{}) bool {
+	switch v := v.(type) {
+	case nil:
+		return v2 == nil
+	case bool:
+		b, ok := v2.(bool)
+		return ok && b == v
+	case string:
+		str, ok := v2.(string)
+		return ok && str == v
+	case json.Number:
+		n, ok := v2.(json.Number)
+		return ok && n == v
+	case float64:
+		f, ok := v2.(float64)
+		return ok && f == v
+	}
+	return false
+}
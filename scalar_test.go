@@ -0,0 +1,142 @@
+package jsonschema_test
+
+import (
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+func TestValidateScalar(t *testing.T) {
+	tests := []struct {
+		schema string
+		valid  []interface{}
+		errors []interface{}
+	}{
+		{
+			schema: `{"type": "string", "minLength": 2, "maxLength": 5, "pattern": "^a"}`,
+			valid:  []interface{}{"ab", "abcde"},
+			errors: []interface{}{"a", "abcdef", "bb", json.Number("1"), nil},
+		},
+		{
+			schema: `{"type": "integer", "minimum": 1, "exclusiveMaximum": 100, "multipleOf": 2}`,
+			valid:  []interface{}{json.Number("2"), float64(98), 4, json.Number("2.0")},
+			errors: []interface{}{json.Number("0"), json.Number("100"), json.Number("3"), float64(2.5), "2"},
+		},
+		{
+			schema: `{"minimum": 0.1, "maximum": 0.3}`,
+			valid:  []interface{}{json.Number("0.1"), json.Number("0.2"), json.Number("0.3"), "x"},
+			errors: []interface{}{json.Number("0.09"), json.Number("0.30000000000000000001"), float64(0.31)},
+		},
+		{
+			schema: `{"multipleOf": 0.1}`,
+			valid:  []interface{}{json.Number("0.3")},
+			errors: []interface{}{json.Number("0.33")},
+		},
+		{
+			schema: `{"enum": ["a", 1, null, true]}`,
+			valid:  []interface{}{"a", json.Number("1"), json.Number("1.0"), float64(1), nil, true},
+			errors: []interface{}{"b", false, json.Number("2")},
+		},
+		{
+			schema: `{"$defs": {"s": {"type": "string"}}, "allOf": [{"$ref": "#/$defs/s"}], "anyOf": [{"const": "x"}, {"maxLength": 1}]}`,
+			valid:  []interface{}{"x", "y"},
+			errors: []interface{}{"yy", json.Number("1")},
+		},
+		{
+			schema: `{"oneOf": [{"type": "string"}, {"type": "number"}], "not": {"const": "x"}}`,
+			valid:  []interface{}{"y", json.Number("1")},
+			errors: []interface{}{"x", nil},
+		},
+	}
+	for i, test := range tests {
+		c := jsonschema.NewCompiler()
+		if err := c.AddResource("schema.json", strings.NewReader(test.schema)); err != nil {
+			t.Fatal(err)
+		}
+		sch, err := c.Compile("schema.json")
+		if err != nil {
+			t.Fatalf("#%d: %v", i, err)
+		}
+		for _, v := range test.valid {
+			if err := sch.ValidateScalar(v); err != nil {
+				t.Errorf("#%d: %#v: %v", i, v, err)
+			}
+		}
+		for _, v := range test.errors {
+			err := sch.ValidateScalar(v)
+			if err == nil {
+				t.Errorf("#%d: %#v: error expected", i, v)
+				continue
+			}
+			if want := sch.Validate(v); err.Error() != want.Error() {
+				t.Errorf("#%d: %#v: got %v, want %v", i, v, err, want)
+			}
+		}
+	}
+}
+
+func TestValidateScalar_allocs(t *testing.T) {
+	tests := []struct {
+		schema string
+		value  interface{}
+	}{
+		{`{"type": "string", "minLength": 1, "maxLength": 10, "pattern": "^a"}`, "abc"},
+		{`{"type": "integer", "minimum": 1, "maximum": 100}`, json.Number("42")},
+		{`{"type": "number", "exclusiveMinimum": 0, "multipleOf": 2}`, float64(42)},
+		{`{"enum": ["red", "green", "blue"]}`, "green"},
+		{`{"const": null}`, nil},
+		{`{"$ref": "#/$defs/b", "$defs": {"b": {"type": "boolean"}}}`, true},
+		{`{"allOf": [{"type": "string"}], "anyOf": [{"const": 1}, {"maxLength": 3}]}`, "abc"},
+		{`true`, "abc"},
+	}
+	for _, test := range tests {
+		c := jsonschema.NewCompiler()
+		if err := c.AddResource("schema.json", strings.NewReader(test.schema)); err != nil {
+			t.Fatal(err)
+		}
+		sch, err := c.Compile("schema.json")
+		if err != nil {
+			t.Fatal(err)
+		}
+		allocs := testing.AllocsPerRun(100, func() {
+			if err := sch.ValidateScalar(test.value); err != nil {
+				t.Fatal(err)
+			}
+		})
+		if allocs != 0 {
+			t.Errorf("%s: got %v allocations, want 0", test.schema, allocs)
+		}
+	}
+}
+
+func BenchmarkValidateScalar(b *testing.B) {
+	c := jsonschema.NewCompiler()
+	if err := c.AddResource("schema.json", strings.NewReader(`{"type": "integer", "minimum": 1, "maximum": 100}`)); err != nil {
+		b.Fatal(err)
+	}
+	sch := c.MustCompile("schema.json")
+	v := json.Number("42")
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := sch.ValidateScalar(v); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestValidateScalar_invalidNumbers(t *testing.T) {
+	values := []interface{}{math.NaN(), math.Inf(1), math.Inf(-1), json.Number("abc")}
+	for _, schema := range []string{`{"type": "number"}`, `{}`, `true`} {
+		sch := jsonschema.MustCompileString("schema.json", schema)
+		for _, v := range values {
+			got, want := sch.ValidateScalar(v), sch.Validate(v)
+			if (got == nil) != (want == nil) || (got != nil && got.Error() != want.Error()) {
+				t.Errorf("%s: %#v: got %v, want %v", schema, v, got, want)
+			}
+		}
+	}
+}
@@ -3,14 +3,18 @@ package jsonschema
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"math/big"
 	"net/url"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"text/template"
-	"unicode/utf8"
+	"time"
+
+	"github.com/santhosh-tekuri/jsonschema/v5/keywords"
 )
 
 // A Schema represents compiled version of json-schema.
@@ -19,12 +23,17 @@ type Schema struct {
 	Messages map[string]*template.Template
 
 	dynamicAnchors []*Schema
+	resourceHash   string // hash of resource containing s, see ResourceHash
 
 	// type agnostic validations
 	Format          string
 	format          func(interface{}) bool
+	timeFormat      func(interface{}, time.Time) bool
+	assertFormat    bool  // whether format is asserted, unless overridden by ValidateOptions.AssertFormat
 	Always          *bool // always pass/fail. used when booleans are used as schemas in draft-07.
+	always          *bool // Always, or whether s reduces to always pass/fail, see reduceAlways
 	Ref             *Schema
+	refChain        []*Schema // pure references from original $ref to Ref, collapsed at compile time
 	RecursiveAnchor bool
 	RecursiveRef    *Schema
 	DynamicAnchor   string
@@ -32,7 +41,10 @@ type Schema struct {
 	Types           []string      // allowed types.
 	Constant        []interface{} // first element in slice is constant value. note: slice is used to capture nil constant.
 	Enum            []interface{} // allowed values.
+	narrowedTypes   []string      // see NarrowedTypes
+	typesImplied    bool          // whether value matching const or enum, matches Types too
 	enumError       string        // error message for enum fail. captured here to avoid constructing error message every time.
+	enumLimit       int           // number of values listed in enumError, see Compiler.MaxEnumMessageValues
 	Not             *Schema
 	AllOf           []*Schema
 	AnyOf           []*Schema
@@ -49,8 +61,9 @@ type Schema struct {
 	PropertyNames         *Schema
 	RegexProperties       bool // property names must be valid regex. used only in draft4 as workaround in metaschema.
 	PatternProperties     map[*regexp.Regexp]*Schema
-	AdditionalProperties  interface{}            // nil or bool or *Schema.
-	Dependencies          map[string]interface{} // map value is *Schema or []string.
+	dupPatterns           map[string][]*regexp.Regexp // patterns matching a Properties entry, with same schema as that entry
+	AdditionalProperties  interface{}                 // nil or bool or *Schema.
+	Dependencies          map[string]interface{}      // map value is *Schema or []string.
 	DependentRequired     map[string][]string
 	DependentSchemas      map[string]*Schema
 	UnevaluatedProperties *Schema
@@ -59,6 +72,8 @@ type Schema struct {
 	MinItems         int // -1 if not specified.
 	MaxItems         int // -1 if not specified.
 	UniqueItems      bool
+	UniqueKeys       []string    // json-pointers into items, see Compiler.EnableUniqueKeys
+	keysRequired     bool        // whether items missing any of UniqueKeys are invalid
 	Items            interface{} // nil or *Schema or []*Schema
 	AdditionalItems  interface{} // nil or bool or *Schema.
 	PrefixItems      []*Schema
@@ -73,10 +88,12 @@ type Schema struct {
 	MinLength        int // -1 if not specified.
 	MaxLength        int // -1 if not specified.
 	Pattern          *regexp.Regexp
+	fullMatch        bool // whether Pattern matches whole string, as per Compiler.FullMatchPatterns
 	ContentEncoding  string
 	decoder          func(string) ([]byte, error)
 	ContentMediaType string
 	mediaType        func([]byte) error
+	assertContent    bool // whether content is asserted, unless overridden by ValidateOptions.AssertContent
 
 	// number validators
 	Minimum          *big.Rat
@@ -84,6 +101,7 @@ type Schema struct {
 	Maximum          *big.Rat
 	ExclusiveMaximum *big.Rat
 	MultipleOf       *big.Rat
+	bounds           [4]ratFloat // float64 of Minimum, ExclusiveMinimum, Maximum and ExclusiveMaximum, used by ValidateScalar
 
 	// annotations. captured only when Compiler.ExtractAnnotations is true.
 	Title       string
@@ -95,6 +113,12 @@ type Schema struct {
 	Examples    []interface{}
 	Deprecated  bool
 
+	// Sensitive tells that values validated by this schema or its subschemas
+	// must not appear in validation errors. It is true, if schema has
+	// "x-sensitive": true or "writeOnly": true, or its location is listed
+	// in Compiler.SensitiveLocations.
+	Sensitive bool
+
 	// user defined extensions
 	Extensions map[string]ExtSchema
 }
@@ -103,6 +127,64 @@ func (s *Schema) String() string {
 	return s.Location
 }
 
+// ResourceHash returns the hex encoded sha256 of the raw bytes of the
+// resource containing s, as in ResourceInfo.Hash. It is empty for the
+// schemas not compiled by Compiler.
+func (s *Schema) ResourceHash() string {
+	return s.resourceHash
+}
+
+// Resolve follows the chain of pure references starting from s, and returns
+// the first schema with constraints other than $ref. A pure reference is a
+// schema with only $ref and optionally annotations.
+//
+// returns s if it is not a pure reference. If the chain loops, the first
+// schema repeated in the chain is returned.
+func (s *Schema) Resolve() *Schema {
+	var seen map[*Schema]struct{}
+	for s.isPureRef() {
+		if seen == nil {
+			seen = make(map[*Schema]struct{})
+		}
+		if _, ok := seen[s]; ok {
+			break
+		}
+		seen[s] = struct{}{}
+		s = s.Ref
+	}
+	return s
+}
+
+// isPureRef tells whether s has no constraints other than $ref.
+//
+// schema with dynamic anchors is not pure reference, because
+// skipping it changes the dynamic scope.
+func (s *Schema) isPureRef() bool {
+	return s.Ref != nil && s.Always == nil && len(s.Messages) == 0 && !s.Sensitive &&
+		len(s.dynamicAnchors) == 0 && !s.RecursiveAnchor && s.DynamicAnchor == "" &&
+		s.unconstrained()
+}
+
+// unconstrained tells whether s has no assertions or applicators, other
+// than $ref. Annotations, anchors and custom messages are not considered.
+func (s *Schema) unconstrained() bool {
+	return s.RecursiveRef == nil && s.DynamicRef == nil && s.Format == "" &&
+		len(s.Types) == 0 && len(s.Constant) == 0 && len(s.Enum) == 0 &&
+		s.Not == nil && len(s.AllOf) == 0 && len(s.AnyOf) == 0 && len(s.OneOf) == 0 && s.If == nil &&
+		s.MinProperties == -1 && s.MaxProperties == -1 && len(s.Required) == 0 &&
+		len(s.Properties) == 0 && s.PropertyNames == nil && !s.RegexProperties &&
+		len(s.PatternProperties) == 0 && s.AdditionalProperties == nil &&
+		len(s.Dependencies) == 0 && len(s.DependentRequired) == 0 && len(s.DependentSchemas) == 0 &&
+		s.UnevaluatedProperties == nil &&
+		s.MinItems == -1 && s.MaxItems == -1 && !s.UniqueItems && len(s.UniqueKeys) == 0 &&
+		s.Items == nil && s.AdditionalItems == nil && len(s.PrefixItems) == 0 && s.Items2020 == nil &&
+		s.Contains == nil && s.UnevaluatedItems == nil &&
+		s.MinLength == -1 && s.MaxLength == -1 && s.Pattern == nil &&
+		s.ContentEncoding == "" && s.ContentMediaType == "" &&
+		s.Minimum == nil && s.ExclusiveMinimum == nil && s.Maximum == nil && s.ExclusiveMaximum == nil &&
+		s.MultipleOf == nil && len(s.Extensions) == 0
+}
+
 func newSchema(url, floc string, doc interface{}) *Schema {
 	// fill with default values
 	s := &Schema{
@@ -135,27 +217,294 @@ func newSchema(url, floc string, doc interface{}) *Schema {
 // Validate validates given doc, against the json-schema s.
 //
 // the v must be the raw json value. for number precision
-// unmarshal with json.UseNumber().
+// unmarshal with json.UseNumber(). Objects and arrays can also
+// be ObjectNode and ArrayNode. Values can also be json.RawMessage,
+// map[string]json.RawMessage and []json.RawMessage, which are
+// decoded only where the schema needs them. Leaf values can also be
+// JSONLeaf, time.Time or json.Marshaler; see JSONLeaf.
 //
 // returns *ValidationError if v does not confirm with schema s.
 // returns InfiniteLoopError if it detects loop during validation.
 // returns InvalidJSONTypeError if it detects any non json value in v.
 func (s *Schema) Validate(v interface{}) (err error) {
-	return s.validateValue(v, "")
+	return s.validateValue(&validation{}, v, "")
+}
+
+// ValidateOptions are the options for a single validation.
+type ValidateOptions struct {
+	// Limits are checked on v, before validating against the schema.
+	Limits Limits
+
+	// Trace populates ValidationError.Trace with the evaluation path
+	// of each error. This is expensive, use it only for debugging.
+	Trace bool
+
+	// FailFast stops validation at first error. The error returned
+	// has only the first error found.
+	FailFast bool
+
+	// MaxContainsCauses limits the number of item errors reported under
+	// minContains error. The item errors are grouped by the keyword of
+	// their leaf error, and each group has at most MaxContainsCauses item
+	// errors. Zero means 10 and negative means no limit.
+	MaxContainsCauses int
+
+	// TreatQuotedNumbers validates a string, that is a json number,
+	// as number against the schemas whose type allows number or integer,
+	// but not string. For example "42" is valid against
+	// {"type": "integer", "minimum": 18}. The number is seen only by the
+	// keywords of such schema; its subschemas applied in-place, such as
+	// allOf and $ref, see the string.
+	TreatQuotedNumbers bool
+
+	// AssertFormat, if not nil, overrides whether format keyword is asserted.
+	// By default, it is asserted as per Compiler.AssertFormat and the draft.
+	AssertFormat *bool
+
+	// AssertContent, if not nil, overrides whether contentEncoding and
+	// contentMediaType keywords are asserted. By default, they are asserted
+	// as per Compiler.AssertContent and the draft.
+	AssertContent *bool
+
+	// IgnoreRequired skips required and dependentRequired keywords.
+	// This is useful to validate partial documents, such as patches.
+	IgnoreRequired bool
+
+	// StrictIntegers matches type integer, only with the numbers without
+	// fraction or exponent, such as 10 but not 10.0 or 1e1. This applies
+	// only to json.Number, because float64 does not retain the form.
+	StrictIntegers bool
+
+	// EmptyIsAbsent treats the properties with given kinds of empty values
+	// as missing, in required, dependentRequired and dependencies keywords.
+	// Other keywords still validate such properties. This is not as per
+	// specification, and is meant for producers sending empty values
+	// to mean "not provided".
+	EmptyIsAbsent EmptyKind
+
+	// MaxComparisons limits the number of json values compared by const,
+	// enum and uniqueItems keywords in a validation, to bound the work on
+	// hostile input. Validation fails with *LimitError once exceeded.
+	// Zero means no limit.
+	MaxComparisons int
+
+	// MaxEvaluations limits the number of schemas applied to values in a
+	// validation, to bound the work of applicators such as anyOf over large
+	// arrays. Validation fails with *LimitError once exceeded, which
+	// matches ErrBudgetExceeded with errors.Is. Zero means no limit.
+	MaxEvaluations int
+
+	// LenientTypes reports each non json value in v, as validation
+	// error at its location, and continues validating rest of v.
+	// By default, validation fails with InvalidJSONTypeError.
+	LenientTypes bool
+
+	// Metrics, if not nil, is reported the outcome of validation.
+	// See MetricsSink.
+	Metrics MetricsSink
+
+	// LazyMessages leaves ValidationError.Message of the errors returned
+	// empty, until they are formatted by Error, GoString, Render or the
+	// output formats. This saves the formatting of messages, when only
+	// the validity or structured fields of errors are used. Note that
+	// such errors must not be formatted concurrently, and v must not be
+	// modified until then, as messages may quote values from v.
+	LazyMessages bool
+
+	// UpstreamMessages words the messages of keyword failures as in
+	// upstream github.com/santhosh-tekuri/jsonschema/v5, for migrating
+	// tests and clients matching its messages. Only the messages differ;
+	// keywords, locations, causes and details are same. The keywords with
+	// no upstream message, use native message. See upstreamMessages.
+	UpstreamMessages bool
+
+	// Now returns the current time, used by TimeFormats and available
+	// to extensions as ValidationContext.Now. This is useful to validate
+	// deterministically in tests. Nil means time.Now.
+	Now func() time.Time
+}
+
+// EmptyKind is a set of kinds of empty json values.
+// It is used by ValidateOptions.EmptyIsAbsent.
+type EmptyKind int
+
+const (
+	EmptyString EmptyKind = 1 << iota // ""
+	EmptyObject                       // {}
+	EmptyArray                        // []
+	EmptyNull                         // null
+)
+
+// isEmpty tells whether v is an empty value of kind in k.
+func (k EmptyKind) isEmpty(v interface{}) bool {
+	switch v := v.(type) {
+	case nil:
+		return k&EmptyNull != 0
+	case string:
+		return v == "" && k&EmptyString != 0
+	case map[string]interface{}:
+		return len(v) == 0 && k&EmptyObject != 0
+	case []interface{}:
+		return len(v) == 0 && k&EmptyArray != 0
+	case ObjectNode:
+		return v.Len() == 0 && k&EmptyObject != 0
+	case ArrayNode:
+		return v.Len() == 0 && k&EmptyArray != 0
+	case json.RawMessage:
+		if v, err := native(v); err == nil {
+			return k.isEmpty(v)
+		}
+	}
+	return false
+}
+
+// ValidateWith is like Validate, but with given options.
+//
+// returns *LimitError if v exceeds opts.Limits, opts.MaxComparisons or opts.MaxEvaluations. Note that the limits
+// are checked on already decoded value; use Decoder to enforce them
+// while decoding.
+func (s *Schema) ValidateWith(v interface{}, opts ValidateOptions) (err error) {
+	return s.validateWith(&validation{opts: opts}, v)
+}
+
+// validateWith is ValidateWith, with the options in vd.
+func (s *Schema) validateWith(vd *validation, v interface{}) (err error) {
+	if vd.opts.Metrics != nil {
+		defer s.observe(vd.opts.Metrics, time.Now(), &err)
+	}
+	if !vd.opts.Limits.isZero() {
+		lc := &limitChecker{Limits: vd.opts.Limits, vd: vd}
+		if err := lc.check(v); err != nil {
+			return err
+		}
+	}
+	return s.validateValue(vd, v, "")
+}
+
+// validation holds the state of a single validation.
+type validation struct {
+	opts        ValidateOptions
+	comparisons int // remaining budget of comparisons, see ValidateOptions.MaxComparisons
+	evaluations int // remaining budget of evaluations, see ValidateOptions.MaxEvaluations
+
+	// result collects the annotations, if not nil. see Schema.Evaluate
+	result *Result
+
+	// natives caches native values of json.RawMessage and nodes, by instance location.
+	natives map[string]nativeValue
+
+	// scope is the stack of schemas, kept for reuse by next validateRoot.
+	scope []schemaRef
+}
+
+// budget returns the budget of comparisons for checkEquals.
+func (vd *validation) budget() *int {
+	if vd.opts.MaxComparisons > 0 {
+		return &vd.comparisons
+	}
+	return nil
+}
+
+// acceptsQuotedNumber tells whether string is validated as number
+// against s, with ValidateOptions.TreatQuotedNumbers.
+func (s *Schema) acceptsQuotedNumber() bool {
+	numeric := false
+	for _, t := range s.Types {
+		switch t {
+		case "string":
+			return false
+		case "number", "integer":
+			numeric = true
+		}
+	}
+	return numeric
+}
+
+// present tells whether obj has property pname, for the keywords such
+// as required. See ValidateOptions.EmptyIsAbsent.
+func (vd *validation) present(obj map[string]interface{}, pname string) bool {
+	pvalue, ok := obj[pname]
+	return ok && !vd.opts.EmptyIsAbsent.isEmpty(pvalue)
+}
+
+func (vd *validation) assertFormat(s *Schema) bool {
+	if vd.opts.AssertFormat != nil {
+		return *vd.opts.AssertFormat
+	}
+	return s.assertFormat
+}
+
+func (vd *validation) assertContent(s *Schema) bool {
+	if vd.opts.AssertContent != nil {
+		return *vd.opts.AssertContent
+	}
+	return s.assertContent
+}
+
+// isInteger tells whether v, which is a json number, matches type integer.
+func (vd *validation) isInteger(v interface{}) bool {
+	if n, ok := v.(json.Number); ok && vd.opts.StrictIntegers {
+		return strings.IndexAny(string(n), ".eE") == -1
+	}
+	return toRat(v).IsInt()
+}
+
+// validatedByProperty tells whether validating property pname with the
+// schema of given pattern can be skipped, because it is same schema
+// as that of pname in Properties.
+func (s *Schema) validatedByProperty(pname string, pattern *regexp.Regexp) bool {
+	for _, re := range s.dupPatterns[pname] {
+		if re == pattern {
+			return true
+		}
+	}
+	return false
 }
 
-func (s *Schema) validateValue(v interface{}, vloc string) (err error) {
+// sameSchema tells whether s1 and s2 are same schema, or pure references
+// to same schema. Only one reference is followed, because the schemas
+// referred may be still being compiled.
+func sameSchema(s1, s2 *Schema) bool {
+	if s1.isPureRef() {
+		s1 = s1.Ref
+	}
+	if s2.isPureRef() {
+		s2 = s2.Ref
+	}
+	return s1 == s2
+}
+
+// validateValue validates v with s.Resolve(), so that
+// errors do not have the wrapping of top-level pure references.
+func (s *Schema) validateValue(vd *validation, v interface{}, vloc string) (err error) {
 	defer func() {
 		if r := recover(); r != nil {
-			switch r := r.(type) {
-			case InfiniteLoopError, InvalidJSONTypeError:
-				err = r.(error)
-			default:
-				panic(r)
-			}
+			err = recoverError(r)
 		}
 	}()
-	if _, err := s.validate(nil, 0, "", v, vloc); err != nil {
+	return s.validateRoot(vd, v, vloc)
+}
+
+// recoverError returns the error, with which validation panicked.
+// it re-panics, if r is not a validation error.
+func recoverError(r interface{}) error {
+	switch r := r.(type) {
+	case InfiniteLoopError, InvalidJSONTypeError, *LimitError:
+		return r.(error)
+	default:
+		panic(r)
+	}
+}
+
+// validateRoot is validateValue without recovering from panics.
+func (s *Schema) validateRoot(vd *validation, v interface{}, vloc string) error {
+	vd.comparisons = vd.opts.MaxComparisons
+	vd.evaluations = vd.opts.MaxEvaluations
+	s = s.Resolve()
+	if _, err := s.validate(vd, vd.scope[:0], 0, "", v, vloc); err != nil {
+		if !vd.opts.LazyMessages {
+			err.(*ValidationError).formatMessages()
+		}
 		ve := ValidationError{
 			KeywordLocation:         "",
 			AbsoluteKeywordLocation: s.Location,
@@ -187,14 +536,61 @@ func (s *Schema) formatError(keywordPath string, format string, a ...interface{}
 }
 
 // validate validates given value v with this schema.
-func (s *Schema) validate(scope []schemaRef, vscope int, spath string, v interface{}, vloc string) (result validationResult, err error) {
+func (s *Schema) validate(vd *validation, scope []schemaRef, vscope int, spath string, v interface{}, vloc string) (result validationResult, err error) {
+	if vd.opts.MaxEvaluations > 0 {
+		if vd.evaluations--; vd.evaluations < 0 {
+			panic(&LimitError{"MaxEvaluations", vd.opts.MaxEvaluations, vloc, s.Location})
+		}
+	}
+	if vd.result != nil {
+		// annotations of failed schema are dropped
+		mark := len(vd.result.contains)
+		defer func() {
+			if err != nil {
+				vd.result.contains = vd.result.contains[:mark]
+			}
+		}()
+	}
 	validationError := func(keywordPath string, format string, a ...interface{}) *ValidationError {
-		return &ValidationError{
+		if vd.opts.UpstreamMessages {
+			format, a = upstreamMessage(s, keywordPath, format, a)
+		}
+		if sensitive(scope) {
+			a = redactArgs(v, a)
+		}
+		keyword := keywordPath
+		if i := strings.IndexByte(keyword, '/'); i != -1 {
+			keyword = keyword[:i]
+		} else if keyword == "" && s.Always != nil {
+			keyword = "false"
+		}
+		ve := errorPool.Get().(*ValidationError)
+		*ve = ValidationError{
+			Keyword:                 keyword,
 			KeywordLocation:         keywordLocation(scope, keywordPath),
 			AbsoluteKeywordLocation: joinPtr(s.Location, keywordPath),
 			InstanceLocation:        vloc,
-			Message:                 s.formatError(keywordPath, format, a...),
+			schema:                  s,
+			path:                    keywordPath,
+			format:                  format,
+			args:                    a,
+			pooled:                  true,
+		}
+		if vd.opts.Trace {
+			ve.Trace = newTrace(scope, keywordPath)
+		}
+		return ve
+	}
+
+	// keywordError returns ValidationError for the error of package keywords.
+	keywordError := func(err error) *ValidationError {
+		e, ok := err.(*keywords.Error)
+		if !ok {
+			panic(jsonTypeError(err))
 		}
+		ve := validationError(e.Keyword, e.Format, e.Args...)
+		ve.Details = e.Details
+		return ve
 	}
 
 	sref := schemaRef{spath, s, false}
@@ -202,16 +598,38 @@ func (s *Schema) validate(scope []schemaRef, vscope int, spath string, v interfa
 		panic(err)
 	}
 	scope = append(scope, sref)
+	if cap(scope) > cap(vd.scope) {
+		vd.scope = scope[:0]
+	}
 	vscope++
 
+	v, err = vd.native(v, vloc)
+	if err != nil {
+		panic(InvalidJSONTypeError(fmt.Sprintf("%v at %s", err, quote(vloc))))
+	}
+
+	if s.always != nil {
+		if !*s.always {
+			if s.Always == nil {
+				// reduced from "not": {}
+				return result, validationError("not", "not failed")
+			}
+			return result, validationError("", "not allowed")
+		}
+		return result, nil
+	}
+
 	// populate result
+	count := -1
 	switch v := v.(type) {
 	case map[string]interface{}:
+		count = len(v)
 		result.unevalProps = make(map[string]struct{})
 		for pname := range v {
 			result.unevalProps[pname] = struct{}{}
 		}
 	case []interface{}:
+		count = len(v)
 		result.unevalItems = make(map[int]struct{})
 		for i := range v {
 			result.unevalItems[i] = struct{}{}
@@ -219,16 +637,27 @@ func (s *Schema) validate(scope []schemaRef, vscope int, spath string, v interfa
 	}
 
 	validate := func(sch *Schema, schPath string, v interface{}, vpath string) error {
+		if sch.always != nil && *sch.always {
+			return nil
+		}
 		vloc := vloc
 		if vpath != "" {
 			vloc += "/" + vpath
 		}
-		_, err := sch.validate(scope, 0, schPath, v, vloc)
+		_, err := sch.validate(vd, scope, 0, schPath, v, vloc)
 		return err
 	}
 
+	// orig is v, before conversion of quoted number. it is what the
+	// in-place applicators and extensions see.
+	orig := v
+
 	validateInplace := func(sch *Schema, schPath string) error {
-		vr, err := sch.validate(scope, vscope, schPath, v, vloc)
+		if sch.always != nil && *sch.always {
+			// evaluates nothing, as it has no applicators
+			return nil
+		}
+		vr, err := sch.validate(vd, scope, vscope, schPath, orig, vloc)
 		if err == nil {
 			// update result
 			for pname := range result.unevalProps {
@@ -245,14 +674,77 @@ func (s *Schema) validate(scope []schemaRef, vscope int, spath string, v interfa
 		return err
 	}
 
-	if s.Always != nil {
-		if !*s.Always {
-			return result, validationError("", "not allowed")
+	if vd.opts.LenientTypes {
+		if format, arg, ok := unsupportedValue(v); ok {
+			return result, validationError("", format, arg)
+		}
+	}
+
+	// quoted tells whether v is a string, tried as quoted number.
+	// quotedNumber tells whether the string parsed as json number.
+	quoted, quotedNumber := false, false
+	if vd.opts.TreatQuotedNumbers && s.acceptsQuotedNumber() {
+		if str, ok := v.(string); ok {
+			quoted = true
+			if isJSONNumber(str) {
+				quotedNumber = true
+				v = json.Number(str)
+			}
+		}
+	}
+
+	var errors []error
+
+	// failFast tells whether validation must stop, as an error is already found.
+	failFast := func() bool {
+		return vd.opts.FailFast && len(errors) > 0
+	}
+
+	// equal is equals, which in lenient mode, reports the first non json value
+	// found in v, instead of panicking.
+	invalidReported := false
+	equal := func(v1, v2 interface{}) bool {
+		eq, err := checkEquals(v1, v2, vd.budget())
+		switch {
+		case err == nil:
+		case err == errComparisons:
+			panic(&LimitError{"MaxComparisons", vd.opts.MaxComparisons, vloc, s.Location})
+		case !vd.opts.LenientTypes:
+			panic(err)
+		case !invalidReported:
+			invalidReported = true
+			if ptr, bad, ok := findUnsupported(v); ok {
+				format, arg, _ := unsupportedValue(bad)
+				ve := validationError("", format, arg)
+				ve.InstanceLocation += ptr
+				errors = append(errors, ve)
+			}
+		}
+		return eq
+	}
+	inEnum := func() bool {
+		for _, item := range s.Enum {
+			if equal(v, item) {
+				return true
+			}
+		}
+		return false
+	}
+
+	// if matching const or enum implies matching type, they are checked
+	// first, to skip type check on match. with StrictIntegers, value equal
+	// to an integer may not match type integer.
+	valuesChecked, valuesMatched := false, false
+	if s.typesImplied && !vd.opts.StrictIntegers {
+		valuesChecked = true
+		if len(s.Constant) > 0 {
+			valuesMatched = equal(v, s.Constant[0])
+		} else {
+			valuesMatched = inEnum()
 		}
-		return result, nil
 	}
 
-	if len(s.Types) > 0 {
+	if len(s.Types) > 0 && !valuesMatched {
 		vType := jsonType(v)
 		matched := false
 		for _, t := range s.Types {
@@ -260,22 +752,29 @@ func (s *Schema) validate(scope []schemaRef, vscope int, spath string, v interfa
 				matched = true
 				break
 			} else if t == "integer" && vType == "number" {
-				num, _ := new(big.Rat).SetString(fmt.Sprint(v))
-				if num.IsInt() {
+				if vd.isInteger(v) {
 					matched = true
 					break
 				}
 			}
 		}
 		if !matched {
+			switch {
+			case quotedNumber:
+				vType = "quoted " + vType
+			case quoted:
+				vType += ", which is not a quoted number"
+			}
 			return result, validationError("type", "expected %s, but got %s", strings.Join(s.Types, " or "), vType)
 		}
 	}
 
-	var errors []error
-
 	if len(s.Constant) > 0 {
-		if !equals(v, s.Constant[0]) {
+		matched := valuesMatched
+		if !valuesChecked {
+			matched = equal(v, s.Constant[0])
+		}
+		if !matched {
 			switch jsonType(s.Constant[0]) {
 			case "object", "array":
 				errors = append(errors, validationError("const", "const failed"))
@@ -286,19 +785,20 @@ func (s *Schema) validate(scope []schemaRef, vscope int, spath string, v interfa
 	}
 
 	if len(s.Enum) > 0 {
-		matched := false
-		for _, item := range s.Enum {
-			if equals(v, item) {
-				matched = true
-				break
-			}
+		matched := valuesMatched
+		if !valuesChecked || len(s.Constant) > 0 {
+			matched = inEnum()
 		}
 		if !matched {
-			errors = append(errors, validationError("enum", s.enumError))
+			var hint interface{} = ""
+			if str, ok := v.(string); ok && len(s.Enum) > 1 {
+				hint = enumHint{s.Enum, str}
+			}
+			errors = append(errors, validationError("enum", "%s%v", s.enumError, hint).withDetails("allowed", s.Enum))
 		}
 	}
 
-	if s.format != nil && !s.format(v) {
+	if (s.format != nil || s.timeFormat != nil) && vd.assertFormat(s) && !vd.validFormat(s, v) {
 		var val = v
 		if v, ok := v.(string); ok {
 			val = quote(v)
@@ -306,31 +806,50 @@ func (s *Schema) validate(scope []schemaRef, vscope int, spath string, v interfa
 		errors = append(errors, validationError("format", "%v is not valid %s", val, quote(s.Format)))
 	}
 
+	if failFast() {
+		return result, errors[0]
+	}
+
 	switch v := v.(type) {
 	case map[string]interface{}:
 		if s.MinProperties != -1 && len(v) < s.MinProperties {
-			errors = append(errors, validationError("minProperties", "minimum %d properties allowed, but found %d properties", s.MinProperties, len(v)))
+			errors = append(errors, validationError("minProperties", "minimum %d properties allowed, but found %d properties", s.MinProperties, len(v)).withDetails("limit", s.MinProperties, "count", len(v)))
 		}
 		if s.MaxProperties != -1 && len(v) > s.MaxProperties {
-			errors = append(errors, validationError("maxProperties", "maximum %d properties allowed, but found %d properties", s.MaxProperties, len(v)))
+			errors = append(errors, validationError("maxProperties", "maximum %d properties allowed, but found %d properties", s.MaxProperties, len(v)).withDetails("limit", s.MaxProperties, "count", len(v)))
 		}
-		if len(s.Required) > 0 {
-			var missing []string
+		if len(s.Required) > 0 && !vd.opts.IgnoreRequired {
+			var missing, quoted []string
 			for _, pname := range s.Required {
-				if _, ok := v[pname]; !ok {
-					missing = append(missing, quote(pname))
+				if !vd.present(v, pname) {
+					missing = append(missing, pname)
+					quoted = append(quoted, quote(pname))
 				}
 			}
 			if len(missing) > 0 {
-				errors = append(errors, validationError("required", "missing properties: %s", strings.Join(missing, ", ")))
+				errors = append(errors, validationError("required", "missing properties: %s", strings.Join(quoted, ", ")).withDetails("missing", missing))
+			}
+		}
+
+		// errors of properties and patternProperties, grouped by property name,
+		// so that a property failing against both is reported once
+		var perrors map[string][]error
+		var pnames []string
+		propertyError := func(pname string, err error) {
+			if perrors == nil {
+				perrors = make(map[string][]error)
+			}
+			if _, ok := perrors[pname]; !ok {
+				pnames = append(pnames, pname)
 			}
+			perrors[pname] = append(perrors[pname], err)
 		}
 
 		for pname, sch := range s.Properties {
 			if pvalue, ok := v[pname]; ok {
 				delete(result.unevalProps, pname)
 				if err := validate(sch, "properties/"+escape(pname), pvalue, escape(pname)); err != nil {
-					errors = append(errors, err)
+					propertyError(pname, err)
 				}
 			}
 		}
@@ -354,16 +873,35 @@ func (s *Schema) validate(scope []schemaRef, vscope int, spath string, v interfa
 			for pname, pvalue := range v {
 				if pattern.MatchString(pname) {
 					delete(result.unevalProps, pname)
+					if s.validatedByProperty(pname, pattern) {
+						continue
+					}
 					if err := validate(sch, "patternProperties/"+escape(pattern.String()), pvalue, escape(pname)); err != nil {
-						errors = append(errors, err)
+						propertyError(pname, err)
 					}
 				}
 			}
 		}
+		sort.Strings(pnames)
+		for _, pname := range pnames {
+			perrs := perrors[pname]
+			if len(perrs) == 1 {
+				errors = append(errors, perrs[0])
+				continue
+			}
+			sort.Slice(perrs, func(i, j int) bool {
+				return perrs[i].(*ValidationError).KeywordLocation < perrs[j].(*ValidationError).KeywordLocation
+			})
+			ve := validationError("properties/"+escape(pname), "property %s failed against %d schemas", quote(pname), len(perrs))
+			ve.InstanceLocation += "/" + escape(pname)
+			errors = append(errors, ve.add(perrs...))
+		}
 		if s.AdditionalProperties != nil {
 			if allowed, ok := s.AdditionalProperties.(bool); ok {
 				if !allowed && len(result.unevalProps) > 0 {
-					errors = append(errors, validationError("additionalProperties", "additionalProperties %s not allowed", result.unevalPnames()))
+					pnames := result.unevalNames()
+					errors = append(errors, validationError("additionalProperties", "additionalProperties %s not allowed", quoteAll(pnames)).
+						withDetails("properties", pnames))
 				}
 			} else {
 				schema := s.AdditionalProperties.(*Schema)
@@ -385,19 +923,24 @@ func (s *Schema) validate(scope []schemaRef, vscope int, spath string, v interfa
 						errors = append(errors, err)
 					}
 				case []string:
+					if !vd.present(v, dname) {
+						continue
+					}
 					for i, pname := range dvalue {
-						if _, ok := v[pname]; !ok {
-							errors = append(errors, validationError("dependencies/"+escape(dname)+"/"+strconv.Itoa(i), "property %s is required, if %s property exists", quote(pname), quote(dname)))
+						if !vd.present(v, pname) {
+							errors = append(errors, validationError("dependencies/"+escape(dname)+"/"+strconv.Itoa(i), "property %s is required, if %s property exists", quote(pname), quote(dname)).
+								withDetails("presentLocation", vloc+"/"+escape(dname), "missingLocation", vloc+"/"+escape(pname)))
 						}
 					}
 				}
 			}
 		}
 		for dname, dvalue := range s.DependentRequired {
-			if _, ok := v[dname]; ok {
+			if vd.present(v, dname) && !vd.opts.IgnoreRequired {
 				for i, pname := range dvalue {
-					if _, ok := v[pname]; !ok {
-						errors = append(errors, validationError("dependentRequired/"+escape(dname)+"/"+strconv.Itoa(i), "property %s is required, if %s property exists", quote(pname), quote(dname)))
+					if !vd.present(v, pname) {
+						errors = append(errors, validationError("dependentRequired/"+escape(dname)+"/"+strconv.Itoa(i), "property %s is required, if %s property exists", quote(pname), quote(dname)).
+							withDetails("presentLocation", vloc+"/"+escape(dname), "missingLocation", vloc+"/"+escape(pname)))
 					}
 				}
 			}
@@ -412,16 +955,19 @@ func (s *Schema) validate(scope []schemaRef, vscope int, spath string, v interfa
 
 	case []interface{}:
 		if s.MinItems != -1 && len(v) < s.MinItems {
-			errors = append(errors, validationError("minItems", "minimum %d items required, but found %d items", s.MinItems, len(v)))
+			errors = append(errors, validationError("minItems", "minimum %d items required, but found %d items", s.MinItems, len(v)).withDetails("limit", s.MinItems, "count", len(v)))
 		}
 		if s.MaxItems != -1 && len(v) > s.MaxItems {
-			errors = append(errors, validationError("maxItems", "maximum %d items required, but found %d items", s.MaxItems, len(v)))
+			errors = append(errors, validationError("maxItems", "maximum %d items required, but found %d items", s.MaxItems, len(v)).withDetails("limit", s.MaxItems, "count", len(v)))
+		}
+		if len(s.UniqueKeys) > 0 {
+			errors = append(errors, s.uniqueKeys(vd, v, sensitive(scope), validationError)...)
 		}
 		if s.UniqueItems {
 			for i := 1; i < len(v); i++ {
 				for j := 0; j < i; j++ {
-					if equals(v[i], v[j]) {
-						errors = append(errors, validationError("uniqueItems", "items at index %d and %d are equal", j, i))
+					if equal(v[i], v[j]) {
+						errors = append(errors, validationError("uniqueItems", "items at index %d and %d are equal", j, i).withDetails("indexes", []int{j, i}))
 					}
 				}
 			}
@@ -456,7 +1002,7 @@ func (s *Schema) validate(scope []schemaRef, vscope int, spath string, v interfa
 				if additionalItems {
 					result.unevalItems = nil
 				} else if len(v) > len(items) {
-					errors = append(errors, validationError("additionalItems", "only %d items are allowed, but found %d items", len(items), len(v)))
+					errors = append(errors, validationError("additionalItems", "only %d items are allowed, but found %d items", len(items), len(v)).withDetails("limit", len(items), "count", len(v)))
 				}
 			}
 		}
@@ -481,35 +1027,40 @@ func (s *Schema) validate(scope []schemaRef, vscope int, spath string, v interfa
 		// contains + minContains + maxContains
 		if s.Contains != nil && (s.MinContains != -1 || s.MaxContains != -1) {
 			matched := 0
+			matchedIndexes := []int{}
 			var causes []error
+			var causeIndexes []int
 			for i, item := range v {
 				if err := validate(s.Contains, "contains", item, strconv.Itoa(i)); err != nil {
 					causes = append(causes, err)
+					causeIndexes = append(causeIndexes, i)
 				} else {
 					matched++
+					matchedIndexes = append(matchedIndexes, i)
 					if s.ContainsEval {
 						delete(result.unevalItems, i)
 					}
 				}
 			}
+			if vd.result != nil && matched > 0 {
+				vd.result.contains = append(vd.result.contains, containsMatch{joinPtr(s.Location, "contains"), vloc, matchedIndexes})
+			}
 			if s.MinContains != -1 && matched < s.MinContains {
-				errors = append(errors, validationError("minContains", "valid must be >= %d, but got %d", s.MinContains, matched).add(causes...))
+				errors = append(errors, validationError("minContains", "valid must be >= %d, but got %d", s.MinContains, matched).withDetails("limit", s.MinContains, "matchedCount", matched, "matchedIndexes", matchedIndexes).add(groupContainsCauses(causes, causeIndexes, vd.opts.MaxContainsCauses, validationError)...))
+			} else {
+				for _, cause := range causes {
+					release(cause)
+				}
 			}
 			if s.MaxContains != -1 && matched > s.MaxContains {
-				errors = append(errors, validationError("maxContains", "valid must be <= %d, but got %d", s.MaxContains, matched))
+				errors = append(errors, validationError("maxContains", "valid must be <= %d, but got %d", s.MaxContains, matched).withDetails("limit", s.MaxContains, "matchedCount", matched, "matchedIndexes", matchedIndexes))
 			}
 		}
 
 	case string:
 		// minLength + maxLength
-		if s.MinLength != -1 || s.MaxLength != -1 {
-			length := utf8.RuneCount([]byte(v))
-			if s.MinLength != -1 && length < s.MinLength {
-				errors = append(errors, validationError("minLength", "length must be >= %d, but got %d", s.MinLength, length))
-			}
-			if s.MaxLength != -1 && length > s.MaxLength {
-				errors = append(errors, validationError("maxLength", "length must be <= %d, but got %d", s.MaxLength, length))
-			}
+		if err := keywords.Length(v, s.MinLength, s.MaxLength); err != nil {
+			errors = append(errors, keywordError(err))
 		}
 
 		if s.Pattern != nil && !s.Pattern.MatchString(v) {
@@ -517,7 +1068,7 @@ func (s *Schema) validate(scope []schemaRef, vscope int, spath string, v interfa
 		}
 
 		// contentEncoding + contentMediaType
-		if s.decoder != nil || s.mediaType != nil {
+		if (s.decoder != nil || s.mediaType != nil) && vd.assertContent(s) {
 			decoded := s.ContentEncoding == ""
 			var content []byte
 			if s.decoder != nil {
@@ -543,7 +1094,7 @@ func (s *Schema) validate(scope []schemaRef, vscope int, spath string, v interfa
 		var numVal *big.Rat
 		num := func() *big.Rat {
 			if numVal == nil {
-				numVal, _ = new(big.Rat).SetString(fmt.Sprint(v))
+				numVal = toRat(v)
 			}
 			return numVal
 		}
@@ -564,12 +1115,16 @@ func (s *Schema) validate(scope []schemaRef, vscope int, spath string, v interfa
 			errors = append(errors, validationError("exclusiveMaximum", "must be < %v but found %v", f64(s.ExclusiveMaximum), v))
 		}
 		if s.MultipleOf != nil {
-			if q := new(big.Rat).Quo(num(), s.MultipleOf); !q.IsInt() {
-				errors = append(errors, validationError("multipleOf", "%v not multipleOf %v", v, f64(s.MultipleOf)))
+			if err := keywords.MultipleOf(v, s.MultipleOf); err != nil {
+				errors = append(errors, keywordError(err))
 			}
 		}
 	}
 
+	if failFast() {
+		return result, errors[0]
+	}
+
 	// $ref + $recursiveRef + $dynamicRef
 	validateRef := func(sch *Schema, refPath string) error {
 		if sch != nil {
@@ -583,7 +1138,12 @@ func (s *Schema) validate(scope []schemaRef, vscope int, spath string, v interfa
 		}
 		return nil
 	}
-	if err := validateRef(s.Ref, "$ref"); err != nil {
+	ref := s.Ref
+	if vd.opts.Trace && len(s.refChain) > 0 {
+		// show the pure references collapsed, in KeywordLocation and Trace
+		ref = s.refChain[0]
+	}
+	if err := validateRef(ref, "$ref"); err != nil {
 		errors = append(errors, err)
 	}
 	if s.RecursiveRef != nil {
@@ -623,8 +1183,20 @@ func (s *Schema) validate(scope []schemaRef, vscope int, spath string, v interfa
 		}
 	}
 
-	if s.Not != nil && validateInplace(s.Not, "not") == nil {
-		errors = append(errors, validationError("not", "not failed"))
+	if failFast() {
+		return result, errors[0]
+	}
+
+	if s.Not != nil {
+		if err := validateInplace(s.Not, "not"); err == nil {
+			errors = append(errors, validationError("not", "not failed"))
+		} else {
+			release(err)
+		}
+	}
+
+	if failFast() {
+		return result, errors[0]
 	}
 
 	for i, sch := range s.AllOf {
@@ -634,6 +1206,10 @@ func (s *Schema) validate(scope []schemaRef, vscope int, spath string, v interfa
 		}
 	}
 
+	if failFast() {
+		return result, errors[0]
+	}
+
 	if len(s.AnyOf) > 0 {
 		matched := false
 		var causes []error
@@ -646,9 +1222,17 @@ func (s *Schema) validate(scope []schemaRef, vscope int, spath string, v interfa
 		}
 		if !matched {
 			errors = append(errors, validationError("anyOf", "anyOf failed").add(causes...))
+		} else {
+			for _, cause := range causes {
+				release(cause)
+			}
 		}
 	}
 
+	if failFast() {
+		return result, errors[0]
+	}
+
 	if len(s.OneOf) > 0 {
 		matched := -1
 		var causes []error
@@ -666,9 +1250,17 @@ func (s *Schema) validate(scope []schemaRef, vscope int, spath string, v interfa
 		}
 		if matched == -1 {
 			errors = append(errors, validationError("oneOf", "oneOf failed").add(causes...))
+		} else {
+			for _, cause := range causes {
+				release(cause)
+			}
 		}
 	}
 
+	if failFast() {
+		return result, errors[0]
+	}
+
 	// if + then + else
 	if s.If != nil {
 		err := validateInplace(s.If, "if")
@@ -689,14 +1281,25 @@ func (s *Schema) validate(scope []schemaRef, vscope int, spath string, v interfa
 		}
 		// restore dynamic scope
 		scope[len(scope)-1].discard = false
+		if err != nil {
+			release(err)
+		}
+	}
+
+	if failFast() {
+		return result, errors[0]
 	}
 
 	for _, ext := range s.Extensions {
-		if err := ext.Validate(ValidationContext{result, validate, validateInplace, validationError}, v); err != nil {
+		if err := ext.Validate(ValidationContext{result, count, validate, validateInplace, validationError, vd.now}, orig); err != nil {
 			errors = append(errors, err)
 		}
 	}
 
+	if failFast() {
+		return result, errors[0]
+	}
+
 	// UnevaluatedProperties + UnevaluatedItems
 	switch v := v.(type) {
 	case map[string]interface{}:
@@ -736,75 +1339,242 @@ type validationResult struct {
 	unevalItems map[int]struct{}
 }
 
-func (vr validationResult) unevalPnames() string {
+// unevalNames returns the names of unevaluated properties, sorted.
+func (vr validationResult) unevalNames() []string {
 	pnames := make([]string, 0, len(vr.unevalProps))
 	for pname := range vr.unevalProps {
-		pnames = append(pnames, quote(pname))
+		pnames = append(pnames, pname)
 	}
-	return strings.Join(pnames, ", ")
+	sort.Strings(pnames)
+	return pnames
+}
+
+// quoteAll returns comma separated list of quoted strings.
+func quoteAll(strs []string) string {
+	quoted := make([]string, len(strs))
+	for i, s := range strs {
+		quoted[i] = quote(s)
+	}
+	return strings.Join(quoted, ", ")
 }
 
 // jsonType returns the json type of given value v.
 //
 // It panics if the given value is not valid json value
 func jsonType(v interface{}) string {
+	t, err := checkJSONType(v)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// checkJSONType is jsonType, which returns InvalidJSONTypeError
+// instead of panicking.
+func checkJSONType(v interface{}) (string, error) {
+	if t := keywords.TypeOf(v); t != "" {
+		return t, nil
+	}
 	switch v.(type) {
-	case nil:
-		return "null"
-	case bool:
-		return "boolean"
-	case json.Number, float64, int, int32, int64:
-		return "number"
-	case string:
-		return "string"
-	case []interface{}:
-		return "array"
-	case map[string]interface{}:
-		return "object"
+	case ArrayNode:
+		return "array", nil
+	case ObjectNode:
+		return "object", nil
 	}
-	panic(InvalidJSONTypeError(fmt.Sprintf("%T", v)))
+	nv, err := native(v)
+	if err != nil {
+		return "", InvalidJSONTypeError(err.Error())
+	}
+	if t := keywords.TypeOf(nv); t != "" {
+		return t, nil
+	}
+	return "", InvalidJSONTypeError((&keywords.TypeError{Value: v}).Error())
 }
 
-// equals tells if given two json values are equal or not.
-func equals(v1, v2 interface{}) bool {
-	v1Type := jsonType(v1)
-	if v1Type != jsonType(v2) {
-		return false
+// jsonTypeError converts the errors of package keywords, to the errors
+// of this package.
+func jsonTypeError(err error) error {
+	if e, ok := err.(*keywords.TypeError); ok {
+		return InvalidJSONTypeError(e.Error())
 	}
-	switch v1Type {
-	case "array":
-		arr1, arr2 := v1.([]interface{}), v2.([]interface{})
-		if len(arr1) != len(arr2) {
-			return false
+	if err == keywords.ErrBudget {
+		return errComparisons
+	}
+	return err
+}
+
+// unsupportedValue returns the error message format and its argument,
+// if v is not valid json value. Nested values of v are not checked.
+func unsupportedValue(v interface{}) (format string, arg interface{}, ok bool) {
+	if _, err := checkJSONType(v); err != nil {
+		if _, ok := v.(float64); ok {
+			return "unsupported number %v", v, true
 		}
-		for i := range arr1 {
-			if !equals(arr1[i], arr2[i]) {
-				return false
-			}
+		return "value of unsupported type %s", fmt.Sprintf("%T", v), true
+	}
+	if n, ok := v.(json.Number); ok && !isJSONNumber(string(n)) {
+		return "unsupported number %v", v, true
+	}
+	return "", nil, false
+}
+
+// findUnsupported returns the first non json value found in v,
+// along with its location relative to v.
+func findUnsupported(v interface{}) (ptr string, bad interface{}, ok bool) {
+	if _, _, ok := unsupportedValue(v); ok {
+		return "", v, true
+	}
+	nv, err := native(v)
+	if err != nil {
+		return "", v, true
+	}
+	switch v := nv.(type) {
+	case map[string]interface{}:
+		pnames := make([]string, 0, len(v))
+		for pname := range v {
+			pnames = append(pnames, pname)
 		}
-		return true
-	case "object":
-		obj1, obj2 := v1.(map[string]interface{}), v2.(map[string]interface{})
-		if len(obj1) != len(obj2) {
-			return false
+		sort.Strings(pnames)
+		for _, pname := range pnames {
+			if ptr, bad, ok := findUnsupported(v[pname]); ok {
+				return "/" + escape(pname) + ptr, bad, true
+			}
 		}
-		for k, v1 := range obj1 {
-			if v2, ok := obj2[k]; ok {
-				if !equals(v1, v2) {
-					return false
-				}
-			} else {
-				return false
+	case []interface{}:
+		for i, item := range v {
+			if ptr, bad, ok := findUnsupported(item); ok {
+				return "/" + strconv.Itoa(i) + ptr, bad, true
 			}
 		}
-		return true
-	case "number":
-		num1, _ := new(big.Rat).SetString(fmt.Sprint(v1))
-		num2, _ := new(big.Rat).SetString(fmt.Sprint(v2))
-		return num1.Cmp(num2) == 0
-	default:
-		return v1 == v2
 	}
+	return "", nil, false
+}
+
+// groupContainsCauses groups the errors of items not matching contains
+// schema, by keyword of their leaf error. Each group has at most max
+// item errors as causes; zero max means 10 and negative max means no limit.
+func groupContainsCauses(causes []error, indexes []int, max int, validationError func(keywordPath string, format string, a ...interface{}) *ValidationError) []error {
+	if max == 0 {
+		max = 10
+	}
+	type group struct {
+		leaf    *ValidationError
+		causes  []error
+		indexes []int
+	}
+	var groups []*group
+	byCode := make(map[string]*group)
+	for i, cause := range causes {
+		leaf := cause.(*ValidationError).leaf()
+		g, ok := byCode[leaf.Keyword]
+		if !ok {
+			g = &group{leaf: leaf}
+			byCode[leaf.Keyword] = g
+			groups = append(groups, g)
+		}
+		if max < 0 || len(g.causes) < max {
+			g.causes = append(g.causes, cause)
+		}
+		g.indexes = append(g.indexes, indexes[i])
+	}
+	grouped := make([]error, len(groups))
+	for i, g := range groups {
+		grouped[i] = validationError("contains", "%d items failed: %s", len(g.indexes), g.leaf.message()).
+			withDetails("code", g.leaf.Keyword, "indexes", g.indexes).
+			add(g.causes...)
+	}
+	return grouped
+}
+
+// sensitive tells whether any schema in scope is Sensitive.
+func sensitive(scope []schemaRef) bool {
+	for _, sr := range scope {
+		if sr.schema.Sensitive {
+			return true
+		}
+	}
+	return false
+}
+
+// redactArgs returns the error message arguments a, with the arguments
+// formatting value v replaced by "[redacted]".
+func redactArgs(v interface{}, a []interface{}) []interface{} {
+	var quoted string
+	switch v := v.(type) {
+	case map[string]interface{}, []interface{}:
+		// not used as arguments
+		return a
+	case string:
+		quoted = quote(v)
+	}
+	redacted := make([]interface{}, len(a))
+	for i, arg := range a {
+		if arg == v || (quoted != "" && arg == quoted) {
+			arg = "[redacted]"
+		} else if _, ok := arg.(enumHint); ok {
+			// closest matches reveal the value
+			arg = ""
+		}
+		redacted[i] = arg
+	}
+	return redacted
+}
+
+// toRat converts given json number to *big.Rat.
+//
+// all representations of negative zero, such as "-0", "-0.0"
+// or float64 -0.0, convert to zero. float64 is converted
+// using its shortest decimal representation, so that 0.1
+// converts to 1/10 rather than its binary approximation.
+func toRat(v interface{}) *big.Rat {
+	r, err := parseRat(v)
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+// parseRat is toRat, which returns InvalidJSONTypeError
+// instead of panicking.
+func parseRat(v interface{}) (*big.Rat, error) {
+	r, err := keywords.Rat(v)
+	return r, jsonTypeError(err)
+}
+
+// equals tells if given two json values are equal or not.
+//
+// It panics if any of the given values is not valid json value
+func equals(v1, v2 interface{}) bool {
+	eq, err := checkEquals(v1, v2, nil)
+	if err != nil {
+		panic(err)
+	}
+	return eq
+}
+
+// errComparisons is returned by checkEquals, when budget is exhausted.
+var errComparisons = errors.New("jsonschema: too many comparisons")
+
+// compareNative is keywords.Comparison.Convert, converting values to
+// native types.
+func compareNative(v interface{}) (interface{}, error) {
+	v, err := native(v)
+	if err != nil {
+		return nil, InvalidJSONTypeError(err.Error())
+	}
+	return v, nil
+}
+
+// checkEquals is equals, which returns InvalidJSONTypeError
+// instead of panicking.
+//
+// It uses explicit stack rather than recursion, so that deeply nested
+// values do not exhaust goroutine stack. If budget is not nil, it is
+// decremented for each pair of values compared, and errComparisons is
+// returned once it goes below zero.
+func checkEquals(v1, v2 interface{}, budget *int) (bool, error) {
+	eq, err := keywords.Comparison{Convert: compareNative, Budget: budget}.Equal(v1, v2)
+	return eq, jsonTypeError(err)
 }
 
 // escape converts given token to valid json-pointer token
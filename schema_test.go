@@ -5,16 +5,20 @@ import (
 	"crypto/tls"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"io/ioutil"
+	"math"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"os"
 	"path"
 	"path/filepath"
+	"reflect"
 	"runtime"
 	"strings"
+	"sync"
 	"testing"
 
 	"github.com/santhosh-tekuri/jsonschema/v5"
@@ -372,6 +376,74 @@ func TestInvalidSchema(t *testing.T) {
 	}
 }
 
+func TestSchemaError_ref(t *testing.T) {
+	common := `{"$defs": {"address": {"type": "object"}}, "x-list": [{"type": "string"}]}`
+	loadErr := errors.New("connection refused")
+	newCompiler := func(ref string) *jsonschema.Compiler {
+		c := jsonschema.NewCompiler()
+		c.LoadURL = func(s string) (io.ReadCloser, error) {
+			switch s {
+			case "map:///common.json":
+				return ioutil.NopCloser(strings.NewReader(common)), nil
+			case "map:///schema.json":
+				return ioutil.NopCloser(strings.NewReader(`{"$ref": "` + ref + `"}`)), nil
+			}
+			return nil, loadErr
+		}
+		return c
+	}
+
+	t.Run("resourceLoad", func(t *testing.T) {
+		_, err := newCompiler("missing.json#/$defs/address").Compile("map:///schema.json")
+		if !errors.Is(err, jsonschema.ErrResourceLoad) || errors.Is(err, jsonschema.ErrFragmentNotFound) {
+			t.Fatalf("got %v, want ErrResourceLoad", err)
+		}
+		var lerr *jsonschema.ResourceLoadError
+		if !errors.As(err, &lerr) || lerr.URL != "map:///missing.json" || !errors.Is(err, loadErr) {
+			t.Fatalf("got %#v", err)
+		}
+	})
+
+	notFoundTests := []struct {
+		ref, fragment, prefix string
+	}{
+		{"common.json#/$defs/adress", "#/$defs/adress", "#/$defs"},
+		{"common.json#/$defs/address/properties/x", "#/$defs/address/properties/x", "#/$defs/address"},
+		{"common.json#/x-list/1", "#/x-list/1", "#/x-list"},
+		{"common.json#/x-list/first", "#/x-list/first", "#/x-list"},
+		{"common.json#/defs", "#/defs", "#"},
+		{"common.json#address", "#address", "#"},
+	}
+	for _, test := range notFoundTests {
+		t.Run("fragmentNotFound "+test.ref, func(t *testing.T) {
+			_, err := newCompiler(test.ref).Compile("map:///schema.json")
+			if !errors.Is(err, jsonschema.ErrFragmentNotFound) || errors.Is(err, jsonschema.ErrResourceLoad) {
+				t.Fatalf("got %v, want ErrFragmentNotFound", err)
+			}
+			var ferr *jsonschema.FragmentNotFoundError
+			if !errors.As(err, &ferr) {
+				t.Fatalf("got %#v", err)
+			}
+			if ferr.URL != "map:///common.json" || ferr.Fragment != test.fragment || ferr.Prefix != test.prefix {
+				t.Fatalf("got %#v", ferr)
+			}
+		})
+	}
+
+	for _, ref := range []string{"common.json#/$defs/~2", "common.json#/$defs/a~", "common.json#1address"} {
+		t.Run("invalidFragment "+ref, func(t *testing.T) {
+			_, err := newCompiler(ref).Compile("map:///schema.json")
+			if !errors.Is(err, jsonschema.ErrInvalidFragment) {
+				t.Fatalf("got %v, want ErrInvalidFragment", err)
+			}
+			var ierr *jsonschema.InvalidFragmentError
+			if !errors.As(err, &ierr) || ierr.URL != "map:///common.json" {
+				t.Fatalf("got %#v", err)
+			}
+		})
+	}
+}
+
 func TestCompileURL(t *testing.T) {
 	httpURL, httpsURL, cleanup := runHTTPServers()
 	defer cleanup()
@@ -428,6 +500,367 @@ func TestInvalidJsonTypeError(t *testing.T) {
 	}
 }
 
+func TestInvalidJsonTypeError_float(t *testing.T) {
+	sch := jsonschema.MustCompileString("test.json", `{"minimum": 0}`)
+	for _, v := range []interface{}{math.NaN(), math.Inf(1), math.Inf(-1)} {
+		if _, ok := sch.Validate(v).(jsonschema.InvalidJSONTypeError); !ok {
+			t.Errorf("%v: got %v, want InvalidJSONTypeError", v, sch.Validate(v))
+		}
+	}
+}
+
+func TestValidateWith_lenientTypes(t *testing.T) {
+	sch := jsonschema.MustCompileString("test.json", `{
+		"properties": {
+			"name": {"type": "string"},
+			"created": {"type": "string"},
+			"tags": {"uniqueItems": true},
+			"score": {"minimum": 0}
+		}
+	}`)
+	doc := map[string]interface{}{
+		"name":    json.Number("1"),
+		"created": make(chan int),
+		"tags":    []interface{}{[]interface{}{1}, []interface{}{make(chan int)}},
+		"score":   math.NaN(),
+	}
+
+	// strict by default
+	if _, ok := sch.Validate(doc).(jsonschema.InvalidJSONTypeError); !ok {
+		t.Fatalf("got %v, want InvalidJSONTypeError", sch.Validate(doc))
+	}
+
+	err := sch.ValidateWith(doc, jsonschema.ValidateOptions{LenientTypes: true})
+	ve, ok := err.(*jsonschema.ValidationError)
+	if !ok {
+		t.Fatalf("got %v, want *ValidationError", err)
+	}
+	got := map[string]string{}
+	var leaves func(*jsonschema.ValidationError)
+	leaves = func(ve *jsonschema.ValidationError) {
+		if len(ve.Causes) == 0 {
+			got[ve.InstanceLocation] = ve.Message
+		}
+		for _, cause := range ve.Causes {
+			leaves(cause)
+		}
+	}
+	leaves(ve)
+	want := map[string]string{
+		"/name":     "expected string, but got number",
+		"/created":  "value of unsupported type chan int",
+		"/tags/1/0": "value of unsupported type chan int",
+		"/score":    "unsupported number NaN",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestValidateWith_treatQuotedNumbers(t *testing.T) {
+	tests := []struct {
+		schema string
+		doc    string
+		valid  bool
+		msg    string
+	}{
+		{`{"type": "integer", "minimum": 18}`, `"42"`, true, ""},
+		{`{"type": "integer", "minimum": 18}`, `"17"`, false, "must be >= 18 but found 17"},
+		{`{"type": "integer", "minimum": 18}`, `"abc"`, false, "expected integer, but got string, which is not a quoted number"},
+		{`{"type": "integer", "minimum": 18}`, `"4.5"`, false, "expected integer, but got quoted number"},
+		{`{"type": "integer", "minimum": 18}`, `"1e2"`, true, ""},
+		{`{"type": "integer", "minimum": 18}`, `"+42"`, false, "expected integer, but got string, which is not a quoted number"},
+		{`{"type": "integer", "minimum": 18}`, `"042"`, false, "expected integer, but got string, which is not a quoted number"},
+		{`{"type": "integer", "minimum": 18}`, `" 42"`, false, "expected integer, but got string, which is not a quoted number"},
+		{`{"type": "integer", "minimum": 18}`, `42`, true, ""},
+		{`{"type": "number", "multipleOf": 0.5}`, `"-1.5E0"`, true, ""},
+		{`{"type": "number", "enum": [1, 2]}`, `"2.0"`, true, ""},
+		{`{"type": ["string", "integer"], "minimum": 18}`, `"5"`, true, ""},
+		{`{"type": ["string", "integer"], "minLength": 2}`, `"5"`, false, "length must be >= 2, but got 1"},
+		{`{"minimum": 18}`, `"5"`, true, ""},
+		{`{"properties": {"age": {"type": "integer", "maximum": 100}}}`, `{"age": "42"}`, true, ""},
+		{`{"type": "integer", "allOf": [{"type": "integer", "maximum": 100}]}`, `"142"`, false, "must be <= 100 but found 142"},
+		{`{"type": "integer", "allOf": [{"maximum": 100}]}`, `"142"`, true, ""},
+		{`{"type": "number", "minimum": 5, "allOf": [{"type": "string"}]}`, `"7"`, true, ""},
+		{`{"type": "number", "minimum": 5, "allOf": [{"type": "string"}]}`, `"3"`, false, "must be >= 5 but found 3"},
+		{`{"type": "number", "minimum": 5, "$ref": "#/$defs/s", "$defs": {"s": {"type": "string", "maxLength": 1}}}`, `"17"`, false, "length must be <= 1, but got 2"},
+	}
+	for i, test := range tests {
+		sch := jsonschema.MustCompileString(fmt.Sprintf("test%d.json", i), test.schema)
+		err := sch.ValidateWith(decodeString(t, test.doc), jsonschema.ValidateOptions{TreatQuotedNumbers: true})
+		if test.valid {
+			if err != nil {
+				t.Errorf("%s %s: %v", test.schema, test.doc, err)
+			}
+			continue
+		}
+		ve, ok := err.(*jsonschema.ValidationError)
+		if !ok {
+			t.Errorf("%s %s: got %v, want *ValidationError", test.schema, test.doc, err)
+			continue
+		}
+		for len(ve.Causes) > 0 {
+			ve = ve.Causes[0]
+		}
+		if ve.Message != test.msg {
+			t.Errorf("%s %s: got %q, want %q", test.schema, test.doc, ve.Message, test.msg)
+		}
+	}
+
+	// off by default
+	sch := jsonschema.MustCompileString("test.json", `{"type": "integer"}`)
+	if err := sch.Validate(decodeString(t, `"42"`)); err == nil {
+		t.Error("quoted number must be invalid by default")
+	}
+}
+
+func TestValidateWith_overrides(t *testing.T) {
+	sch := jsonschema.MustCompileString("test.json", `{
+		"required": ["id"],
+		"properties": {
+			"id": {"type": "integer"},
+			"email": {"format": "email"},
+			"data": {"contentEncoding": "base64"}
+		}
+	}`)
+	doc := decodeString(t, `{"email": "not-email", "data": "#", "id": 1.0}`)
+	yes, no := true, false
+	tests := []struct {
+		name  string
+		opts  jsonschema.ValidateOptions
+		doc   interface{}
+		valid bool
+	}{
+		{"default", jsonschema.ValidateOptions{}, decodeString(t, `{"email": "not-email", "data": "#", "id": 1.0}`), true},
+		{"AssertFormat", jsonschema.ValidateOptions{AssertFormat: &yes}, decodeString(t, `{"email": "not-email", "id": 1}`), false},
+		{"AssertContent", jsonschema.ValidateOptions{AssertContent: &yes}, decodeString(t, `{"data": "#", "id": 1}`), false},
+		{"IgnoreRequired", jsonschema.ValidateOptions{IgnoreRequired: true}, decodeString(t, `{}`), true},
+		{"required", jsonschema.ValidateOptions{}, decodeString(t, `{}`), false},
+		{"StrictIntegers", jsonschema.ValidateOptions{StrictIntegers: true}, decodeString(t, `{"id": 1.0}`), false},
+		{"StrictIntegers_exponent", jsonschema.ValidateOptions{StrictIntegers: true}, decodeString(t, `{"id": 1e1}`), false},
+		{"StrictIntegers_valid", jsonschema.ValidateOptions{StrictIntegers: true}, decodeString(t, `{"id": -10}`), true},
+		{"StrictIntegers_float64", jsonschema.ValidateOptions{StrictIntegers: true}, map[string]interface{}{"id": 1.0}, true},
+		{"all", jsonschema.ValidateOptions{AssertFormat: &yes, AssertContent: &yes, StrictIntegers: true}, doc, false},
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		for _, test := range tests {
+			wg.Add(1)
+			go func(name string, opts jsonschema.ValidateOptions, doc interface{}, valid bool) {
+				defer wg.Done()
+				if err := sch.ValidateWith(doc, opts); (err == nil) != valid {
+					t.Errorf("%s: got %v, want valid=%v", name, err, valid)
+				}
+			}(test.name, test.opts, test.doc, test.valid)
+		}
+	}
+	wg.Wait()
+
+	// draft-07 asserts format by default, which can be turned off
+	sch = jsonschema.MustCompileString("test7.json", `{"$schema": "http://json-schema.org/draft-07/schema#", "format": "email"}`)
+	if err := sch.Validate("not-email"); err == nil {
+		t.Error("draft-07 must assert format by default")
+	}
+	if err := sch.ValidateWith("not-email", jsonschema.ValidateOptions{AssertFormat: &no}); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCompiler_AssertContent_draft2020(t *testing.T) {
+	schema := `{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"contentEncoding": "base64",
+		"contentMediaType": "application/json"
+	}`
+	for _, assert := range []bool{false, true} {
+		c := jsonschema.NewCompiler()
+		c.AssertContent = assert
+		if err := c.AddResource("test.json", strings.NewReader(schema)); err != nil {
+			t.Fatal(err)
+		}
+		sch, err := c.Compile("test.json")
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, doc := range []string{"#", "e30K" /* "{}\n" */, "bm90IGpzb24=" /* "not json" */} {
+			valid := !assert || doc == "e30K"
+			if err := sch.Validate(doc); (err == nil) != valid {
+				t.Errorf("AssertContent=%v %q: got %v, want valid=%v", assert, doc, err, valid)
+			}
+		}
+	}
+}
+
+func TestValidateWith_emptyIsAbsent(t *testing.T) {
+	sch := jsonschema.MustCompileString("test.json", `{
+		"required": ["a"],
+		"properties": {"a": {"type": ["string", "object", "array", "null"], "minLength": 1}},
+		"dependentRequired": {"b": ["c"]}
+	}`)
+	all := jsonschema.EmptyString | jsonschema.EmptyObject | jsonschema.EmptyArray | jsonschema.EmptyNull
+	tests := []struct {
+		doc     string
+		empty   jsonschema.EmptyKind
+		missing bool // whether required fails
+	}{
+		{`{"a": ""}`, 0, false},
+		{`{"a": ""}`, jsonschema.EmptyString, true},
+		{`{"a": ""}`, jsonschema.EmptyObject | jsonschema.EmptyArray, false},
+		{`{"a": {}}`, jsonschema.EmptyObject, true},
+		{`{"a": {}}`, jsonschema.EmptyString, false},
+		{`{"a": []}`, jsonschema.EmptyArray, true},
+		{`{"a": []}`, jsonschema.EmptyObject | jsonschema.EmptyNull, false},
+		{`{"a": null}`, jsonschema.EmptyNull, true},
+		{`{"a": null}`, all &^ jsonschema.EmptyNull, false},
+		{`{"a": " "}`, all, false},
+		{`{"a": [null]}`, all, false},
+		{`{"a": {"b": ""}}`, all, false},
+	}
+	for _, test := range tests {
+		err := sch.ValidateWith(decodeString(t, test.doc), jsonschema.ValidateOptions{EmptyIsAbsent: test.empty})
+		missing := false
+		if ve, ok := err.(*jsonschema.ValidationError); ok {
+			for _, cause := range ve.Causes {
+				if cause.Keyword == "required" {
+					missing = true
+					if cause.Message != "missing properties: 'a'" {
+						t.Errorf("%s: got %q", test.doc, cause.Message)
+					}
+				}
+			}
+		}
+		if missing != test.missing {
+			t.Errorf("%s with %b: got missing=%v, want %v", test.doc, test.empty, missing, test.missing)
+		}
+	}
+
+	// other keywords still validate empty value
+	err := sch.ValidateWith(decodeString(t, `{"a": ""}`), jsonschema.ValidateOptions{EmptyIsAbsent: all})
+	var keywords []string
+	for _, cause := range err.(*jsonschema.ValidationError).Causes {
+		keywords = append(keywords, cause.Keyword)
+	}
+	if !reflect.DeepEqual(keywords, []string{"required", "minLength"}) {
+		t.Errorf("got %#v", err)
+	}
+
+	// dependentRequired
+	for _, test := range []struct {
+		doc   string
+		valid bool
+	}{
+		{`{"a": "x", "b": 1, "c": ""}`, false},
+		{`{"a": "x", "b": "", "c": ""}`, true},
+		{`{"a": "x", "b": 1, "c": 0}`, true},
+	} {
+		err := sch.ValidateWith(decodeString(t, test.doc), jsonschema.ValidateOptions{EmptyIsAbsent: jsonschema.EmptyString})
+		if (err == nil) != test.valid {
+			t.Errorf("%s: got %v, want valid=%v", test.doc, err, test.valid)
+		}
+	}
+}
+
+// nest returns v nested in depth arrays.
+func nest(v interface{}, depth int) interface{} {
+	for i := 0; i < depth; i++ {
+		v = []interface{}{v}
+	}
+	return v
+}
+
+func TestEquals_deep(t *testing.T) {
+	const depth = 100000
+	deep := func() interface{} {
+		return nest(json.Number("1"), depth)
+	}
+	constSch := jsonschema.MustCompileString("const.json", `{"const": 0}`)
+	constSch.Constant = []interface{}{deep()}
+	enumSch := jsonschema.MustCompileString("enum.json", `{"enum": [0]}`)
+	enumSch.SetEnum([]interface{}{"x", deep()})
+	uniqueSch := jsonschema.MustCompileString("unique.json", `{"uniqueItems": true}`)
+
+	tests := []struct {
+		name  string
+		sch   *jsonschema.Schema
+		doc   interface{}
+		valid bool
+	}{
+		{"const", constSch, deep(), true},
+		{"const_mismatch", constSch, nest(json.Number("2"), depth), false},
+		{"const_shallower", constSch, nest(json.Number("1"), depth-1), false},
+		{"enum", enumSch, deep(), true},
+		{"enum_mismatch", enumSch, nest("1", depth), false},
+		{"uniqueItems", uniqueSch, []interface{}{deep(), deep()}, false},
+		{"uniqueItems_unique", uniqueSch, []interface{}{deep(), nest(1.5, depth)}, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := test.sch.Validate(test.doc); (err == nil) != test.valid {
+				t.Fatalf("got %v, want valid=%v", err, test.valid)
+			}
+		})
+	}
+
+	// budget
+	opts := jsonschema.ValidateOptions{MaxComparisons: depth}
+	err := uniqueSch.ValidateWith([]interface{}{deep(), deep()}, opts)
+	if le, ok := err.(*jsonschema.LimitError); !ok || le.Limit != "MaxComparisons" || le.InstanceLocation != "" {
+		t.Fatalf("got %v, want *LimitError", err)
+	}
+	opts.MaxComparisons = depth + 1
+	if _, ok := uniqueSch.ValidateWith([]interface{}{deep(), deep()}, opts).(*jsonschema.ValidationError); !ok {
+		t.Fatal("*ValidationError expected within budget")
+	}
+}
+
+func TestNegativeZero(t *testing.T) {
+	zeros := []interface{}{
+		json.Number("0"),
+		json.Number("-0"),
+		json.Number("-0.0"),
+		json.Number("-0e10"),
+		0.0,
+		math.Copysign(0, -1),
+		0,
+	}
+	tests := []struct {
+		schema string
+		valid  bool
+	}{
+		{`{"type": "integer"}`, true},
+		{`{"minimum": 0}`, true},
+		{`{"maximum": 0}`, true},
+		{`{"exclusiveMinimum": 0}`, false},
+		{`{"exclusiveMaximum": 0}`, false},
+		{`{"multipleOf": 0.5}`, true},
+		{`{"const": 0}`, true},
+		{`{"const": -0.0}`, true},
+		{`{"enum": [0]}`, true},
+		{`{"enum": [1, -0]}`, true},
+		{`{"not": {"const": 0}}`, false},
+	}
+	for i, test := range tests {
+		sch := jsonschema.MustCompileString(fmt.Sprintf("schema%d.json", i), test.schema)
+		for _, zero := range zeros {
+			if got := sch.Validate(zero) == nil; got != test.valid {
+				t.Errorf("%s with %#v: valid got %v, want %v", test.schema, zero, got, test.valid)
+			}
+		}
+	}
+
+	sch := jsonschema.MustCompileString("unique.json", `{"uniqueItems": true}`)
+	for _, z1 := range zeros {
+		for _, z2 := range zeros {
+			if err := sch.Validate([]interface{}{z1, z2}); err == nil {
+				t.Errorf("[%#v, %#v]: duplicate not detected", z1, z2)
+			}
+			if err := sch.Validate([]interface{}{map[string]interface{}{"a": z1}, map[string]interface{}{"a": z2}}); err == nil {
+				t.Errorf("[{a: %#v}, {a: %#v}]: duplicate not detected", z1, z2)
+			}
+		}
+	}
+}
+
 func TestInfiniteLoopError(t *testing.T) {
 	t.Run("compile", func(t *testing.T) {
 		compiler := jsonschema.NewCompiler()
@@ -455,7 +888,8 @@ func TestInfiniteLoopError(t *testing.T) {
 		err = schema.Validate(decodeString(t, `{"prop": 1}`))
 		switch err := err.(type) {
 		case jsonschema.InfiniteLoopError:
-			suffix := "testdata/loop-validate.json#/$ref/$ref/not/$ref/allOf/0/$ref/anyOf/0/$ref/oneOf/0/$ref/dependencies/prop/$ref/dependentSchemas/prop/$ref/then/$ref/else/$dynamicRef/$ref"
+			// pure reference at #/$ref is collapsed
+			suffix := "testdata/loop-validate.json#/$ref/not/$ref/allOf/0/$ref/anyOf/0/$ref/oneOf/0/$ref/dependencies/prop/$ref/dependentSchemas/prop/$ref/then/$ref/else/$dynamicRef/$ref"
 			if !strings.HasSuffix(string(err), suffix) {
 				t.Errorf("        got: %s", string(err))
 				t.Errorf("want-suffix: %s", suffix)
@@ -660,6 +1094,56 @@ func TestCompiler_LoadURL(t *testing.T) {
 	}
 }
 
+func TestCompiler_RefMappings(t *testing.T) {
+	mirror := map[string]string{
+		"/mirror/a.json":     `{ "properties": { "b": { "$ref": "b.json" } } }`,
+		"/mirror/b.json":     `{ "$ref": "https://schemas.example.com/types.json#/$defs/name" }`,
+		"/mirror/types.json": `{ "$defs": { "name": { "type": "string" } } }`,
+	}
+	var requested []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = append(requested, r.URL.Path)
+		doc, ok := mirror[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = io.WriteString(w, doc)
+	}))
+	defer srv.Close()
+
+	c := jsonschema.NewCompiler()
+	c.RefMappings = map[string]string{
+		"https://":                     "http://unreachable.invalid/",
+		"https://schemas.example.com/": srv.URL + "/mirror/",
+	}
+	if err := c.AddResource("schema.json", strings.NewReader(`{ "$ref": "https://schemas.example.com/a.json" }`)); err != nil {
+		t.Fatal(err)
+	}
+	sch, err := c.Compile("schema.json")
+	if err != nil {
+		t.Fatalf("%#v", err)
+	}
+	if len(requested) != 3 {
+		t.Fatalf("requested: %v", requested)
+	}
+	if err := sch.Validate(decodeString(t, `{"b": "x"}`)); err != nil {
+		t.Fatal(err)
+	}
+	err = sch.Validate(decodeString(t, `{"b": 1}`))
+	ve, ok := err.(*jsonschema.ValidationError)
+	if !ok {
+		t.Fatalf("got %v, want *ValidationError", err)
+	}
+	leaf := ve
+	for len(leaf.Causes) > 0 {
+		leaf = leaf.Causes[0]
+	}
+	if want := "https://schemas.example.com/types.json#/$defs/name/type"; leaf.AbsoluteKeywordLocation != want {
+		t.Fatalf("absoluteKeywordLocation: got %s, want %s", leaf.AbsoluteKeywordLocation, want)
+	}
+}
+
 func TestFilePathSpaces(t *testing.T) {
 	if _, err := jsonschema.Compile("testdata/person schema.json"); err != nil {
 		t.Fatal(err)
@@ -698,3 +1182,319 @@ func decodeReader(t *testing.T, r io.Reader) interface{} {
 	}
 	return doc
 }
+
+func TestValidateWith_trace(t *testing.T) {
+	resources := map[string]string{
+		"http://example.com/root.json": `{
+			"properties": {
+				"a": {"$ref": "a.json"}
+			}
+		}`,
+		"http://example.com/a.json": `{
+			"allOf": [
+				{"$ref": "b.json#/$defs/positive"}
+			]
+		}`,
+		"http://example.com/b.json": `{
+			"$defs": {
+				"positive": {"exclusiveMinimum": 0}
+			}
+		}`,
+	}
+	c := jsonschema.NewCompiler()
+	for url, schema := range resources {
+		if err := c.AddResource(url, strings.NewReader(schema)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	sch, err := c.Compile("http://example.com/root.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := decodeString(t, `{"a": -1}`)
+
+	// trace is not populated by default
+	ve := sch.Validate(v).(*jsonschema.ValidationError)
+	if leaf := ve.Causes[0].Causes[0].Causes[0]; leaf.Trace != nil {
+		t.Fatalf("trace: got %v, want nil", leaf.Trace)
+	}
+
+	ve = sch.ValidateWith(v, jsonschema.ValidateOptions{Trace: true}).(*jsonschema.ValidationError)
+	leaf := ve
+	for len(leaf.Causes) > 0 {
+		leaf = leaf.Causes[0]
+	}
+	if leaf.KeywordLocation != "/properties/a/$ref/allOf/0/$ref/exclusiveMinimum" {
+		t.Fatalf("keywordLocation: got %s", leaf.KeywordLocation)
+	}
+	want := jsonschema.Trace{
+		{"http://example.com/root.json", "/properties/a"},
+		{"http://example.com/root.json", "/properties/a/$ref"},
+		{"http://example.com/a.json", "/allOf/0"},
+		{"http://example.com/a.json", "/allOf/0/$ref"},
+		{"http://example.com/b.json", "/$defs/positive/exclusiveMinimum"},
+	}
+	if len(leaf.Trace) != len(want) {
+		t.Fatalf("trace: got\n%v\nwant\n%v", leaf.Trace, want)
+	}
+	for i := range want {
+		if leaf.Trace[i] != want[i] {
+			t.Fatalf("trace: got\n%v\nwant\n%v", leaf.Trace, want)
+		}
+	}
+	wantStr := strings.Join([]string{
+		"http://example.com/root.json#/properties/a",
+		"  http://example.com/root.json#/properties/a/$ref",
+		"    http://example.com/a.json#/allOf/0",
+		"      http://example.com/a.json#/allOf/0/$ref",
+		"        http://example.com/b.json#/$defs/positive/exclusiveMinimum",
+	}, "\n")
+	if got := leaf.Trace.String(); got != wantStr {
+		t.Fatalf("trace string: got\n%s\nwant\n%s", got, wantStr)
+	}
+}
+
+func TestSchema_Resolve(t *testing.T) {
+	sch := jsonschema.MustCompileString("schema.json", `{
+		"$ref": "#/$defs/a",
+		"title": "pure reference with annotation",
+		"$defs": {
+			"a": {"$ref": "#/$defs/b"},
+			"b": {"type": "string", "$ref": "#/$defs/c"},
+			"c": {"maxLength": 2}
+		}
+	}`)
+	b := sch.Resolve()
+	if !strings.HasSuffix(b.Location, "#/$defs/b") {
+		t.Fatalf("resolve: got %s", b.Location)
+	}
+	if sch.Ref.Resolve() != b {
+		t.Fatalf("resolve single: got %s", sch.Ref.Resolve().Location)
+	}
+	if b.Resolve() != b {
+		t.Fatalf("resolve non-pure: got %s", b.Resolve().Location)
+	}
+
+	ve, ok := sch.Validate(1).(*jsonschema.ValidationError)
+	if !ok {
+		t.Fatalf("got %v, want *ValidationError", ve)
+	}
+	if !strings.HasSuffix(ve.AbsoluteKeywordLocation, "#/$defs/b") {
+		t.Fatalf("absoluteKeywordLocation: got %s", ve.AbsoluteKeywordLocation)
+	}
+	if got := ve.Causes[0].KeywordLocation; got != "/type" {
+		t.Fatalf("keywordLocation: got %s, want /type", got)
+	}
+
+	// cycle, made after compilation, because compiler collapses
+	// pure references in sch.Ref
+	c := jsonschema.MustCompileString("cycle.json", `{"$ref": "#/$defs/a", "$defs": {"a": {}}}`)
+	a := c.Ref
+	a.Ref = c
+	if got := c.Resolve(); got != c {
+		t.Fatalf("resolve cycle: got %s", got.Location)
+	}
+	if got := a.Resolve(); got != a {
+		t.Fatalf("resolve cycle: got %s", got.Location)
+	}
+}
+
+func TestValidationError_details(t *testing.T) {
+	tests := []struct {
+		schema  string
+		doc     string
+		details map[string]interface{}
+	}{
+		{`{"minProperties": 3}`, `{"a": 1}`, map[string]interface{}{"limit": 3, "count": 1}},
+		{`{"maxProperties": 1}`, `{"a": 1, "b": 2}`, map[string]interface{}{"limit": 1, "count": 2}},
+		{`{"required": ["a", "b", "c"]}`, `{"b": 1}`, map[string]interface{}{"missing": []string{"a", "c"}}},
+		{`{"minItems": 2}`, `[1]`, map[string]interface{}{"limit": 2, "count": 1}},
+		{`{"maxItems": 2}`, `[1, 2, 3]`, map[string]interface{}{"limit": 2, "count": 3}},
+		{`{"uniqueItems": true}`, `[1, 2, 1]`, map[string]interface{}{"indexes": []int{0, 2}}},
+		{`{"minLength": 2}`, `"a"`, map[string]interface{}{"limit": 2, "length": 1}},
+		{`{"contains": {"type": "string"}, "minContains": 3}`, `["a", 1, "b"]`, map[string]interface{}{"limit": 3, "matchedCount": 2, "matchedIndexes": []int{0, 2}}},
+		{`{"contains": {"type": "string"}, "maxContains": 1}`, `["a", 1, "b"]`, map[string]interface{}{"limit": 1, "matchedCount": 2, "matchedIndexes": []int{0, 2}}},
+		{`{"$schema": "http://json-schema.org/draft-07/schema#", "items": [true], "additionalItems": false}`, `[1, 2]`, map[string]interface{}{"limit": 1, "count": 2}},
+	}
+	for i, test := range tests {
+		sch := jsonschema.MustCompileString(fmt.Sprintf("schema%d.json", i), test.schema)
+		ve, ok := sch.Validate(decodeString(t, test.doc)).(*jsonschema.ValidationError)
+		if !ok {
+			t.Errorf("%s: *ValidationError expected", test.schema)
+			continue
+		}
+		if got := ve.Causes[0].Details; !reflect.DeepEqual(got, test.details) {
+			t.Errorf("%s: details got %v, want %v", test.schema, got, test.details)
+		}
+	}
+}
+
+func TestSchema_sensitive(t *testing.T) {
+	const secret = "hunter2-s3cr3t!"
+	resources := map[string]string{
+		"http://example.com/user.json": `{
+			"properties": {
+				"password": {"$ref": "secret.json"},
+				"token": {"$ref": "#/$defs/token"},
+				"pin": {"writeOnly": true, "maximum": 999},
+				"apiKey": {"pattern": "^[a-z]+$"}
+			},
+			"$defs": {
+				"token": {
+					"allOf": [{"format": "email"}, {"minLength": 100}],
+					"anyOf": [{"enum": ["a", "b"]}, {"const": "c"}],
+					"contentEncoding": "base64"
+				}
+			}
+		}`,
+		"http://example.com/secret.json": `{
+			"x-sensitive": true,
+			"oneOf": [
+				{"pattern": "^[a-z]+$"},
+				{"maxLength": 3, "format": "ipv4"}
+			]
+		}`,
+	}
+	c := jsonschema.NewCompiler()
+	c.Draft = jsonschema.Draft7
+	c.AssertFormat = true
+	c.SensitiveLocations = []string{"http://example.com/user.json#/$defs/token", "http://example.com/user.json#/properties/apiKey"}
+	for url, schema := range resources {
+		if err := c.AddResource(url, strings.NewReader(schema)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	sch, err := c.Compile("http://example.com/user.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc := map[string]interface{}{
+		"password": secret,
+		"token":    secret,
+		"apiKey":   secret,
+		"pin":      json.Number("31415926"),
+	}
+	ve, ok := sch.Validate(doc).(*jsonschema.ValidationError)
+	if !ok {
+		t.Fatalf("*ValidationError expected")
+	}
+	basic, err := json.Marshal(ve.BasicOutput())
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := strings.Join([]string{ve.Error(), fmt.Sprintf("%#v", ve), ve.DetailedString(), string(basic)}, "\n")
+	for _, fragment := range []string{"hunter", "s3cr3t", "3141"} {
+		if strings.Contains(out, fragment) {
+			t.Fatalf("%q found in:\n%s", fragment, out)
+		}
+	}
+	if !strings.Contains(out, "[redacted]") {
+		t.Fatalf("[redacted] not found in:\n%s", out)
+	}
+
+	// not sensitive
+	sch = jsonschema.MustCompileString("schema.json", `{"$schema": "http://json-schema.org/draft-07/schema#", "format": "ipv4"}`)
+	if err := sch.Validate(secret); err == nil || !strings.Contains(fmt.Sprintf("%#v", err), "s3cr3t") {
+		t.Fatalf("value expected in error: %#v", err)
+	}
+}
+
+func TestMinContains_grouped(t *testing.T) {
+	sch := jsonschema.MustCompileString("http://example.com/contains.json", `{
+		"contains": {"type": "object", "required": ["id"]},
+		"minContains": 20
+	}`)
+	arr := make([]interface{}, 1000)
+	for i := range arr {
+		switch i % 100 {
+		case 0:
+			arr[i] = map[string]interface{}{"id": i}
+		case 1:
+			arr[i] = map[string]interface{}{"name": "x"}
+		default:
+			arr[i] = "x"
+		}
+	}
+	tests := []struct {
+		golden string
+		opts   jsonschema.ValidateOptions
+	}{
+		{"testdata/contains/default.txt", jsonschema.ValidateOptions{}},
+		{"testdata/contains/max1.txt", jsonschema.ValidateOptions{MaxContainsCauses: 1}},
+	}
+	for _, test := range tests {
+		t.Run(test.golden, func(t *testing.T) {
+			ve, ok := sch.ValidateWith(arr, test.opts).(*jsonschema.ValidationError)
+			if !ok {
+				t.Fatal("*ValidationError expected")
+			}
+			minContains := ve.Causes[0]
+			if got := minContains.Details["matchedIndexes"]; !reflect.DeepEqual(got, []int{0, 100, 200, 300, 400, 500, 600, 700, 800, 900}) {
+				t.Fatalf("matchedIndexes: got %v", got)
+			}
+			if len(minContains.Causes) != 2 {
+				t.Fatalf("causes: got %d, want 2", len(minContains.Causes))
+			}
+			for i, want := range []struct {
+				code  string
+				count int
+			}{{"required", 10}, {"type", 980}} {
+				cause := minContains.Causes[i]
+				if cause.Details["code"] != want.code || len(cause.Details["indexes"].([]int)) != want.count {
+					t.Fatalf("cause %d: got %v %d, want %v %d", i, cause.Details["code"], len(cause.Details["indexes"].([]int)), want.code, want.count)
+				}
+			}
+			got := ve.Render(jsonschema.RenderOptions{})
+			want, err := ioutil.ReadFile(test.golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != strings.TrimSpace(string(want)) {
+				t.Fatalf("got:\n%s\nwant:\n%s", got, want)
+			}
+		})
+	}
+}
+
+func TestPropertiesOverlap(t *testing.T) {
+	sch := jsonschema.MustCompileString("http://example.com/overlap.json", `{
+		"$defs": {"name": {"type": "string", "maxLength": 3}},
+		"properties": {
+			"id": {"type": "integer"},
+			"name": {"$ref": "#/$defs/name"},
+			"x-a": {"minimum": 10}
+		},
+		"patternProperties": {
+			"^name$": {"$ref": "#/$defs/name"},
+			"^x-": {"type": "integer", "maximum": 0}
+		}
+	}`)
+	tests := []struct {
+		doc    string
+		golden string
+	}{
+		{`{"id": "a", "name": "abcdef", "x-a": 5}`, "testdata/overlap/grouped.txt"},
+		{`{"id": 1, "name": "abcdef", "x-b": 5}`, "testdata/overlap/deduplicated.txt"},
+		{`{"id": 1, "name": "abc", "x-b": -1}`, ""},
+	}
+	for _, test := range tests {
+		err := sch.Validate(decodeString(t, test.doc))
+		if test.golden == "" {
+			if err != nil {
+				t.Errorf("%s: %v", test.doc, err)
+			}
+			continue
+		}
+		ve, ok := err.(*jsonschema.ValidationError)
+		if !ok {
+			t.Fatalf("%s: got %v, want *ValidationError", test.doc, err)
+		}
+		want, err := ioutil.ReadFile(test.golden)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := fmt.Sprintf("%#v\n", ve); got != string(want) {
+			t.Errorf("%s: got\n%s\nwant\n%s", test.doc, got, want)
+		}
+	}
+}
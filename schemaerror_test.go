@@ -0,0 +1,58 @@
+package jsonschema_test
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+func TestSchemaError_ValidationCauses(t *testing.T) {
+	c := jsonschema.NewCompiler()
+	c.Draft = jsonschema.Draft2020
+	schema := `{
+		"type": 5,
+		"properties": {
+			"a": {"minLength": -1}
+		},
+		"required": "a"
+	}`
+	if err := c.AddResource("schema.json", strings.NewReader(schema)); err != nil {
+		t.Fatal(err)
+	}
+	_, err := c.Compile("schema.json")
+	var se *jsonschema.SchemaError
+	if !errors.As(err, &se) {
+		t.Fatalf("got %#v, want *SchemaError", err)
+	}
+	if _, ok := se.Err.(*jsonschema.ValidationError); !ok {
+		t.Fatalf("Err: got %#v, want *ValidationError", se.Err)
+	}
+	var got []string
+	for _, cause := range se.ValidationCauses {
+		got = append(got, cause.InstanceLocation)
+	}
+	sort.Strings(got)
+	want := []string{"/properties/a/minLength", "/required", "/type"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("instance locations: got %q, want %q", got, want)
+	}
+}
+
+func TestSchemaError_ValidationCauses_notMeta(t *testing.T) {
+	c := jsonschema.NewCompiler()
+	if err := c.AddResource("schema.json", strings.NewReader(`{"$ref": "#/missing"}`)); err != nil {
+		t.Fatal(err)
+	}
+	_, err := c.Compile("schema.json")
+	se, ok := err.(*jsonschema.SchemaError)
+	if !ok {
+		t.Fatalf("got %#v, want *SchemaError", err)
+	}
+	if se.ValidationCauses != nil {
+		t.Fatalf("ValidationCauses: got %v, want nil", se.ValidationCauses)
+	}
+}
@@ -0,0 +1,197 @@
+package jsonschema
+
+import (
+	"sort"
+	"strings"
+)
+
+// SchemaStats describes the size and complexity of a compiled schema.
+// It is computed by Schema.Stats.
+type SchemaStats struct {
+	Schemas       int            // number of distinct schemas reachable, including the schema itself
+	Resources     int            // number of distinct documents, the reachable schemas are from
+	MaxDepth      int            // maximum of shortest distance from the schema, to any reachable schema
+	Keywords      map[string]int // number of reachable schemas using each keyword
+	Patterns      int            // number of regexes in pattern and patternProperties
+	EnumValues    int            // total number of enum members
+	OneOfBranches int            // total number of oneOf subschemas
+	MaxFanOut     int            // maximum number of subschemas, any schema applies to same instance location
+}
+
+// Stats walks the schemas reachable from s, and returns their statistics.
+// Each schema is visited once, thus cycles introduced by $ref are safe.
+func (s *Schema) Stats() SchemaStats {
+	stats := SchemaStats{Keywords: make(map[string]int)}
+	resources := make(map[string]struct{})
+	depth := map[*Schema]int{s: 0}
+	queue := []*Schema{s}
+	for len(queue) > 0 {
+		sch := queue[0]
+		queue = queue[1:]
+		stats.Schemas++
+		if d := depth[sch]; d > stats.MaxDepth {
+			stats.MaxDepth = d
+		}
+		url := sch.Location
+		if i := strings.IndexByte(url, '#'); i != -1 {
+			url = url[:i]
+		}
+		resources[url] = struct{}{}
+		for _, keyword := range sch.keywords() {
+			stats.Keywords[keyword]++
+		}
+		if sch.Pattern != nil {
+			stats.Patterns++
+		}
+		stats.Patterns += len(sch.PatternProperties)
+		stats.EnumValues += len(sch.Enum)
+		stats.OneOfBranches += len(sch.OneOf)
+
+		fanOut := 0
+		sch.subschemas(func(sub *Schema, inplace bool) {
+			if inplace {
+				fanOut++
+			}
+			if _, ok := depth[sub]; !ok {
+				depth[sub] = depth[sch] + 1
+				queue = append(queue, sub)
+			}
+		})
+		if fanOut > stats.MaxFanOut {
+			stats.MaxFanOut = fanOut
+		}
+	}
+	stats.Resources = len(resources)
+	return stats
+}
+
+// subschemas calls f for each subschema of s, in deterministic order.
+// inplace tells whether the subschema applies to same instance location as s.
+func (s *Schema) subschemas(f func(sch *Schema, inplace bool)) {
+	visit := func(sch *Schema, inplace bool) {
+		if sch != nil {
+			f(sch, inplace)
+		}
+	}
+	visitValue := func(v interface{}, inplace bool) {
+		switch v := v.(type) {
+		case *Schema:
+			f(v, inplace)
+		case []*Schema:
+			for _, sch := range v {
+				f(sch, inplace)
+			}
+		}
+	}
+
+	visit(s.Ref, true)
+	visit(s.RecursiveRef, true)
+	visit(s.DynamicRef, true)
+	visit(s.Not, true)
+	visitValue(s.AllOf, true)
+	visitValue(s.AnyOf, true)
+	visitValue(s.OneOf, true)
+	visit(s.If, true)
+	visit(s.Then, true)
+	visit(s.Else, true)
+
+	for _, pname := range sortedKeys(s.Properties) {
+		f(s.Properties[pname], false)
+	}
+	visit(s.PropertyNames, false)
+	patterns := make([]string, 0, len(s.PatternProperties))
+	byPattern := make(map[string]*Schema, len(s.PatternProperties))
+	for re, sch := range s.PatternProperties {
+		patterns = append(patterns, re.String())
+		byPattern[re.String()] = sch
+	}
+	sort.Strings(patterns)
+	for _, pattern := range patterns {
+		f(byPattern[pattern], false)
+	}
+	visitValue(s.AdditionalProperties, false)
+	dnames := make([]string, 0, len(s.Dependencies))
+	for dname := range s.Dependencies {
+		dnames = append(dnames, dname)
+	}
+	sort.Strings(dnames)
+	for _, dname := range dnames {
+		visitValue(s.Dependencies[dname], true)
+	}
+	for _, dname := range sortedKeys(s.DependentSchemas) {
+		f(s.DependentSchemas[dname], true)
+	}
+	visit(s.UnevaluatedProperties, false)
+
+	visitValue(s.Items, false)
+	visitValue(s.AdditionalItems, false)
+	visitValue(s.PrefixItems, false)
+	visit(s.Items2020, false)
+	visit(s.Contains, false)
+	visit(s.UnevaluatedItems, false)
+}
+
+func sortedKeys(m map[string]*Schema) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// keywords returns the names of keywords used in s, other than annotations.
+// draft specific names such as "items" for Items2020 are not distinguished.
+func (s *Schema) keywords() []string {
+	var keywords []string
+	add := func(keyword string, used bool) {
+		if used {
+			keywords = append(keywords, keyword)
+		}
+	}
+	add("$ref", s.Ref != nil)
+	add("$recursiveRef", s.RecursiveRef != nil)
+	add("$dynamicRef", s.DynamicRef != nil)
+	add("format", s.Format != "")
+	add("type", len(s.Types) > 0)
+	add("const", len(s.Constant) > 0)
+	add("enum", len(s.Enum) > 0)
+	add("not", s.Not != nil)
+	add("allOf", len(s.AllOf) > 0)
+	add("anyOf", len(s.AnyOf) > 0)
+	add("oneOf", len(s.OneOf) > 0)
+	add("if", s.If != nil)
+	add("minProperties", s.MinProperties != -1)
+	add("maxProperties", s.MaxProperties != -1)
+	add("required", len(s.Required) > 0)
+	add("properties", len(s.Properties) > 0)
+	add("propertyNames", s.PropertyNames != nil)
+	add("patternProperties", len(s.PatternProperties) > 0)
+	add("additionalProperties", s.AdditionalProperties != nil)
+	add("dependencies", len(s.Dependencies) > 0)
+	add("dependentRequired", len(s.DependentRequired) > 0)
+	add("dependentSchemas", len(s.DependentSchemas) > 0)
+	add("unevaluatedProperties", s.UnevaluatedProperties != nil)
+	add("minItems", s.MinItems != -1)
+	add("maxItems", s.MaxItems != -1)
+	add("uniqueItems", s.UniqueItems)
+	add("items", s.Items != nil || s.Items2020 != nil)
+	add("additionalItems", s.AdditionalItems != nil)
+	add("prefixItems", len(s.PrefixItems) > 0)
+	add("contains", s.Contains != nil)
+	add("unevaluatedItems", s.UnevaluatedItems != nil)
+	add("minLength", s.MinLength != -1)
+	add("maxLength", s.MaxLength != -1)
+	add("pattern", s.Pattern != nil)
+	add("contentEncoding", s.ContentEncoding != "")
+	add("contentMediaType", s.ContentMediaType != "")
+	add("minimum", s.Minimum != nil)
+	add("exclusiveMinimum", s.ExclusiveMinimum != nil)
+	add("maximum", s.Maximum != nil)
+	add("exclusiveMaximum", s.ExclusiveMaximum != nil)
+	add("multipleOf", s.MultipleOf != nil)
+	for name := range s.Extensions {
+		keywords = append(keywords, name)
+	}
+	return keywords
+}
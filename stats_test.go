@@ -0,0 +1,65 @@
+package jsonschema_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+func TestSchema_Stats(t *testing.T) {
+	c := jsonschema.NewCompiler()
+	resources := map[string]string{
+		"tree.json": `{
+			"type": "object",
+			"properties": {
+				"value": {"$ref": "value.json"},
+				"children": {"type": "array", "items": {"$ref": "#"}}
+			},
+			"patternProperties": {"^x-": true}
+		}`,
+		"value.json": `{
+			"oneOf": [
+				{"type": "string", "pattern": "^[a-z]+$"},
+				{"enum": [1, 2, 3]},
+				{"$ref": "tree.json"}
+			]
+		}`,
+	}
+	for url, schema := range resources {
+		if err := c.AddResource(url, strings.NewReader(schema)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	sch, err := c.Compile("tree.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := sch.Stats()
+	want := jsonschema.SchemaStats{
+		Schemas:   9,
+		Resources: 2,
+		MaxDepth:  3,
+		Keywords: map[string]int{
+			"type":              3,
+			"properties":        1,
+			"patternProperties": 1,
+			"$ref":              3,
+			"items":             1,
+			"oneOf":             1,
+			"pattern":           1,
+			"enum":              1,
+		},
+		Patterns:      2,
+		EnumValues:    3,
+		OneOfBranches: 3,
+		MaxFanOut:     3,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %+v\nwant %+v", got, want)
+	}
+	if !reflect.DeepEqual(sch.Stats(), got) {
+		t.Fatal("stats must be deterministic")
+	}
+}
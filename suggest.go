@@ -0,0 +1,338 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// SuggestionKind tells the kind of mistake, a Suggestion fixes.
+type SuggestionKind string
+
+const (
+	// SuggestRename suggests renaming a property not allowed, to a known
+	// property with similar name.
+	SuggestRename SuggestionKind = "rename"
+
+	// SuggestEnumValue suggests replacing a string, with the enum member
+	// differing only in case or surrounding whitespace.
+	SuggestEnumValue SuggestionKind = "enumValue"
+
+	// SuggestUnquote suggests replacing a string containing number or
+	// boolean, with that number or boolean.
+	SuggestUnquote SuggestionKind = "unquote"
+
+	// SuggestDateFormat suggests reordering a date such as "31/12/2020"
+	// into "2020-12-31".
+	SuggestDateFormat SuggestionKind = "dateFormat"
+)
+
+// Suggestion is a proposed fix for a validation error, computed by Suggest.
+type Suggestion struct {
+	Kind             SuggestionKind
+	InstanceLocation string      // location of the value to be changed
+	Message          string      // describes the change
+	Replacement      interface{} // new property name for SuggestRename, otherwise new value
+	Confidence       float64     // between 0 and 1, higher is more likely to be correct
+}
+
+// Suggest returns the fixes for the mistakes it detects in doc, from the
+// validation error err, returned by schema.Validate(doc). The suggestions
+// are sorted by decreasing confidence.
+//
+// Only a fixed set of mistakes are detected; see SuggestionKind. No
+// suggestions are made for the values of sensitive schemas.
+func Suggest(err error, schema *Schema, doc interface{}) []Suggestion {
+	ve, ok := err.(*ValidationError)
+	if !ok {
+		return nil
+	}
+	schemas := make(map[string]*Schema) // by location
+	queue := []*Schema{schema}
+	for len(queue) > 0 {
+		sch := queue[0]
+		queue = queue[1:]
+		if _, ok := schemas[sch.Location]; ok {
+			continue
+		}
+		schemas[sch.Location] = sch
+		sch.subschemas(func(sub *Schema, inplace bool) {
+			queue = append(queue, sub)
+		})
+	}
+
+	var suggestions []Suggestion
+	a := NewInstanceAccessor(doc)
+	var visit func(ve *ValidationError)
+	visit = func(ve *ValidationError) {
+		for _, cause := range ve.Causes {
+			visit(cause)
+		}
+		if len(ve.Causes) > 0 || ve.Keyword == "" {
+			return
+		}
+		loc := strings.TrimSuffix(ve.AbsoluteKeywordLocation, "/"+ve.Keyword)
+		sch, ok := schemas[loc]
+		if !ok || sch.Sensitive {
+			return
+		}
+		v, ok := a.Get(ve.InstanceLocation)
+		if !ok {
+			return
+		}
+		suggestions = append(suggestions, suggest(ve, sch, v)...)
+	}
+	visit(ve)
+
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		if suggestions[i].Confidence != suggestions[j].Confidence {
+			return suggestions[i].Confidence > suggestions[j].Confidence
+		}
+		return suggestions[i].InstanceLocation < suggestions[j].InstanceLocation
+	})
+	return suggestions
+}
+
+// suggest returns the suggestions for leaf error ve, reported by schema
+// sch for value v.
+func suggest(ve *ValidationError, sch *Schema, v interface{}) []Suggestion {
+	switch ve.Keyword {
+	case "additionalProperties":
+		pnames, _ := ve.Details["properties"].([]string)
+		return suggestRename(ve.InstanceLocation, pnames, sch)
+	case "enum":
+		if str, ok := v.(string); ok {
+			return suggestEnumValue(ve.InstanceLocation, str, sch.Enum)
+		}
+	case "type":
+		if str, ok := v.(string); ok {
+			return suggestUnquote(ve.InstanceLocation, str, sch.Types)
+		}
+	case "format":
+		if str, ok := v.(string); ok && (sch.Format == "date" || sch.Format == "date-time") {
+			return suggestDateFormat(ve.InstanceLocation, str, sch.Format)
+		}
+	}
+	return nil
+}
+
+func suggestRename(loc string, pnames []string, sch *Schema) []Suggestion {
+	var suggestions []Suggestion
+	for _, pname := range pnames {
+		best, bestDist := "", -1
+		for _, known := range sortedKeys(sch.Properties) {
+			d := levenshtein(pname, known)
+			if bestDist == -1 || d < bestDist {
+				best, bestDist = known, d
+			}
+		}
+		// allow one edit for every three characters
+		if bestDist == -1 || bestDist > 1+len([]rune(best))/3 {
+			continue
+		}
+		n := len([]rune(best))
+		if m := len([]rune(pname)); m > n {
+			n = m
+		}
+		suggestions = append(suggestions, Suggestion{
+			Kind:             SuggestRename,
+			InstanceLocation: loc + "/" + escape(pname),
+			Message:          fmt.Sprintf("rename property %s to %s", quote(pname), quote(best)),
+			Replacement:      best,
+			Confidence:       1 - float64(bestDist)/float64(n),
+		})
+	}
+	return suggestions
+}
+
+func suggestEnumValue(loc string, str string, enum []interface{}) []Suggestion {
+	trimmed := strings.TrimSpace(str)
+	for _, item := range enum {
+		member, ok := item.(string)
+		if !ok {
+			continue
+		}
+		var confidence float64
+		switch {
+		case trimmed == member:
+			confidence = 0.95
+		case str == trimmed && strings.EqualFold(str, member):
+			confidence = 0.9
+		case strings.EqualFold(trimmed, member):
+			confidence = 0.85
+		default:
+			continue
+		}
+		return []Suggestion{{
+			Kind:             SuggestEnumValue,
+			InstanceLocation: loc,
+			Message:          fmt.Sprintf("replace %s with %s", quote(str), quote(member)),
+			Replacement:      member,
+			Confidence:       confidence,
+		}}
+	}
+	return nil
+}
+
+func suggestUnquote(loc string, str string, types []string) []Suggestion {
+	allowed := func(t string) bool {
+		for _, typ := range types {
+			if typ == t {
+				return true
+			}
+		}
+		return false
+	}
+	var replacement interface{}
+	switch {
+	case isJSONNumber(str) && allowed("number"):
+		replacement = json.Number(str)
+	case isJSONNumber(str) && allowed("integer") && toRat(json.Number(str)).IsInt():
+		replacement = json.Number(str)
+	case (str == "true" || str == "false") && allowed("boolean"):
+		replacement = str == "true"
+	default:
+		return nil
+	}
+	return []Suggestion{{
+		Kind:             SuggestUnquote,
+		InstanceLocation: loc,
+		Message:          fmt.Sprintf("replace %s with %v", quote(str), replacement),
+		Replacement:      replacement,
+		Confidence:       0.9,
+	}}
+}
+
+// dateRegexp matches dates with day, month and year in any order,
+// separated by '/', '.' or '-'.
+var dateRegexp = regexp.MustCompile(`^(\d{1,4})[/.-](\d{1,2})[/.-](\d{1,4})($|[ Tt])`)
+
+func suggestDateFormat(loc string, str string, format string) []Suggestion {
+	m := dateRegexp.FindStringSubmatch(str)
+	if m == nil {
+		return nil
+	}
+	rest := str[len(m[0]):]
+	if m[4] != "" {
+		rest = "T" + rest
+	}
+	type order struct {
+		year, month, day string
+		name             string
+		confidence       float64
+	}
+	var orders []order
+	switch {
+	case len(m[1]) == 4 && len(m[3]) <= 2:
+		orders = []order{{m[1], m[2], m[3], "YYYY/MM/DD", 0.95}}
+	case len(m[3]) == 4 && len(m[1]) <= 2:
+		orders = []order{{m[3], m[2], m[1], "DD/MM/YYYY", 0.6}, {m[3], m[1], m[2], "MM/DD/YYYY", 0.5}}
+	default:
+		return nil
+	}
+	var suggestions []Suggestion
+	for _, o := range orders {
+		month, _ := strconv.Atoi(o.month)
+		day, _ := strconv.Atoi(o.day)
+		date := fmt.Sprintf("%s-%02d-%02d", o.year, month, day)
+		if !isDate(date) {
+			continue
+		}
+		replacement := date
+		if format == "date-time" {
+			if rest == "" || !isDateTime(date+rest) {
+				continue
+			}
+			replacement = date + rest
+		} else if rest != "" {
+			continue
+		}
+		suggestions = append(suggestions, Suggestion{
+			Kind:             SuggestDateFormat,
+			InstanceLocation: loc,
+			Message:          fmt.Sprintf("replace %s in %s with %s", quote(str), o.name, quote(replacement)),
+			Replacement:      replacement,
+			Confidence:       o.confidence,
+		})
+	}
+	if len(suggestions) == 1 && len(orders) == 2 {
+		// only one order gives valid date
+		suggestions[0].Confidence = 0.9
+	}
+	return suggestions
+}
+
+// enumHint is the argument of enum error message, listing the enum
+// members closest to a string value. It is formatted lazily, because
+// computing edit distances of large enums is costly.
+type enumHint struct {
+	enum  []interface{}
+	value string
+}
+
+func (h enumHint) String() string {
+	type match struct {
+		member string
+		dist   int
+	}
+	var matches []match
+	for _, item := range h.enum {
+		member, ok := item.(string)
+		if !ok {
+			continue
+		}
+		// allow one edit for every three characters, as in suggestRename
+		if d := levenshtein(h.value, member); d <= 1+len([]rune(member))/3 {
+			matches = append(matches, match{member, d})
+		}
+	}
+	if len(matches) == 0 {
+		return ""
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].dist < matches[j].dist
+	})
+	if len(matches) > 3 {
+		matches = matches[:3]
+	}
+	quoted := make([]string, len(matches))
+	for i, m := range matches {
+		quoted[i] = fmt.Sprintf("%#v", m.member)
+	}
+	return fmt.Sprintf("; did you mean %s?", strings.Join(quoted, ", "))
+}
+
+// levenshtein returns the edit distance between s and t.
+func levenshtein(s, t string) int {
+	a, b := []rune(s), []rune(t)
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min3(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
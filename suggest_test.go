@@ -0,0 +1,83 @@
+package jsonschema_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+func TestSuggest(t *testing.T) {
+	c := jsonschema.NewCompiler()
+	c.Draft = jsonschema.Draft7
+	if err := c.AddResource("schema.json", strings.NewReader(`{
+		"properties": {
+			"firstName": {"type": "string"},
+			"age": {"type": "integer"},
+			"active": {"type": "boolean"},
+			"color": {"enum": ["red", "green", "Blue"]},
+			"born": {"type": "string", "format": "date"},
+			"seen": {"type": "string", "format": "date"},
+			"secret": {"enum": ["a", "b"]}
+		},
+		"additionalProperties": false
+	}`)); err != nil {
+		t.Fatal(err)
+	}
+	sch, err := c.Compile("schema.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sch.Properties["secret"].Sensitive = true
+
+	doc := decodeString(t, `{
+		"firstname": "john",
+		"age": "42",
+		"active": "true",
+		"color": " blue ",
+		"born": "25/12/2000",
+		"seen": "01/02/2020",
+		"secret": "A",
+		"zzz": 1
+	}`)
+	err = sch.Validate(doc)
+	if err == nil {
+		t.Fatal("validation must fail")
+	}
+
+	type suggestion struct {
+		kind        jsonschema.SuggestionKind
+		loc         string
+		replacement interface{}
+		confidence  float64
+	}
+	want := []suggestion{
+		{jsonschema.SuggestUnquote, "/active", true, 0.9},
+		{jsonschema.SuggestUnquote, "/age", json.Number("42"), 0.9},
+		{jsonschema.SuggestDateFormat, "/born", "2000-12-25", 0.9},
+		{jsonschema.SuggestRename, "/firstname", "firstName", 1 - 1.0/9},
+		{jsonschema.SuggestEnumValue, "/color", "Blue", 0.85},
+		{jsonschema.SuggestDateFormat, "/seen", "2020-02-01", 0.6},
+		{jsonschema.SuggestDateFormat, "/seen", "2020-01-02", 0.5},
+	}
+	got := jsonschema.Suggest(err, sch, doc)
+	if len(got) != len(want) {
+		t.Fatalf("got %d suggestions, want %d: %#v", len(got), len(want), got)
+	}
+	for i, w := range want {
+		g := got[i]
+		if g.Kind != w.kind || g.InstanceLocation != w.loc || g.Replacement != w.replacement || g.Confidence != w.confidence {
+			t.Errorf("suggestion %d: got %#v, want %#v", i, g, w)
+		}
+		if g.Message == "" {
+			t.Errorf("suggestion %d: message is empty", i)
+		}
+	}
+}
+
+func TestSuggest_notValidationError(t *testing.T) {
+	if got := jsonschema.Suggest(nil, nil, nil); got != nil {
+		t.Fatalf("got %v, want nil", got)
+	}
+}
@@ -0,0 +1,419 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// TransformOptions controls the document produced by Transform.
+type TransformOptions struct {
+	// Inline replaces $ref with the referenced schema.
+	// Recursive references are never inlined.
+	Inline bool
+
+	// MaxInlineDepth limits the nesting of inlined references, when
+	// Inline is true. Zero means no limit. References beyond this
+	// depth are left as references into generated $defs.
+	MaxInlineDepth int
+
+	// StripAnnotations omits title, description, default, $comment,
+	// readOnly, writeOnly, examples and deprecated. Note that annotations
+	// are available only if Compiler.ExtractAnnotations was true.
+	StripAnnotations bool
+
+	// RenameDefs returns the name in generated $defs for the schema
+	// at given absolute location. If nil, names are derived from
+	// location, with numeric suffix to make them unique.
+	RenameDefs func(old string) string
+}
+
+// Transform converts compiled schema s into a plain, self-contained
+// draft 2020-12 schema document.
+//
+// Schemas referenced by $ref are either inlined or collected in
+// $defs of the returned document, so that the document does not
+// refer to any external resource.
+//
+// Schemas using $recursiveRef, $dynamicRef or extensions can not be
+// transformed. Nor can schemas asserting format, contentEncoding or
+// contentMediaType, such as draft-07 schemas, because these keywords
+// are only annotations in draft 2020-12.
+func Transform(s *Schema, opts TransformOptions) (interface{}, error) {
+	t := &transformer{
+		opts:  opts,
+		root:  s,
+		defs:  make(map[*Schema]string),
+		names: make(map[string]*Schema),
+	}
+	doc, err := t.doc(s)
+	if err != nil {
+		return nil, err
+	}
+	defs := make(map[string]interface{})
+	for len(t.pending) > 0 {
+		sch := t.pending[0]
+		t.pending = t.pending[1:]
+		t.inlining, t.depth = append(t.inlining[:0], sch), 0
+		d, err := t.doc(sch)
+		if err != nil {
+			return nil, err
+		}
+		defs[t.defs[sch]] = d
+	}
+	m, ok := doc.(map[string]interface{})
+	if !ok {
+		// boolean schema, which never has $defs
+		return doc, nil
+	}
+	m["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	if len(defs) > 0 {
+		m["$defs"] = defs
+	}
+	return m, nil
+}
+
+// MarshalJSON returns the document produced by Transform
+// with default options.
+func (s *Schema) MarshalJSON() ([]byte, error) {
+	doc, err := Transform(s, TransformOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(doc)
+}
+
+type transformer struct {
+	opts     TransformOptions
+	root     *Schema
+	defs     map[*Schema]string // schemas collected in $defs, with their names
+	names    map[string]*Schema // inverse of defs
+	pending  []*Schema          // schemas in defs yet to be transformed
+	inlining []*Schema          // stack of schemas being inlined
+	depth    int                // number of references inlined in current stack
+}
+
+// ref returns the document for reference to s.
+func (t *transformer) ref(s *Schema) (interface{}, bool, error) {
+	if t.opts.Inline && (t.opts.MaxInlineDepth == 0 || t.depth < t.opts.MaxInlineDepth) {
+		recursive := s == t.root
+		for _, sch := range t.inlining {
+			if sch == s {
+				recursive = true
+				break
+			}
+		}
+		if !recursive {
+			t.inlining = append(t.inlining, s)
+			t.depth++
+			doc, err := t.doc(s)
+			t.inlining = t.inlining[:len(t.inlining)-1]
+			t.depth--
+			return doc, true, err
+		}
+	}
+	if s == t.root {
+		return "#", false, nil
+	}
+	name, ok := t.defs[s]
+	if !ok {
+		name = t.defName(s)
+		if sch, ok := t.names[name]; ok {
+			return nil, false, fmt.Errorf("jsonschema: %s and %s are renamed to same name %q", sch.Location, s.Location, name)
+		}
+		t.defs[s], t.names[name] = name, s
+		t.pending = append(t.pending, s)
+	}
+	return "#/$defs/" + escape(name), false, nil
+}
+
+func (t *transformer) defName(s *Schema) string {
+	if t.opts.RenameDefs != nil {
+		return t.opts.RenameDefs(s.Location)
+	}
+	u, f := split(s.Location)
+	name := f[strings.LastIndexByte(f, '/')+1:]
+	if name == "" || name == "#" {
+		name = strings.TrimSuffix(path.Base(u), path.Ext(u))
+	}
+	if name == "" || name == "." || name == "/" {
+		name = "schema"
+	}
+	for i, base := 2, name; ; i++ {
+		if _, ok := t.names[name]; !ok {
+			return name
+		}
+		name = base + "_" + strconv.Itoa(i)
+	}
+}
+
+// doc returns the document for s.
+func (t *transformer) doc(s *Schema) (interface{}, error) {
+	if s.Always != nil {
+		return *s.Always, nil
+	}
+	switch {
+	case s.RecursiveRef != nil:
+		return nil, fmt.Errorf("jsonschema: transform of $recursiveRef in %s is not supported", s.Location)
+	case s.DynamicRef != nil:
+		return nil, fmt.Errorf("jsonschema: transform of $dynamicRef in %s is not supported", s.Location)
+	case len(s.Extensions) > 0:
+		return nil, fmt.Errorf("jsonschema: transform of extensions in %s is not supported", s.Location)
+	case s.RegexProperties:
+		return nil, fmt.Errorf("jsonschema: transform of regexProperties in %s is not supported", s.Location)
+	case (s.format != nil || s.timeFormat != nil) && s.assertFormat:
+		return nil, fmt.Errorf("jsonschema: transform of format assertion in %s is not supported", s.Location)
+	case (s.decoder != nil || s.mediaType != nil) && s.assertContent:
+		return nil, fmt.Errorf("jsonschema: transform of content assertion in %s is not supported", s.Location)
+	}
+
+	m := make(map[string]interface{})
+	var err error
+	sch := func(s *Schema) interface{} {
+		if err != nil {
+			return nil
+		}
+		var doc interface{}
+		doc, err = t.doc(s)
+		return doc
+	}
+	schemas := func(ss []*Schema) []interface{} {
+		arr := make([]interface{}, len(ss))
+		for i, s := range ss {
+			arr[i] = sch(s)
+		}
+		return arr
+	}
+	schemaMap := func(ss map[string]*Schema) map[string]interface{} {
+		obj := make(map[string]interface{}, len(ss))
+		for k, s := range ss {
+			obj[k] = sch(s)
+		}
+		return obj
+	}
+	putInt := func(kw string, i int, unspecified int) {
+		if i != unspecified {
+			m[kw] = i
+		}
+	}
+	putRat := func(kw string, r *big.Rat) {
+		if r != nil {
+			m[kw] = ratToNumber(r)
+		}
+	}
+	putString := func(kw string, s string) {
+		if s != "" {
+			m[kw] = s
+		}
+	}
+
+	// type agnostic
+	if len(s.Types) == 1 {
+		m["type"] = s.Types[0]
+	} else if len(s.Types) > 1 {
+		m["type"] = s.Types
+	}
+	if len(s.Constant) > 0 {
+		m["const"] = s.Constant[0]
+	}
+	if len(s.Enum) > 0 {
+		m["enum"] = s.Enum
+	}
+	putString("format", s.Format)
+	if s.Not != nil {
+		m["not"] = sch(s.Not)
+	}
+	if len(s.AllOf) > 0 {
+		m["allOf"] = schemas(s.AllOf)
+	}
+	if len(s.AnyOf) > 0 {
+		m["anyOf"] = schemas(s.AnyOf)
+	}
+	if len(s.OneOf) > 0 {
+		m["oneOf"] = schemas(s.OneOf)
+	}
+	if s.If != nil {
+		m["if"] = sch(s.If)
+		if s.Then != nil {
+			m["then"] = sch(s.Then)
+		}
+		if s.Else != nil {
+			m["else"] = sch(s.Else)
+		}
+	}
+
+	// object
+	putInt("minProperties", s.MinProperties, -1)
+	putInt("maxProperties", s.MaxProperties, -1)
+	if len(s.Required) > 0 {
+		m["required"] = s.Required
+	}
+	if len(s.Properties) > 0 {
+		m["properties"] = schemaMap(s.Properties)
+	}
+	if s.PropertyNames != nil {
+		m["propertyNames"] = sch(s.PropertyNames)
+	}
+	if len(s.PatternProperties) > 0 {
+		obj := make(map[string]interface{}, len(s.PatternProperties))
+		for re, s := range s.PatternProperties {
+			obj[re.String()] = sch(s)
+		}
+		m["patternProperties"] = obj
+	}
+	switch ap := s.AdditionalProperties.(type) {
+	case bool:
+		m["additionalProperties"] = ap
+	case *Schema:
+		m["additionalProperties"] = sch(ap)
+	}
+	depRequired := make(map[string]interface{})
+	depSchemas := make(map[string]interface{})
+	for pname, dep := range s.Dependencies {
+		switch dep := dep.(type) {
+		case []string:
+			depRequired[pname] = dep
+		case *Schema:
+			depSchemas[pname] = sch(dep)
+		}
+	}
+	for pname, dep := range s.DependentRequired {
+		depRequired[pname] = dep
+	}
+	for pname, dep := range s.DependentSchemas {
+		depSchemas[pname] = sch(dep)
+	}
+	if len(depRequired) > 0 {
+		m["dependentRequired"] = depRequired
+	}
+	if len(depSchemas) > 0 {
+		m["dependentSchemas"] = depSchemas
+	}
+	if s.UnevaluatedProperties != nil {
+		m["unevaluatedProperties"] = sch(s.UnevaluatedProperties)
+	}
+
+	// array
+	putInt("minItems", s.MinItems, -1)
+	putInt("maxItems", s.MaxItems, -1)
+	if s.UniqueItems {
+		m["uniqueItems"] = true
+	}
+	switch items := s.Items.(type) {
+	case *Schema:
+		m["items"] = sch(items)
+	case []*Schema:
+		m["prefixItems"] = schemas(items)
+		switch ai := s.AdditionalItems.(type) {
+		case bool:
+			if !ai {
+				m["items"] = false
+			}
+		case *Schema:
+			m["items"] = sch(ai)
+		}
+	}
+	if len(s.PrefixItems) > 0 {
+		m["prefixItems"] = schemas(s.PrefixItems)
+	}
+	if s.Items2020 != nil {
+		m["items"] = sch(s.Items2020)
+	}
+	if s.Contains != nil {
+		m["contains"] = sch(s.Contains)
+		putInt("minContains", s.MinContains, 1)
+		putInt("maxContains", s.MaxContains, -1)
+	}
+	if s.UnevaluatedItems != nil {
+		m["unevaluatedItems"] = sch(s.UnevaluatedItems)
+	}
+
+	// string
+	putInt("minLength", s.MinLength, -1)
+	putInt("maxLength", s.MaxLength, -1)
+	if s.Pattern != nil {
+		m["pattern"] = s.Pattern.String()
+	}
+	putString("contentEncoding", s.ContentEncoding)
+	putString("contentMediaType", s.ContentMediaType)
+
+	// number
+	putRat("minimum", s.Minimum)
+	putRat("exclusiveMinimum", s.ExclusiveMinimum)
+	putRat("maximum", s.Maximum)
+	putRat("exclusiveMaximum", s.ExclusiveMaximum)
+	putRat("multipleOf", s.MultipleOf)
+
+	if len(s.Messages) > 0 {
+		messages := make(map[string]interface{}, len(s.Messages))
+		for keyword, tmpl := range s.Messages {
+			messages[keyword] = tmpl.Root.String()
+		}
+		m["messages"] = messages
+	}
+
+	if s.Sensitive {
+		m["x-sensitive"] = true
+	}
+
+	if !t.opts.StripAnnotations {
+		putString("title", s.Title)
+		putString("description", s.Description)
+		if s.Default != nil {
+			m["default"] = s.Default
+		}
+		putString("$comment", s.Comment)
+		if s.ReadOnly {
+			m["readOnly"] = true
+		}
+		if s.WriteOnly {
+			m["writeOnly"] = true
+		}
+		if len(s.Examples) > 0 {
+			m["examples"] = s.Examples
+		}
+		if s.Deprecated {
+			m["deprecated"] = true
+		}
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if s.Ref != nil {
+		ref, inlined, err := t.ref(s.Ref)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case !inlined:
+			m["$ref"] = ref
+		case len(m) == 0:
+			return ref, nil
+		default:
+			allOf, _ := m["allOf"].([]interface{})
+			m["allOf"] = append([]interface{}{ref}, allOf...)
+		}
+	}
+	return m, nil
+}
+
+// ratToNumber returns the shortest decimal json.Number exactly representing r.
+// If r does not have finite decimal representation, it is approximated.
+func ratToNumber(r *big.Rat) json.Number {
+	if r.IsInt() {
+		return json.Number(r.Num().String())
+	}
+	for prec := 1; prec <= 1000; prec++ {
+		s := r.FloatString(prec)
+		if v, ok := new(big.Rat).SetString(s); ok && v.Cmp(r) == 0 {
+			return json.Number(s)
+		}
+	}
+	f, _ := r.Float64()
+	return json.Number(strconv.FormatFloat(f, 'g', -1, 64))
+}
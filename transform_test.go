@@ -0,0 +1,198 @@
+package jsonschema_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+func TestTransform(t *testing.T) {
+	resources := map[string]string{
+		"schema.json": `{
+			"title": "tree",
+			"$ref": "#/$defs/node",
+			"$defs": {
+				"node": {
+					"type": "object",
+					"required": ["value"],
+					"properties": {
+						"value": {"$ref": "common.json#/$defs/positive"},
+						"legacy": {"$ref": "legacy.json"},
+						"children": {"type": "array", "items": {"$ref": "#/$defs/node"}}
+					}
+				}
+			}
+		}`,
+		"common.json": `{
+			"$defs": {
+				"positive": {"type": "number", "exclusiveMinimum": 0, "multipleOf": 0.25, "description": "positive"}
+			}
+		}`,
+		"legacy.json": `{
+			"$schema": "http://json-schema.org/draft-07/schema#",
+			"items": [{"type": "string"}, {"$ref": "common.json#/$defs/positive"}],
+			"additionalItems": false,
+			"dependencies": {"a": ["b"], "c": {"required": ["d"]}}
+		}`,
+	}
+	c := jsonschema.NewCompiler()
+	c.ExtractAnnotations = true
+	for url, schema := range resources {
+		if err := c.AddResource(url, strings.NewReader(schema)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	sch, err := c.Compile("schema.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	docs := []string{
+		`{"value": 1}`,
+		`{"value": 0}`,
+		`{"value": 1.3}`,
+		`{"value": 1, "children": [{"value": 2}, {"value": 3, "children": [{"value": 0.25}]}]}`,
+		`{"value": 1, "children": [{"value": 2}, {"value": 3, "children": [{"value": -1}]}]}`,
+		`{"value": 1, "children": [{"children": []}]}`,
+		`{"value": 1, "legacy": ["x", 0.5]}`,
+		`{"value": 1, "legacy": ["x", 0.5, 1]}`,
+		`{"value": 1, "legacy": [1]}`,
+		`{"value": 1, "legacy": {"a": 1, "b": 2, "c": 3, "d": 4}}`,
+		`{"value": 1, "legacy": {"a": 1}}`,
+		`{"value": 1, "legacy": {"c": 1}}`,
+		`[]`,
+	}
+
+	tests := []struct {
+		name string
+		opts jsonschema.TransformOptions
+		defs []string
+	}{
+		{"refs", jsonschema.TransformOptions{}, []string{"legacy", "node", "positive"}},
+		{"inline", jsonschema.TransformOptions{Inline: true}, []string{"node"}},
+		{"inlineDepth", jsonschema.TransformOptions{Inline: true, MaxInlineDepth: 1}, []string{"legacy", "node", "positive"}},
+		{"rename", jsonschema.TransformOptions{RenameDefs: func(old string) string {
+			return "def_" + old[strings.LastIndexByte(old, '/')+1:]
+		}}, []string{"def_legacy.json#", "def_node", "def_positive"}},
+		{"strip", jsonschema.TransformOptions{Inline: true, StripAnnotations: true}, []string{"node"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			doc, err := jsonschema.Transform(sch, test.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			b, err := json.Marshal(doc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Logf("%s", b)
+
+			var defs []string
+			for name := range doc.(map[string]interface{})["$defs"].(map[string]interface{}) {
+				defs = append(defs, name)
+			}
+			if len(defs) != len(test.defs) {
+				t.Fatalf("defs: got %v, want %v", defs, test.defs)
+			}
+			for _, name := range test.defs {
+				if !bytes.Contains(b, []byte(`"`+name+`":`)) {
+					t.Fatalf("defs: got %v, want %v", defs, test.defs)
+				}
+			}
+			if stripped := !bytes.Contains(b, []byte(`"description"`)); stripped != test.opts.StripAnnotations {
+				t.Fatalf("annotations stripped: got %v, want %v", stripped, test.opts.StripAnnotations)
+			}
+
+			// must be self-contained
+			tc := jsonschema.NewCompiler()
+			tc.LoadURL = func(s string) (io.ReadCloser, error) {
+				return nil, errors.New("no loading allowed")
+			}
+			if err := tc.AddResource("transformed.json", bytes.NewReader(b)); err != nil {
+				t.Fatal(err)
+			}
+			tsch, err := tc.Compile("transformed.json")
+			if err != nil {
+				t.Fatalf("%#v", err)
+			}
+			for _, doc := range docs {
+				v := decodeString(t, doc)
+				want, got := sch.Validate(v) == nil, tsch.Validate(v) == nil
+				if got != want {
+					t.Errorf("%s: valid got %v, want %v", doc, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestTransform_format(t *testing.T) {
+	tests := []struct {
+		schema string
+		ok     bool // whether transform is supported
+	}{
+		{`{"$schema": "http://json-schema.org/draft-07/schema#", "format": "email"}`, false},
+		{`{"$schema": "http://json-schema.org/draft-07/schema#", "contentEncoding": "base64"}`, false},
+		{`{"$schema": "http://json-schema.org/draft-07/schema#", "properties": {"a": {"format": "email"}}}`, false},
+		{`{"$schema": "http://json-schema.org/draft-07/schema#", "format": "x-unknown"}`, true},
+		{`{"format": "email", "contentEncoding": "base64"}`, true},
+	}
+	for i, test := range tests {
+		sch := jsonschema.MustCompileString(fmt.Sprintf("schema%d.json", i), test.schema)
+		doc, err := jsonschema.Transform(sch, jsonschema.TransformOptions{})
+		if !test.ok {
+			if err == nil {
+				t.Errorf("%s: error expected", test.schema)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", test.schema, err)
+			continue
+		}
+		b, err := json.Marshal(doc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tsch := jsonschema.MustCompileString(fmt.Sprintf("transformed%d.json", i), string(b))
+		for _, v := range []interface{}{"x", "a@b.com", map[string]interface{}{"a": "x"}} {
+			if want, got := sch.Validate(v) == nil, tsch.Validate(v) == nil; got != want {
+				t.Errorf("%s %v: valid got %v, want %v", test.schema, v, got, want)
+			}
+		}
+	}
+}
+
+func TestTransform_unsupported(t *testing.T) {
+	sch := jsonschema.MustCompileString("schema.json", `{
+		"$dynamicAnchor": "node",
+		"properties": {"next": {"$dynamicRef": "#node"}}
+	}`)
+	if _, err := jsonschema.Transform(sch, jsonschema.TransformOptions{}); err == nil {
+		t.Fatal("error expected")
+	}
+}
+
+func TestSchema_MarshalJSON(t *testing.T) {
+	sch := jsonschema.MustCompileString("schema.json", `{
+		"$schema": "http://json-schema.org/draft-04/schema#",
+		"minimum": 1.5,
+		"exclusiveMinimum": true,
+		"type": ["integer", "string"]
+	}`)
+	b, err := json.Marshal(sch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"$schema":"https://json-schema.org/draft/2020-12/schema","exclusiveMinimum":1.5,"type":["integer","string"]}`
+	if string(b) != want {
+		t.Fatalf("got %s, want %s", b, want)
+	}
+}
@@ -0,0 +1,132 @@
+package jsonschema
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// loadUniqueKeys returns the json-pointers in value of uniqueKeys keyword.
+func loadUniqueKeys(v interface{}) ([]string, error) {
+	arr, ok := v.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("must be array")
+	}
+	keys := make([]string, len(arr))
+	for i, item := range arr {
+		ptr, ok := item.(string)
+		if !ok {
+			return nil, fmt.Errorf("item at index %d must be string", i)
+		}
+		if ptr != "" && ptr[0] != '/' {
+			return nil, fmt.Errorf("item at index %d is not json-pointer: %s", i, quote(ptr))
+		}
+		keys[i] = ptr
+	}
+	return keys, nil
+}
+
+// uniqueKeys returns the errors of uniqueKeys keyword of s, for array v.
+// The items are grouped by hash of their keys, so that it takes linear
+// time unlike uniqueItems. If sensitive, the values of keys are not
+// reported in the errors.
+func (s *Schema) uniqueKeys(vd *validation, v []interface{}, sensitive bool, validationError func(keywordPath string, format string, a ...interface{}) *ValidationError) []error {
+	var errors []error
+	seen := make(map[string]int, len(v))
+	values := make([]interface{}, len(s.UniqueKeys))
+	var hash strings.Builder
+items:
+	for i, item := range v {
+		hash.Reset()
+		for k, ptr := range s.UniqueKeys {
+			value, ok := lookup(item, ptr)
+			if !ok {
+				if s.keysRequired {
+					errors = append(errors, validationError("uniqueKeys", "item at index %d does not have key %s", i, quote(ptr)).withDetails("index", i, "key", ptr))
+				}
+				continue items
+			}
+			if err := hashValue(&hash, value); err != nil {
+				if !vd.opts.LenientTypes {
+					panic(err)
+				}
+				continue items
+			}
+			hash.WriteByte(0)
+			values[k] = value
+		}
+		if j, ok := seen[hash.String()]; ok {
+			keys := make([]string, len(values))
+			for k, value := range values {
+				if sensitive {
+					keys[k] = s.UniqueKeys[k] + "=[redacted]"
+				} else {
+					keys[k] = fmt.Sprintf("%s=%#v", s.UniqueKeys[k], value)
+				}
+			}
+			ve := validationError("uniqueKeys", "items at index %d and %d have same keys %s", j, i, strings.Join(keys, ", "))
+			if sensitive {
+				ve.withDetails("indexes", []int{j, i})
+			} else {
+				ve.withDetails("indexes", []int{j, i}, "values", append([]interface{}(nil), values...))
+			}
+			errors = append(errors, ve)
+			continue
+		}
+		seen[hash.String()] = i
+	}
+	return errors
+}
+
+// hashValue writes canonical form of json value v to b, such that two
+// values are equal, as per checkEquals, iff their canonical forms are same.
+func hashValue(b *strings.Builder, v interface{}) error {
+	v, err := native(v)
+	if err != nil {
+		return InvalidJSONTypeError(err.Error())
+	}
+	switch v := v.(type) {
+	case nil:
+		b.WriteString("null")
+	case bool:
+		b.WriteString(strconv.FormatBool(v))
+	case string:
+		b.WriteString(strconv.Quote(v))
+	case map[string]interface{}:
+		pnames := make([]string, 0, len(v))
+		for pname := range v {
+			pnames = append(pnames, pname)
+		}
+		sort.Strings(pnames)
+		b.WriteByte('{')
+		for _, pname := range pnames {
+			b.WriteString(strconv.Quote(pname))
+			b.WriteByte(':')
+			if err := hashValue(b, v[pname]); err != nil {
+				return err
+			}
+			b.WriteByte(',')
+		}
+		b.WriteByte('}')
+	case []interface{}:
+		b.WriteByte('[')
+		for _, item := range v {
+			if err := hashValue(b, item); err != nil {
+				return err
+			}
+			b.WriteByte(',')
+		}
+		b.WriteByte(']')
+	default:
+		if _, err := checkJSONType(v); err != nil {
+			return err
+		}
+		r, err := parseRat(v)
+		if err != nil {
+			return err
+		}
+		b.WriteString(r.RatString())
+	}
+	return nil
+}
@@ -0,0 +1,174 @@
+package jsonschema_test
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+func compileUniqueKeys(t *testing.T, schema string, required bool) *jsonschema.Schema {
+	t.Helper()
+	c := jsonschema.NewCompiler()
+	c.EnableUniqueKeys = true
+	c.RequireUniqueKeys = required
+	if err := c.AddResource("schema.json", strings.NewReader(schema)); err != nil {
+		t.Fatal(err)
+	}
+	sch, err := c.Compile("schema.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sch
+}
+
+func TestUniqueKeys(t *testing.T) {
+	sch := compileUniqueKeys(t, `{"uniqueKeys": ["/id", "/owner/region"]}`, false)
+	tests := []struct {
+		doc     string
+		indexes [][]int
+	}{
+		{`[{"id": 1, "owner": {"region": "eu"}}, {"id": 1, "owner": {"region": "us"}}]`, nil},
+		{`[{"id": 1, "owner": {"region": "eu"}}, {"id": 2, "owner": {"region": "eu"}}]`, nil},
+		{`[{"id": 1, "owner": {"region": "eu"}, "x": 1}, {"id": 1.0, "owner": {"region": "eu"}, "x": 2}]`, [][]int{{0, 1}}},
+		{`[{"id": [1, {"a": 2}]}, {"id": [1, {"a": 2}], "owner": {}}]`, nil}, // missing region
+		{`[{"id": {"a": 1, "b": 2}, "owner": {"region": null}}, {"owner": {"region": null}, "id": {"b": 2, "a": 1}}]`, [][]int{{0, 1}}},
+		{`[{"id": "1", "owner": {"region": "eu"}}, {"id": 1, "owner": {"region": "eu"}}]`, nil},
+		{`[{"id": 1, "owner": {"region": "eu"}}, {}, {"id": 1, "owner": {"region": "eu"}}, {"id": 1, "owner": {"region": "eu"}}]`, [][]int{{0, 2}, {0, 3}}},
+		{`"not array"`, nil},
+	}
+	for _, test := range tests {
+		err := sch.Validate(decodeString(t, test.doc))
+		if test.indexes == nil {
+			if err != nil {
+				t.Errorf("%s: %v", test.doc, err)
+			}
+			continue
+		}
+		ve, ok := err.(*jsonschema.ValidationError)
+		if !ok {
+			t.Errorf("%s: got %v, want *ValidationError", test.doc, err)
+			continue
+		}
+		var got [][]int
+		for _, cause := range ve.Causes {
+			if cause.Keyword != "uniqueKeys" {
+				t.Fatalf("%s: got keyword %s", test.doc, cause.Keyword)
+			}
+			got = append(got, cause.Details["indexes"].([]int))
+		}
+		if !reflect.DeepEqual(got, test.indexes) {
+			t.Errorf("%s: indexes got %v, want %v", test.doc, got, test.indexes)
+		}
+	}
+
+	t.Run("message", func(t *testing.T) {
+		err := sch.Validate(decodeString(t, `[{"id": 7, "owner": {"region": "eu"}}, {"id": 7, "owner": {"region": "eu"}}]`))
+		ve := err.(*jsonschema.ValidationError).Causes[0]
+		if want := `items at index 0 and 1 have same keys /id="7", /owner/region="eu"`; ve.Message != want {
+			t.Fatalf("got %q, want %q", ve.Message, want)
+		}
+		values := ve.Details["values"].([]interface{})
+		if fmt.Sprint(values) != "[7 eu]" {
+			t.Fatalf("values: got %v", values)
+		}
+	})
+}
+
+func TestUniqueKeys_sensitive(t *testing.T) {
+	doc := `[{"id": "TOPSECRET"}, {"id": "TOPSECRET"}]`
+	for _, schema := range []string{
+		`{"x-sensitive": true, "uniqueKeys": ["/id"]}`,
+		`{"writeOnly": true, "uniqueKeys": ["/id"]}`,
+		`{"properties": {"ids": {"x-sensitive": true, "$ref": "#/$defs/list"}}, "$defs": {"list": {"uniqueKeys": ["/id"]}}}`,
+	} {
+		sch := compileUniqueKeys(t, schema, false)
+		v := decodeString(t, doc)
+		if strings.Contains(schema, `"ids"`) {
+			v = map[string]interface{}{"ids": v}
+		}
+		ve, ok := sch.Validate(v).(*jsonschema.ValidationError)
+		if !ok {
+			t.Fatalf("%s: *ValidationError expected", schema)
+		}
+		leaf := ve
+		for len(leaf.Causes) > 0 {
+			leaf = leaf.Causes[0]
+		}
+		if _, ok := leaf.Details["values"]; ok {
+			t.Errorf("%s: values must not be in details", schema)
+		}
+		out := strings.Join([]string{ve.Error(), fmt.Sprintf("%#v", ve), ve.DetailedString()}, "\n")
+		if strings.Contains(out, "TOPSECRET") {
+			t.Errorf("%s: TOPSECRET found in:\n%s", schema, out)
+		}
+		if !strings.Contains(out, "/id=[redacted]") {
+			t.Errorf("%s: /id=[redacted] not found in:\n%s", schema, out)
+		}
+	}
+}
+
+func TestUniqueKeys_required(t *testing.T) {
+	sch := compileUniqueKeys(t, `{"uniqueKeys": ["/id"]}`, true)
+	err := sch.Validate(decodeString(t, `[{"id": 1}, {"name": "x"}, {"id": 2}]`))
+	ve, ok := err.(*jsonschema.ValidationError)
+	if !ok {
+		t.Fatalf("got %v, want *ValidationError", err)
+	}
+	cause := ve.Causes[0]
+	if cause.Details["index"] != 1 || cause.Details["key"] != "/id" {
+		t.Fatalf("details: got %v", cause.Details)
+	}
+	if err := sch.Validate(decodeString(t, `[{"id": 1}, {"id": 2}]`)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUniqueKeys_disabled(t *testing.T) {
+	sch, err := jsonschema.CompileString("schema.json", `{"uniqueKeys": ["/id"]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sch.Validate(decodeString(t, `[{"id": 1}, {"id": 1}]`)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUniqueKeys_invalid(t *testing.T) {
+	for _, keys := range []string{`"/id"`, `[1]`, `["id"]`} {
+		c := jsonschema.NewCompiler()
+		c.EnableUniqueKeys = true
+		if err := c.AddResource("schema.json", strings.NewReader(`{"uniqueKeys": `+keys+`}`)); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := c.Compile("schema.json"); err == nil {
+			t.Errorf("%s: want error", keys)
+		}
+	}
+}
+
+func TestUniqueKeys_large(t *testing.T) {
+	sch := compileUniqueKeys(t, `{"uniqueKeys": ["/id"]}`, false)
+	arr := make([]interface{}, 100000)
+	for i := range arr {
+		arr[i] = map[string]interface{}{"id": i, "name": "x"}
+	}
+	start := time.Now()
+	if err := sch.Validate(arr); err != nil {
+		t.Fatal(err)
+	}
+	arr = append(arr, map[string]interface{}{"id": 500})
+	ve, ok := sch.Validate(arr).(*jsonschema.ValidationError)
+	if !ok {
+		t.Fatal("*ValidationError expected")
+	}
+	if got := ve.Causes[0].Details["indexes"]; !reflect.DeepEqual(got, []int{500, 100000}) {
+		t.Fatalf("indexes: got %v", got)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("took %v", elapsed)
+	}
+}
@@ -0,0 +1,31 @@
+package jsonschema
+
+import "strings"
+
+// upstreamMessages has the messages of upstream v5, for the keywords
+// whose native message differ from it, keyed by keyword. Given the format
+// and arguments of native message, it returns those of upstream message.
+//
+// The messages of other keywords, such as type, required, const, minimum,
+// pattern, additionalProperties, oneOf and anyOf, are same as upstream.
+// The errors with no counterpart in upstream, such as the causes of
+// contains grouped by keyword, keep native message.
+var upstreamMessages = map[string]func(s *Schema, format string, a []interface{}) (string, []interface{}){
+	// all values are listed, with no suggestions
+	"enum": func(s *Schema, format string, a []interface{}) (string, []interface{}) {
+		return "%s", []interface{}{enumMessage(s.Enum, -1)}
+	},
+}
+
+// upstreamMessage returns format and arguments of the upstream message,
+// for the error of keyword at keywordPath in s.
+func upstreamMessage(s *Schema, keywordPath string, format string, a []interface{}) (string, []interface{}) {
+	keyword := keywordPath
+	if i := strings.IndexByte(keyword, '/'); i != -1 {
+		keyword = keyword[:i]
+	}
+	if f, ok := upstreamMessages[keyword]; ok {
+		return f(s, format, a)
+	}
+	return format, a
+}
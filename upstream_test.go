@@ -0,0 +1,57 @@
+package jsonschema_test
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+func TestValidateOptions_UpstreamMessages(t *testing.T) {
+	f, err := os.Open("testdata/upstream/messages.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var tests []struct {
+		Schema   json.RawMessage
+		Doc      json.RawMessage
+		Messages map[string]string // keywordLocation to upstream message
+	}
+	if err := json.NewDecoder(f).Decode(&tests); err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range tests {
+		c := jsonschema.NewCompiler()
+		c.Draft = jsonschema.Draft2020
+		c.AssertFormat = true
+		if err := c.AddResource("schema.json", strings.NewReader(string(test.Schema))); err != nil {
+			t.Fatal(err)
+		}
+		sch, err := c.Compile("schema.json")
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = sch.ValidateWith(decodeString(t, string(test.Doc)), jsonschema.ValidateOptions{UpstreamMessages: true})
+		ve, ok := err.(*jsonschema.ValidationError)
+		if !ok {
+			t.Fatalf("%s: got %v, want *ValidationError", test.Schema, err)
+		}
+		got := make(map[string]string)
+		var collect func(ve *jsonschema.ValidationError)
+		collect = func(ve *jsonschema.ValidationError) {
+			got[ve.KeywordLocation] = ve.Message
+			for _, cause := range ve.Causes {
+				collect(cause)
+			}
+		}
+		collect(ve)
+		for loc, want := range test.Messages {
+			if got[loc] != want {
+				t.Errorf("%s %s: %s\n got %q\nwant %q", test.Schema, test.Doc, loc, got[loc], want)
+			}
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package jsonschema_test
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+func TestNormalizeURL(t *testing.T) {
+	tests := []struct {
+		name     string
+		resource string
+		compile  string
+	}{
+		{"schemeCase", "https://example.com/schema.json", "HTTPS://example.com/schema.json"},
+		{"hostCase", "https://example.com/schema.json", "https://Example.COM/schema.json"},
+		{"defaultPort", "https://example.com/schema.json", "https://example.com:443/schema.json"},
+		{"defaultPortResource", "http://example.com:80/schema.json", "http://example.com/schema.json"},
+		{"dotSegments", "https://example.com/a/schema.json", "https://example.com/a/b/../c/./../schema.json"},
+		{"percentCase", "https://example.com/my%2Fschema.json", "https://example.com/my%2fschema.json"},
+		{"urn", "urn:example:schema", "URN:example:schema"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := jsonschema.NewCompiler()
+			c.LoadURL = func(s string) (io.ReadCloser, error) {
+				return nil, fmt.Errorf("unexpected load of %s", s)
+			}
+			if err := c.AddResource(test.resource, strings.NewReader(`{"type": "string"}`)); err != nil {
+				t.Fatal(err)
+			}
+			sch, err := c.Compile(test.compile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := sch.Validate(1); err == nil {
+				t.Fatal("want error")
+			}
+		})
+	}
+
+	t.Run("nonDefaultPort", func(t *testing.T) {
+		c := jsonschema.NewCompiler()
+		c.LoadURL = func(s string) (io.ReadCloser, error) {
+			return nil, fmt.Errorf("unexpected load of %s", s)
+		}
+		if err := c.AddResource("https://example.com/schema.json", strings.NewReader(`{}`)); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := c.Compile("https://example.com:8443/schema.json"); err == nil {
+			t.Fatal("want error")
+		}
+	})
+
+	t.Run("pathCase", func(t *testing.T) {
+		c := jsonschema.NewCompiler()
+		c.LoadURL = func(s string) (io.ReadCloser, error) {
+			return nil, fmt.Errorf("unexpected load of %s", s)
+		}
+		if err := c.AddResource("https://example.com/schema.json", strings.NewReader(`{}`)); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := c.Compile("https://example.com/Schema.json"); err == nil {
+			t.Fatal("want error")
+		}
+	})
+}
+
+func TestNormalizeURL_dedup(t *testing.T) {
+	loads := make(map[string]int)
+	c := jsonschema.NewCompiler()
+	c.LoadURL = func(s string) (io.ReadCloser, error) {
+		loads[s]++
+		return ioutil.NopCloser(strings.NewReader(`{
+			"type": "object",
+			"properties": {"name": {"type": "string"}}
+		}`)), nil
+	}
+	schema := `{
+		"allOf": [
+			{"$ref": "HTTPS://Example.com/schema.json"},
+			{"$ref": "https://example.com/schema.json"}
+		],
+		"unevaluatedProperties": false
+	}`
+	if err := c.AddResource("main.json", strings.NewReader(schema)); err != nil {
+		t.Fatal(err)
+	}
+	sch, err := c.Compile("main.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loads) != 1 || loads["https://example.com/schema.json"] != 1 {
+		t.Fatalf("loads: got %v, want one load of normalized url", loads)
+	}
+	if sch.AllOf[0].Ref != sch.AllOf[1].Ref {
+		t.Fatal("refs must resolve to same schema")
+	}
+	if err := sch.Validate(map[string]interface{}{"name": "x"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := sch.Validate(map[string]interface{}{"name": "x", "age": 1}); err == nil {
+		t.Fatal("want error")
+	}
+}
+
+func TestNormalizeURL_display(t *testing.T) {
+	c := jsonschema.NewCompiler()
+	c.LoadURL = func(s string) (io.ReadCloser, error) {
+		return nil, fmt.Errorf("unexpected load of %s", s)
+	}
+	const url = "HTTPS://Example.COM:443/My%2fSchema.json"
+	if err := c.AddResource(url, strings.NewReader(`{"properties": {"a": {"type": "string"}}}`)); err != nil {
+		t.Fatal(err)
+	}
+	sch, err := c.Compile("https://example.com/My%2FSchema.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := url + "#"; sch.Location != want {
+		t.Errorf("Location: got %s, want %s", sch.Location, want)
+	}
+	err = sch.Validate(map[string]interface{}{"a": 1})
+	ve, ok := err.(*jsonschema.ValidationError)
+	if !ok {
+		t.Fatalf("got %v, want *ValidationError", err)
+	}
+	leaf := ve.Causes[0]
+	if want := url + "#/properties/a/type"; leaf.AbsoluteKeywordLocation != want {
+		t.Errorf("AbsoluteKeywordLocation: got %s, want %s", leaf.AbsoluteKeywordLocation, want)
+	}
+	if !strings.Contains(err.Error(), url) {
+		t.Errorf("error must show original url: %v", err)
+	}
+}
+
+func TestNormalizeURL_refMappings(t *testing.T) {
+	var loaded []string
+	c := jsonschema.NewCompiler()
+	c.RefMappings = map[string]string{
+		"HTTPS://Example.COM:443/":        "file:///mirror/",
+		"https://example.com/schemas/v1/": "file:///mirror/v1/",
+	}
+	c.LoadURL = func(s string) (io.ReadCloser, error) {
+		loaded = append(loaded, s)
+		return ioutil.NopCloser(strings.NewReader(`{}`)), nil
+	}
+	for _, url := range []string{"https://example.com/a.json", "https://example.com/schemas/v1/b.json"} {
+		if _, err := c.Compile(url); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := []string{"file:///mirror/a.json", "file:///mirror/v1/b.json"}
+	if fmt.Sprint(loaded) != fmt.Sprint(want) {
+		t.Fatalf("loaded: got %v, want %v", loaded, want)
+	}
+}
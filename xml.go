@@ -0,0 +1,215 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// XMLOptions controls how DecodeXML maps xml to json data model.
+type XMLOptions struct {
+	// DetectTypes converts text and attribute values that are valid
+	// json numbers into json.Number, and "true"/"false" into bool.
+	// By default all values are strings.
+	DetectTypes bool
+
+	// PrefixNamespaces keeps namespace prefixes in names as "prefix:name".
+	// By default namespace prefixes and namespace declarations are stripped.
+	PrefixNamespaces bool
+}
+
+// DecodeXML decodes xml document from r into json data model,
+// so that it can be validated using Schema.Validate.
+//
+// The mapping rules are:
+//   - document is an object with single property named after root element
+//   - attributes are mapped to properties prefixed with "@"
+//   - element with neither attributes nor child elements is mapped to its text
+//   - otherwise element is mapped to object with child elements as properties,
+//     and its non-whitespace text, if any, as "#text" property
+//   - repeated child elements with same name are folded into array,
+//     in document order. single child element is never wrapped in array
+//   - comments, processing instructions and directives are ignored
+//
+// With above rules, instance locations in validation errors can be
+// mapped back to xml. for example "/feed/entry/1/@id" refers to id
+// attribute of second entry element in feed.
+func DecodeXML(r io.Reader, opts XMLOptions) (interface{}, error) {
+	d := xml.NewDecoder(r)
+	for {
+		t, err := d.RawToken()
+		if err == io.EOF {
+			return nil, fmt.Errorf("jsonschema: invalid xml: no root element")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("jsonschema: invalid xml: %v", err)
+		}
+		if se, ok := t.(xml.StartElement); ok {
+			name := opts.name(se.Name)
+			v, err := opts.decodeElement(d, se)
+			if err != nil {
+				return nil, fmt.Errorf("jsonschema: invalid xml: %v", err)
+			}
+			for {
+				t, err := d.RawToken()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					return nil, fmt.Errorf("jsonschema: invalid xml: %v", err)
+				}
+				switch t := t.(type) {
+				case xml.StartElement:
+					return nil, fmt.Errorf("jsonschema: invalid xml: multiple root elements")
+				case xml.CharData:
+					if len(strings.TrimSpace(string(t))) > 0 {
+						return nil, fmt.Errorf("jsonschema: invalid xml: text after root element")
+					}
+				}
+			}
+			return map[string]interface{}{name: v}, nil
+		}
+	}
+}
+
+// decodeElement decodes the element started by se, consuming its end element.
+func (opts XMLOptions) decodeElement(d *xml.Decoder, se xml.StartElement) (interface{}, error) {
+	obj := make(map[string]interface{})
+	for _, attr := range se.Attr {
+		if !opts.PrefixNamespaces && (attr.Name.Space == "xmlns" || attr.Name.Space == "" && attr.Name.Local == "xmlns") {
+			continue
+		}
+		obj["@"+opts.name(attr.Name)] = opts.value(attr.Value)
+	}
+
+	var text strings.Builder
+	var children []string // child names in order of first occurrence
+	for {
+		t, err := d.RawToken()
+		if err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+		switch t := t.(type) {
+		case xml.StartElement:
+			name := opts.name(t.Name)
+			child, err := opts.decodeElement(d, t)
+			if err != nil {
+				return nil, err
+			}
+			prev, ok := obj[name]
+			switch {
+			case !ok:
+				children = append(children, name)
+				obj[name] = child
+			case isXMLArray(prev):
+				arr := prev.(*xmlArray)
+				arr.items = append(arr.items, child)
+			default:
+				obj[name] = &xmlArray{[]interface{}{prev, child}}
+			}
+		case xml.EndElement:
+			if t.Name != se.Name {
+				return nil, fmt.Errorf("element <%s> closed by </%s>", rawName(se.Name), rawName(t.Name))
+			}
+			for _, name := range children {
+				if arr, ok := obj[name].(*xmlArray); ok {
+					obj[name] = arr.items
+				}
+			}
+			s := strings.TrimSpace(text.String())
+			if len(obj) == 0 {
+				return opts.value(s), nil
+			}
+			if s != "" {
+				obj["#text"] = opts.value(s)
+			}
+			return obj, nil
+		case xml.CharData:
+			text.Write(t)
+		}
+	}
+}
+
+// xmlArray collects repeated child elements, so that they
+// can be distinguished from single child element with
+// text that happens to be array.
+type xmlArray struct {
+	items []interface{}
+}
+
+func isXMLArray(v interface{}) bool {
+	_, ok := v.(*xmlArray)
+	return ok
+}
+
+func (opts XMLOptions) name(n xml.Name) string {
+	if opts.PrefixNamespaces && n.Space != "" {
+		return n.Space + ":" + n.Local
+	}
+	return n.Local
+}
+
+func (opts XMLOptions) value(s string) interface{} {
+	if opts.DetectTypes {
+		switch {
+		case s == "true":
+			return true
+		case s == "false":
+			return false
+		case isJSONNumber(s):
+			return json.Number(s)
+		}
+	}
+	return s
+}
+
+func rawName(n xml.Name) string {
+	if n.Space != "" {
+		return n.Space + ":" + n.Local
+	}
+	return n.Local
+}
+
+// isJSONNumber tells whether s is a number as per json grammar.
+//
+// see https://datatracker.ietf.org/doc/html/rfc8259#section-6
+func isJSONNumber(s string) bool {
+	i := 0
+	if i < len(s) && s[i] == '-' {
+		i++
+	}
+	digits := func() int {
+		start := i
+		for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+			i++
+		}
+		return i - start
+	}
+	switch {
+	case i < len(s) && s[i] == '0':
+		i++
+	case digits() == 0:
+		return false
+	}
+	if i < len(s) && s[i] == '.' {
+		i++
+		if digits() == 0 {
+			return false
+		}
+	}
+	if i < len(s) && (s[i] == 'e' || s[i] == 'E') {
+		i++
+		if i < len(s) && (s[i] == '+' || s[i] == '-') {
+			i++
+		}
+		if digits() == 0 {
+			return false
+		}
+	}
+	return i == len(s)
+}
@@ -0,0 +1,102 @@
+package jsonschema_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+func TestDecodeXML(t *testing.T) {
+	tests := []struct {
+		golden string
+		opts   jsonschema.XMLOptions
+	}{
+		{"testdata/xml/feed.json", jsonschema.XMLOptions{}},
+		{"testdata/xml/feed_types.json", jsonschema.XMLOptions{DetectTypes: true}},
+		{"testdata/xml/feed_ns.json", jsonschema.XMLOptions{PrefixNamespaces: true}},
+	}
+	for _, test := range tests {
+		t.Run(test.golden, func(t *testing.T) {
+			f, err := os.Open("testdata/xml/feed.xml")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+			v, err := jsonschema.DecodeXML(f, test.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := json.MarshalIndent(v, "", "  ")
+			if err != nil {
+				t.Fatal(err)
+			}
+			want, err := ioutil.ReadFile(test.golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(bytes.TrimSpace(got), bytes.TrimSpace(want)) {
+				t.Fatalf("got:\n%s\nwant:\n%s", got, want)
+			}
+		})
+	}
+}
+
+func TestDecodeXML_invalid(t *testing.T) {
+	tests := []string{
+		``,
+		`<!-- only comment -->`,
+		`<a><b></a>`,
+		`<a></a><b></b>`,
+		`<a></a>text`,
+		`<a>`,
+	}
+	for _, test := range tests {
+		if _, err := jsonschema.DecodeXML(strings.NewReader(test), jsonschema.XMLOptions{}); err == nil {
+			t.Errorf("%q: error expected", test)
+		}
+	}
+}
+
+func TestDecodeXML_validate(t *testing.T) {
+	sch, err := jsonschema.CompileString("feed.json", `{
+		"properties": {
+			"feed": {
+				"properties": {
+					"entry": {
+						"type": "array",
+						"items": {
+							"properties": {
+								"@id": {"type": "integer"},
+								"price": {"required": ["@currency", "#text"]}
+							}
+						}
+					}
+				}
+			}
+		}
+	}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v, err := jsonschema.DecodeXML(strings.NewReader(`
+		<feed>
+			<entry id="1"><price currency="USD">1</price></entry>
+			<entry id="x"><price currency="USD">1</price></entry>
+		</feed>`), jsonschema.XMLOptions{DetectTypes: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = sch.Validate(v)
+	ve, ok := err.(*jsonschema.ValidationError)
+	if !ok {
+		t.Fatalf("got %v, want *ValidationError", err)
+	}
+	if got := ve.BasicOutput().Errors; got[len(got)-1].InstanceLocation != "/feed/entry/1/@id" {
+		t.Fatalf("got %#v", ve)
+	}
+}